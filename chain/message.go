@@ -31,6 +31,24 @@ type Message struct {
 	GasPremium int64      `json:"gas_premium"`
 	Method     uint64     `json:"method"`
 	Params     ParamsInfo `json:"params"`
+	// Simulation is the dry run of the message at build time, if requested
+	Simulation *Simulation `json:"simulation,omitempty"`
+}
+
+// Simulation is the result of running an unsigned message through StateCall
+type Simulation struct {
+	ExitCode int64 `json:"exit_code"`
+	GasUsed  int64 `json:"gas_used"`
+	// Return is the return value decoded as json when its type is known, hex otherwise
+	Return string `json:"return"`
+	// Error is the actor error of the message or of the msig proposal it executed
+	Error string `json:"error"`
+	// Height is the head the message was run at
+	Height int64 `json:"height"`
+}
+
+func (s *Simulation) Failed() bool {
+	return s.ExitCode != 0 || s.Error != ""
 }
 
 type ParamsInfo struct {
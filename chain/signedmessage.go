@@ -9,19 +9,22 @@ import (
 )
 
 // SignedMessage : Signature use CBOR encoding and conforms to lotus spec
-//   sign := crypto.Signature{
-//	   Type: crypto.SigTypeSecp256k1,
-//	   Data: []byte{1, 2, 3, 4},
-//   }
 //
-//   var buf bytes.Buffer
-//   sign.MarshalCBOR(&buf)
-//   signedMsg := SignedMessage{
-//	   Signature: buf.String(),
-//   }
+//	  sign := crypto.Signature{
+//		   Type: crypto.SigTypeSecp256k1,
+//		   Data: []byte{1, 2, 3, 4},
+//	  }
+//
+//	  var buf bytes.Buffer
+//	  sign.MarshalCBOR(&buf)
+//	  signedMsg := SignedMessage{
+//		   Signature: buf.String(),
+//	  }
 type SignedMessage struct {
 	Message   Message `json:"message"`
 	Signature string  `json:"signature"`
+	// Warning is set by the signer, e.g. when the nonce was already signed for another message
+	Warning string `json:"warning,omitempty"`
 }
 
 func (m *SignedMessage) String() string {
@@ -2,6 +2,7 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,32 +13,28 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 )
 
 var log = logging.Logger("client")
 
+// UnixSocketPrefix marks an endpoint served on a local Unix socket
+const UnixSocketPrefix = "unix://"
+
 type OpenFilAPI struct {
 	endpoint string
 	token    string
 }
 
 func GetOpenFilAPI(ctx *cli.Context) (*OpenFilAPI, error) {
-	repoPath := ctx.String(repo.FlagWalletRepo)
-	r, err := repo.NewFS(repoPath)
-	if err != nil {
-		return nil, err
-	}
-
-	ok, err := r.Exists()
+	r, err := openRepo(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
-		return nil, fmt.Errorf("repo at '%s' is not initialized, run 'openfild init' to set it up", repo.FlagWalletRepo)
-	}
 
 	endpoint, err := r.APIEndpoint()
 	if err != nil {
@@ -48,9 +45,20 @@ func GetOpenFilAPI(ctx *cli.Context) (*OpenFilAPI, error) {
 		return nil, err
 	}
 
-	token, err := r.APIToken()
-	if err != nil {
-		return nil, err
+	// the api socket is authenticated by its file permissions, no token is needed
+	var token []byte
+	if !strings.HasPrefix(endpoint, UnixSocketPrefix) {
+		// the token of the user logged in with login --user comes before the operator token of the repo
+		token, err = r.UserToken()
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			token, err = r.APIToken()
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return &OpenFilAPI{
@@ -59,6 +67,34 @@ func GetOpenFilAPI(ctx *cli.Context) (*OpenFilAPI, error) {
 	}, nil
 }
 
+// SetUserToken stores the token the next cli calls use, an empty token goes back to the operator token of the repo
+func SetUserToken(ctx *cli.Context, token string) error {
+	r, err := openRepo(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.SetUserToken([]byte(token))
+}
+
+func openRepo(ctx *cli.Context) (*repo.FsRepo, error) {
+	repoPath := ctx.String(repo.FlagWalletRepo)
+	r, err := repo.NewFS(repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := r.Exists()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("repo at '%s' is not initialized, run 'openfild init' to set it up", repo.FlagWalletRepo)
+	}
+
+	return r, nil
+}
+
 func (api *OpenFilAPI) Status() (*StatusInfo, error) {
 	res, err := GetRequest(api.endpoint, "/status", api.token, nil)
 	if err != nil {
@@ -73,23 +109,25 @@ func (api *OpenFilAPI) Status() (*StatusInfo, error) {
 	return &si, nil
 }
 
-func (api *OpenFilAPI) Login(loginPassword string) error {
+// Login unlocks the wallet and returns the token issued to user, or to the login password when user is empty
+func (api *OpenFilAPI) Login(user, loginPassword string) (string, error) {
 	req := LoginRequest{
-		loginPassword,
+		User:          user,
+		LoginPassword: loginPassword,
 	}
 
 	res, err := PostRequest(api.endpoint, "/login", api.token, req)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	var li LoginInfo
 	err = json.Unmarshal(res, &li)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return li.Token, nil
 }
 
 func (api *OpenFilAPI) SignOut() error {
@@ -243,6 +281,20 @@ func (api *OpenFilAPI) NodeList() ([]NodeInfo, error) {
 	return nis, nil
 }
 
+func (api *OpenFilAPI) NodeHealth() ([]NodeHealthInfo, error) {
+	res, err := GetRequest(api.endpoint, "/node/health", api.token, nil)
+	if err != nil {
+		return nil, err
+	}
+	var nhs []NodeHealthInfo
+	err = json.Unmarshal(res, &nhs)
+	if err != nil {
+		return nil, err
+	}
+
+	return nhs, nil
+}
+
 func (api *OpenFilAPI) NodeBest() (*NodeInfo, error) {
 	res, err := GetRequest(api.endpoint, "/node/best", api.token, nil)
 	if err != nil {
@@ -385,6 +437,116 @@ func (api *OpenFilAPI) Balance(addr string) (*BalanceInfo, error) {
 	return &r, nil
 }
 
+func (api *OpenFilAPI) Nonce(addr string) (*NonceInfo, error) {
+	res, err := GetRequest(api.endpoint, "/nonce", api.token, map[string]string{"address": addr})
+	if err != nil {
+		return nil, err
+	}
+
+	var r NonceInfo
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) NonceReset(addr string) error {
+	req := NonceRequest{
+		Address: addr,
+	}
+
+	res, err := PostRequest(api.endpoint, "/nonce/reset", api.token, req)
+	if err != nil {
+		return err
+	}
+
+	var r Response
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return err
+	}
+
+	if r.Code != 200 {
+		return errors.New(r.Message)
+	}
+
+	return nil
+}
+
+func (api *OpenFilAPI) WatchList() ([]WatchInfo, error) {
+	res, err := GetRequest(api.endpoint, "/watch/list", api.token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []WatchInfo
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (api *OpenFilAPI) WatchAdd(req WatchRequest) error {
+	return api.watchPost("/watch/add", req)
+}
+
+func (api *OpenFilAPI) WatchRemove(addr string) error {
+	return api.watchPost("/watch/remove", WatchRequest{Address: addr})
+}
+
+func (api *OpenFilAPI) watchPost(relativePath string, req WatchRequest) error {
+	res, err := PostRequest(api.endpoint, relativePath, api.token, req)
+	if err != nil {
+		return err
+	}
+
+	var r Response
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return err
+	}
+
+	if r.Code != 200 {
+		return errors.New(r.Message)
+	}
+
+	return nil
+}
+
+func (api *OpenFilAPI) IndexerStatus() (*IndexerStatus, error) {
+	res, err := GetRequest(api.endpoint, "/indexer/status", api.token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r IndexerStatus
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) FeeEstimate(req FeeEstimateRequest) (*FeeEstimateInfo, error) {
+	res, err := PostRequest(api.endpoint, "/fee/estimate", api.token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r FeeEstimateInfo
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 func (api *OpenFilAPI) Transfer(baseParams buildmessage.BaseParams, from, to, amount string) (*chain.Message, error) {
 	req := TransferRequest{
 		BaseParams: baseParams,
@@ -427,18 +589,70 @@ func (api *OpenFilAPI) Send(req chain.SignedMessage) (string, error) {
 }
 
 func (api *OpenFilAPI) TxHistory(addr string) ([]HistoryResponse, error) {
-	res, err := GetRequest(api.endpoint, "/tx_history", api.token, map[string]string{"address": addr})
+	page, err := api.TxHistoryQuery(HistoryQuery{Addresses: []string{addr}})
 	if err != nil {
 		return nil, err
 	}
 
-	var r []HistoryResponse
+	return page.Records, nil
+}
+
+func (api *OpenFilAPI) TxHistoryQuery(q HistoryQuery) (*HistoryPage, error) {
+	params := map[string]string{}
+	setParam := func(key, value string) {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	setInt := func(key string, value int64) {
+		if value != 0 {
+			params[key] = strconv.FormatInt(value, 10)
+		}
+	}
+
+	setParam("address", strings.Join(q.Addresses, ","))
+	setParam("method", strings.Join(q.Methods, ","))
+	setParam("status", strings.Join(q.Status, ","))
+	setParam("direction", strings.Join(q.Directions, ","))
+	setParam("counterparty", q.Counterparty)
+	setInt("min_epoch", q.MinEpoch)
+	setInt("max_epoch", q.MaxEpoch)
+	setInt("since", q.Since)
+	setInt("until", q.Until)
+	setParam("sort", q.Sort)
+	if q.Desc {
+		params["order"] = "desc"
+	}
+	setInt("limit", int64(q.Limit))
+	setParam("cursor", q.Cursor)
+
+	res, err := GetRequest(api.endpoint, "/tx_history", api.token, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var r HistoryPage
 	err = json.Unmarshal(res, &r)
 	if err != nil {
 		return nil, err
 	}
 
-	return r, nil
+	return &r, nil
+}
+
+func (api *OpenFilAPI) Simulate(req chain.Message) (*chain.Simulation, error) {
+	res, err := PostRequest(api.endpoint, "/simulate", api.token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r chain.Simulation
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
 }
 
 func (api *OpenFilAPI) Sign(req chain.Message) (*chain.SignedMessage, error) {
@@ -490,11 +704,14 @@ func (api *OpenFilAPI) SignAndSend(req chain.Message) (string, error) {
 	return r.Message, nil
 }
 
-func (api *OpenFilAPI) Withdraw(baseParams buildmessage.BaseParams, minerId, amount string) (*chain.Message, error) {
+// Withdraw is sent by sender, owner or beneficiary, from proposes it when the sender is a multisig
+func (api *OpenFilAPI) Withdraw(baseParams buildmessage.BaseParams, minerId, amount, sender, from string) (*chain.Message, error) {
 	req := WithdrawRequest{
 		BaseParams: baseParams,
 		MinerId:    minerId,
 		Amount:     amount,
+		Sender:     sender,
+		From:       from,
 	}
 	res, err := PostRequest(api.endpoint, "/miner/withdraw", api.token, req)
 	if err != nil {
@@ -510,12 +727,28 @@ func (api *OpenFilAPI) Withdraw(baseParams buildmessage.BaseParams, minerId, amo
 	return &r, nil
 }
 
-func (api *OpenFilAPI) ChangeOwner(baseParams buildmessage.BaseParams, minerId string, newOwner string, from string) (*chain.Message, error) {
+func (api *OpenFilAPI) CreateMiner(req CreateMinerRequest) (*chain.Message, error) {
+	res, err := PostRequest(api.endpoint, "/miner/create", api.token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r chain.Message
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) ChangeOwner(baseParams buildmessage.BaseParams, minerId string, newOwner string, from string, autoConfirm bool) (*chain.Message, error) {
 	req := ChangeOwnerRequest{
-		BaseParams: baseParams,
-		MinerId:    minerId,
-		NewOwner:   newOwner,
-		From:       from,
+		BaseParams:  baseParams,
+		MinerId:     minerId,
+		NewOwner:    newOwner,
+		From:        from,
+		AutoConfirm: autoConfirm,
 	}
 	res, err := PostRequest(api.endpoint, "/miner/change_owner", api.token, req)
 	if err != nil {
@@ -531,11 +764,12 @@ func (api *OpenFilAPI) ChangeOwner(baseParams buildmessage.BaseParams, minerId s
 	return &r, nil
 }
 
-func (api *OpenFilAPI) ChangeWorker(baseParams buildmessage.BaseParams, minerId, newWorker string) (*chain.Message, error) {
+func (api *OpenFilAPI) ChangeWorker(baseParams buildmessage.BaseParams, minerId, newWorker string, autoConfirm bool) (*chain.Message, error) {
 	req := ChangeWorkerRequest{
-		BaseParams: baseParams,
-		MinerId:    minerId,
-		NewWorker:  newWorker,
+		BaseParams:  baseParams,
+		MinerId:     minerId,
+		NewWorker:   newWorker,
+		AutoConfirm: autoConfirm,
 	}
 
 	res, err := PostRequest(api.endpoint, "/miner/change_worker", api.token, req)
@@ -573,7 +807,7 @@ func (api *OpenFilAPI) ConfirmChangeWorker(baseParams buildmessage.BaseParams, m
 	return &r, nil
 }
 
-func (api *OpenFilAPI) ChangeBeneficiary(baseParams buildmessage.BaseParams, minerId, beneficiaryAddress, quota, expiration string, OverwritePendingChange bool) (*chain.Message, error) {
+func (api *OpenFilAPI) ChangeBeneficiary(baseParams buildmessage.BaseParams, minerId, beneficiaryAddress, quota, expiration string, OverwritePendingChange bool, autoConfirm bool) (*chain.Message, error) {
 	req := ChangeBeneficiaryRequest{
 		BaseParams:             baseParams,
 		MinerId:                minerId,
@@ -581,6 +815,7 @@ func (api *OpenFilAPI) ChangeBeneficiary(baseParams buildmessage.BaseParams, min
 		Quota:                  quota,
 		Expiration:             expiration,
 		OverwritePendingChange: OverwritePendingChange,
+		AutoConfirm:            autoConfirm,
 	}
 
 	res, err := PostRequest(api.endpoint, "/miner/change_beneficiary", api.token, req)
@@ -653,6 +888,148 @@ func (api *OpenFilAPI) ControlList(minerId string) (*MinerControl, error) {
 	return &r, nil
 }
 
+func (api *OpenFilAPI) MinerInfo(minerIds []string) ([]MinerInfo, error) {
+	res, err := GetRequest(api.endpoint, "/miner/info", api.token, map[string]string{"miner_id": strings.Join(minerIds, ",")})
+	if err != nil {
+		return nil, err
+	}
+
+	var r []MinerInfo
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// MinerVesting projects the unlocks of the vesting funds of the miners, interval is day or week
+func (api *OpenFilAPI) MinerVesting(minerIds []string, interval string, days int) (*VestingSchedule, error) {
+	res, err := GetRequest(api.endpoint, "/miner/vesting", api.token, map[string]string{
+		"actor":    strings.Join(minerIds, ","),
+		"interval": interval,
+		"days":     strconv.Itoa(days),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r VestingSchedule
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) AutomationRules() ([]AutomationRule, error) {
+	res, err := GetRequest(api.endpoint, "/automation/rules", api.token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []AutomationRule
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// AutomationRuleSet adds the rule or updates the rule with the same id
+func (api *OpenFilAPI) AutomationRuleSet(rule AutomationRule) (*AutomationRule, error) {
+	res, err := PostRequest(api.endpoint, "/automation/rules/set", api.token, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var r AutomationRule
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) AutomationRuleRemove(id string) error {
+	res, err := PostRequest(api.endpoint, "/automation/rules/remove", api.token, AutomationRuleRemoveRequest{ID: id})
+	if err != nil {
+		return err
+	}
+
+	var r Response
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return err
+	}
+
+	if r.Code != 200 {
+		return errors.New(r.Message)
+	}
+
+	return nil
+}
+
+// AutomationRuns returns the runs newest first, an empty ruleId returns the runs of all rules, limit 0 returns all
+func (api *OpenFilAPI) AutomationRuns(ruleId string, limit int) ([]AutomationRun, error) {
+	res, err := GetRequest(api.endpoint, "/automation/runs", api.token, map[string]string{
+		"rule_id": ruleId,
+		"limit":   strconv.Itoa(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r []AutomationRun
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Workflows returns the miner workflows, an empty minerId returns the ones of all miners, all adds the done and cancelled ones
+func (api *OpenFilAPI) Workflows(minerId string, all bool) ([]Workflow, error) {
+	res, err := GetRequest(api.endpoint, "/workflow/list", api.token, map[string]string{
+		"miner_id": minerId,
+		"all":      strconv.FormatBool(all),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var r []Workflow
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (api *OpenFilAPI) WorkflowCancel(id string) error {
+	res, err := PostRequest(api.endpoint, "/workflow/cancel", api.token, WorkflowCancelRequest{ID: id})
+	if err != nil {
+		return err
+	}
+
+	var r Response
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return err
+	}
+
+	if r.Code != 200 {
+		return errors.New(r.Message)
+	}
+
+	return nil
+}
+
 func (api *OpenFilAPI) MsigInspect(msigAddress string) (*MsigInspect, error) {
 	res, err := GetRequest(api.endpoint, "/msig/inspect", api.token, map[string]string{"msig_address": msigAddress})
 	if err != nil {
@@ -738,12 +1115,13 @@ func (api *OpenFilAPI) MsigUpdate(msigAddress string) error {
 	return nil
 }
 
-func (api *OpenFilAPI) MsigApprove(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string) (*chain.Message, error) {
+func (api *OpenFilAPI) MsigApprove(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string, expect buildmessage.TxnExpectation) (*chain.Message, error) {
 	req := MsigBaseRequest{
 		BaseParams:  baseParams,
 		From:        from,
 		MsigAddress: msigAddress,
 		TxId:        txId,
+		Expect:      expect,
 	}
 
 	res, err := PostRequest(api.endpoint, "/msig/approve", api.token, req)
@@ -760,12 +1138,13 @@ func (api *OpenFilAPI) MsigApprove(baseParams buildmessage.BaseParams, from stri
 	return &r, nil
 }
 
-func (api *OpenFilAPI) MsigCancel(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string) (*chain.Message, error) {
+func (api *OpenFilAPI) MsigCancel(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string, expect buildmessage.TxnExpectation) (*chain.Message, error) {
 	req := MsigBaseRequest{
 		BaseParams:  baseParams,
 		From:        from,
 		MsigAddress: msigAddress,
 		TxId:        txId,
+		Expect:      expect,
 	}
 
 	res, err := PostRequest(api.endpoint, "/msig/cancel", api.token, req)
@@ -805,12 +1184,13 @@ func (api *OpenFilAPI) MsigTransferPropose(baseParams buildmessage.BaseParams, f
 	return &r, nil
 }
 
-func (api *OpenFilAPI) MsigTransferApprove(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string) (*chain.Message, error) {
+func (api *OpenFilAPI) MsigTransferApprove(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string, expect buildmessage.TxnExpectation) (*chain.Message, error) {
 	req := MsigBaseRequest{
 		BaseParams:  baseParams,
 		From:        from,
 		MsigAddress: msigAddress,
 		TxId:        txId,
+		Expect:      expect,
 	}
 
 	res, err := PostRequest(api.endpoint, "/msig/transfer_approve", api.token, req)
@@ -827,12 +1207,13 @@ func (api *OpenFilAPI) MsigTransferApprove(baseParams buildmessage.BaseParams, f
 	return &r, nil
 }
 
-func (api *OpenFilAPI) MsigTransferCancel(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string) (*chain.Message, error) {
+func (api *OpenFilAPI) MsigTransferCancel(baseParams buildmessage.BaseParams, from string, msigAddress string, txId string, expect buildmessage.TxnExpectation) (*chain.Message, error) {
 	req := MsigBaseRequest{
 		BaseParams:  baseParams,
 		From:        from,
 		MsigAddress: msigAddress,
 		TxId:        txId,
+		Expect:      expect,
 	}
 
 	res, err := PostRequest(api.endpoint, "/msig/transfer_cancel", api.token, req)
@@ -921,6 +1302,78 @@ func (api *OpenFilAPI) MsigAddCancel(baseParams buildmessage.BaseParams, from st
 	return &r, nil
 }
 
+func (api *OpenFilAPI) MsigRemovePropose(baseParams buildmessage.BaseParams, from string, msigAddress string, signer string, dec bool) (*chain.Message, error) {
+	req := MsigRemoveSignerProposeRequest{
+		BaseParams:        baseParams,
+		From:              from,
+		MsigAddress:       msigAddress,
+		SignerAddress:     signer,
+		DecreaseThreshold: dec,
+	}
+
+	res, err := PostRequest(api.endpoint, "/msig/remove_signer_propose", api.token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r chain.Message
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) MsigRemoveApprove(baseParams buildmessage.BaseParams, from string, msigAddress, proposerAddress, txId, signer string, dec bool) (*chain.Message, error) {
+	req := MsigRemoveSignerApproveRequest{
+		BaseParams:        baseParams,
+		From:              from,
+		MsigAddress:       msigAddress,
+		ProposerAddress:   proposerAddress,
+		TxId:              txId,
+		SignerAddress:     signer,
+		DecreaseThreshold: dec,
+	}
+
+	res, err := PostRequest(api.endpoint, "/msig/remove_signer_approve", api.token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r chain.Message
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+func (api *OpenFilAPI) MsigRemoveCancel(baseParams buildmessage.BaseParams, from string, msigAddress, txId, signer string, dec bool) (*chain.Message, error) {
+	req := MsigRemoveSignerCancelRequest{
+		BaseParams:        baseParams,
+		From:              from,
+		MsigAddress:       msigAddress,
+		TxId:              txId,
+		SignerAddress:     signer,
+		DecreaseThreshold: dec,
+	}
+
+	res, err := PostRequest(api.endpoint, "/msig/remove_signer_cancel", api.token, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var r chain.Message
+	err = json.Unmarshal(res, &r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &r, nil
+}
+
 func (api *OpenFilAPI) MsigSwapPropose(baseParams buildmessage.BaseParams, from string, msigAddress, oldAddress, newAddress string) (*chain.Message, error) {
 	req := MsigSwapProposeRequest{
 		BaseParams:  baseParams,
@@ -1501,23 +1954,25 @@ func PostRequest(endpoint, relativePath string, token string, params interface{}
 		return nil, err
 	}
 
-	// The login request contains the login password,
-	// which is sensitive information, skip it
-	if relativePath != "/login" {
+	// The login and unseal requests contain passwords,
+	// which are sensitive information, skip them
+	if relativePath != "/login" && relativePath != "/unseal" {
 		log.Debugw("start PostRequest", "relativePath", relativePath, "params", string(dataByte))
 	}
 
-	req, err := http.NewRequest("POST", urlJoin(endpoint, relativePath), bytes.NewBuffer(dataByte))
+	base, httpClient := endpointClient(endpoint)
+	req, err := http.NewRequest("POST", urlJoin(base, relativePath), bytes.NewBuffer(dataByte))
 	if err != nil {
 		return nil, err
 	}
 
-	return Call(req, token)
+	return call(httpClient, req, token)
 }
 
 // GetRequest http get request
 func GetRequest(endpoint, relativePath string, token string, params map[string]string) ([]byte, error) {
-	u, err := url.Parse(urlJoin(endpoint, relativePath))
+	base, httpClient := endpointClient(endpoint)
+	u, err := url.Parse(urlJoin(base, relativePath))
 	if err != nil {
 		return nil, err
 	}
@@ -1536,17 +1991,38 @@ func GetRequest(endpoint, relativePath string, token string, params map[string]s
 		return nil, err
 	}
 
-	return Call(req, token)
+	return call(httpClient, req, token)
 }
 
 func Call(req *http.Request, token string) ([]byte, error) {
+	return call(&http.Client{}, req, token)
+}
+
+// endpointClient returns the base url and the http client for endpoint,
+// an endpoint like unix:///path/to/socket is reached over the Unix socket
+func endpointClient(endpoint string) (string, *http.Client) {
+	if !strings.HasPrefix(endpoint, UnixSocketPrefix) {
+		return endpoint, &http.Client{}
+	}
+
+	socketPath := strings.TrimPrefix(endpoint, UnixSocketPrefix)
+	return "http://unix", &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+}
+
+func call(client *http.Client, req *http.Request, token string) ([]byte, error) {
 	req.Header.Set("Content-Type", "application/json")
 
 	if len(strings.Trim(token, " ")) > 0 {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
 
-	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Debugf("end of request: %s", err.Error())
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"encoding/json"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
 )
 
@@ -10,9 +12,15 @@ type Response struct {
 }
 
 type LoginRequest struct {
+	// User is empty when logging in with the login password
+	User          string `json:"user"`
 	LoginPassword string `json:"login_password"`
 }
 
+type UnsealRequest struct {
+	MasterPassword string `json:"master_password"`
+}
+
 type NodeRequest struct {
 	Name     string `json:"name"`
 	Endpoint string `json:"endpoint"`
@@ -25,6 +33,30 @@ type NodeInfo struct {
 	Token       string `json:"token"`
 	IsUsing     bool   `json:"isUsing"`
 	BlockHeight string `json:"blockHeight"`
+	Version     string `json:"version"`
+	Network     string `json:"network"`
+	Latency     int64  `json:"latency"` // milliseconds
+	Error       string `json:"error"`
+}
+
+type NodeHealth struct {
+	Name      string `json:"name"`
+	Healthy   bool   `json:"healthy"`
+	Latency   int64  `json:"latency"` // milliseconds
+	Height    int64  `json:"height"`
+	HeadLag   int64  `json:"headLag"` // epochs behind the wall clock
+	Version   string `json:"version"`
+	Network   string `json:"network"`
+	Error     string `json:"error"`
+	CheckedAt int64  `json:"checkedAt"` // unix seconds
+}
+
+type NodeHealthInfo struct {
+	Name     string       `json:"name"`
+	Endpoint string       `json:"endpoint"`
+	IsUsing  bool         `json:"isUsing"`
+	IsPinned bool         `json:"isPinned"`
+	History  []NodeHealth `json:"history"`
 }
 
 type WalletListInfo struct {
@@ -59,7 +91,62 @@ type TransferRequest struct {
 	Amount     string                  `json:"amount"`
 }
 
+type FeeEstimateRequest struct {
+	From string `json:"from"`
+	// To, Value, Method and Params describe the message the gas limit is estimated for, unless GasLimit is set
+	To       string `json:"to"`
+	Value    string `json:"value"`
+	Method   uint64 `json:"method"`
+	Params   string `json:"params"` // hex
+	GasLimit int64  `json:"gas_limit"`
+}
+
+type FeeEstimateInfo struct {
+	// parent base fees in attoFIL, the range is over the recent tipsets
+	BaseFee       string              `json:"base_fee"`
+	BaseFeeMin    string              `json:"base_fee_min"`
+	BaseFeeMedian string              `json:"base_fee_median"`
+	BaseFeeMax    string              `json:"base_fee_max"`
+	Presets       []FeePresetEstimate `json:"presets"`
+}
+
+type FeePresetEstimate struct {
+	Preset     string `json:"preset"`
+	GasLimit   int64  `json:"gas_limit"`
+	GasFeeCap  string `json:"gas_feecap"`  // attoFIL
+	GasPremium string `json:"gas_premium"` // attoFIL
+	// WorstCaseFee, ExpectedBurn and ExpectedFee are in FIL
+	WorstCaseFee string `json:"worst_case_fee"`
+	ExpectedBurn string `json:"expected_burn"`
+	ExpectedFee  string `json:"expected_fee"`
+}
+
+type HistoryQuery struct {
+	// Addresses empty queries every owned and watched address
+	Addresses    []string
+	Methods      []string // method numbers or names
+	Status       []string // pending, success, failed
+	Directions   []string // out, in, self
+	Counterparty string
+	MinEpoch     int64
+	MaxEpoch     int64
+	Since        int64  // unix seconds
+	Until        int64  // unix seconds
+	Sort         string // epoch, time, value, nonce
+	Desc         bool
+	Limit        int
+	Cursor       string
+}
+
+type HistoryPage struct {
+	Records []HistoryResponse `json:"records"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor"`
+}
+
 type HistoryResponse struct {
+	// Address is the owned or watched address the record belongs to
+	Address    string `json:"address"`
 	Version    uint64 `json:"version"`
 	To         string `json:"to"`
 	From       string `json:"from"`
@@ -68,16 +155,49 @@ type HistoryResponse struct {
 	GasLimit   int64  `json:"gas_limit"`
 	GasFeeCap  int64  `json:"gas_feecap"`
 	GasPremium int64  `json:"gas_premium"`
-	Method     uint64 `json:"method"`
-	Params     string `json:"params"`
-	TxCid      string `json:"tx_cid"`
-	TxState    string `json:"tx_state"`
+	// AttoValue, AttoGasFeeCap and AttoGasPremium are in attoFIL, the int64 fields overflow above ~9.22 FIL
+	AttoValue      string `json:"atto_value"`
+	AttoGasFeeCap  string `json:"atto_gas_feecap"`
+	AttoGasPremium string `json:"atto_gas_premium"`
+	Method         uint64 `json:"method"`
+	Params         string `json:"params"`
+	TxCid          string `json:"tx_cid"`
+	TxState        string `json:"tx_state"`
+	// Direction is out, in or self, Epoch is 0 while the message is not found on chain
+	Direction       string `json:"direction"`
+	SubmitTime      int64  `json:"submit_time"` // unix seconds
+	Epoch           int64  `json:"epoch"`
+	InclusionEpoch  int64  `json:"inclusion_epoch"`
+	InclusionTipSet string `json:"inclusion_tipset"`
+	Timestamp       int64  `json:"timestamp"` // unix seconds
+	ExitCode        int64  `json:"exit_code"`
+	GasUsed         int64  `json:"gas_used"`
+	MethodName      string `json:"method_name"`
+	// BaseFeeBurn, OverEstimationBurn and MinerTip are in attoFIL
+	BaseFeeBurn        string `json:"base_fee_burn"`
+	OverEstimationBurn string `json:"over_estimation_burn"`
+	MinerTip           string `json:"miner_tip"`
+	Return             string `json:"return"`
 }
 
 type WithdrawRequest struct {
 	BaseParams buildmessage.BaseParams `json:"base_params"`
 	MinerId    string                  `json:"miner_id"`
 	Amount     string                  `json:"amount"`
+	// Sender is owner or beneficiary (the default), From is the signer proposing when the sender is a multisig
+	Sender string `json:"sender"`
+	From   string `json:"from"`
+}
+
+type CreateMinerRequest struct {
+	BaseParams buildmessage.BaseParams `json:"base_params"`
+	Owner      string                  `json:"owner"`
+	Worker     string                  `json:"worker"`
+	SectorSize string                  `json:"sector_size"`
+	PeerId     string                  `json:"peer_id"`
+	Multiaddrs []string                `json:"multiaddrs"`
+	// From sends the message and defaults to the owner, it is the signer proposing when the owner is a multisig
+	From string `json:"from"`
 }
 
 type ChangeOwnerRequest struct {
@@ -85,6 +205,8 @@ type ChangeOwnerRequest struct {
 	MinerId    string                  `json:"miner_id"`
 	NewOwner   string                  `json:"new_owner"`
 	From       string                  `json:"from"`
+	// AutoConfirm builds the confirm message of the new owner once the change is on chain
+	AutoConfirm bool `json:"auto_confirm"`
 }
 
 type ChangeWorkerRequest struct {
@@ -92,6 +214,8 @@ type ChangeWorkerRequest struct {
 	MinerId         string                  `json:"miner_id"`
 	NewWorker       string                  `json:"new_worker"`
 	NewControlAddrs []string                `json:"new_controlAddrs"`
+	// AutoConfirm builds the confirm message once the worker change delay has passed
+	AutoConfirm bool `json:"auto_confirm"`
 }
 
 type ConfirmChangeWorkerRequest struct {
@@ -107,6 +231,8 @@ type ChangeBeneficiaryRequest struct {
 	Quota                  string                  `json:"quota"`
 	Expiration             string                  `json:"expiration"`
 	OverwritePendingChange bool                    `json:"overwrite_pending_change"`
+	// AutoConfirm builds the confirm message of the next approver once the proposal is on chain
+	AutoConfirm bool `json:"auto_confirm"`
 }
 
 type ConfirmChangeBeneficiaryRequest struct {
@@ -114,6 +240,87 @@ type ConfirmChangeBeneficiaryRequest struct {
 	MinerId    string                  `json:"miner_id"`
 }
 
+type NonceRequest struct {
+	Address string `json:"address"`
+}
+
+type NonceInfo struct {
+	Address string `json:"address"`
+	// ChainNonce and MpoolNonce are 0 on a wallet without node
+	ChainNonce uint64 `json:"chain_nonce"`
+	MpoolNonce uint64 `json:"mpool_nonce"`
+	// Next is the nonce the next built message gets
+	Next     uint64          `json:"next"`
+	Reserved []ReservedNonce `json:"reserved"`
+	Signed   []SignedNonce   `json:"signed"`
+}
+
+type ReservedNonce struct {
+	Nonce      uint64 `json:"nonce"`
+	ReservedAt int64  `json:"reserved_at"` // unix seconds
+}
+
+type SignedNonce struct {
+	Nonce    uint64 `json:"nonce"`
+	MsgCid   string `json:"msg_cid"`
+	SignedAt int64  `json:"signed_at"` // unix seconds
+}
+
+type WatchRequest struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+	// FromEpoch makes the indexer walk the chain again from this epoch, 0 keeps its progress
+	FromEpoch int64 `json:"from_epoch"`
+}
+
+type WatchInfo struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+	AddedAt int64  `json:"added_at"` // unix seconds
+}
+
+type IndexerStatus struct {
+	Enable     bool  `json:"enable"`
+	StartEpoch int64 `json:"start_epoch"`
+	// Height is the last indexed epoch, Head is 0 on a wallet without node
+	Height    int64 `json:"height"`
+	Head      int64 `json:"head"`
+	UpdatedAt int64 `json:"updated_at"` // unix seconds
+}
+
+// Event is one event of the /events stream and the webhooks, Data is a HistoryResponse for the tx events,
+// a MsigProposalEvent, a MinerRoleEvent or a BalanceEvent
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Time int64           `json:"time"` // unix seconds
+	Data json.RawMessage `json:"data"`
+}
+
+type MsigProposalEvent struct {
+	MsigAddress string   `json:"msig_address"`
+	Txid        int64    `json:"txid"`
+	To          string   `json:"to"`
+	Value       string   `json:"value"`
+	Method      uint64   `json:"method"`
+	MethodName  string   `json:"method_name"`
+	Approved    []string `json:"approved"`
+}
+
+// MinerRoleEvent tells that the owner, worker or beneficiary of a watched miner changed
+type MinerRoleEvent struct {
+	MinerId string `json:"miner_id"`
+	Role    string `json:"role"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+}
+
+type BalanceEvent struct {
+	Address   string `json:"address"`
+	Balance   string `json:"balance"`
+	Threshold string `json:"threshold"`
+}
+
 type SingRequest struct {
 	From       string `json:"from"`
 	HexMessage string `json:"hex_message"`
@@ -138,8 +345,149 @@ type MinerControl struct {
 	ControlAddresses []Meta `json:"control_addresses"`
 }
 
+// MinerInfo is the financial state of a miner, amounts are in FIL and powers in bytes,
+// Error is set instead when the miner could not be read
+type MinerInfo struct {
+	MinerId           string          `json:"miner_id"`
+	Error             string          `json:"error,omitempty"`
+	Control           MinerControl    `json:"control"`
+	SectorSize        uint64          `json:"sector_size"`
+	Balance           string          `json:"balance"`
+	AvailableBalance  string          `json:"available_balance"`
+	InitialPledge     string          `json:"initial_pledge"`
+	LockedFunds       string          `json:"locked_funds"` // vesting
+	PreCommitDeposits string          `json:"precommit_deposits"`
+	FeeDebt           string          `json:"fee_debt"`
+	RawPower          string          `json:"raw_power"`
+	QualityAdjPower   string          `json:"quality_adj_power"`
+	HasMinPower       bool            `json:"has_min_power"`
+	LiveSectors       uint64          `json:"live_sectors"`
+	ActiveSectors     uint64          `json:"active_sectors"`
+	FaultySectors     uint64          `json:"faulty_sectors"`
+	BeneficiaryTerm   BeneficiaryTerm `json:"beneficiary_term"`
+	// PendingBeneficiary is the proposed beneficiary change waiting for its confirmations
+	PendingBeneficiary *PendingBeneficiary `json:"pending_beneficiary,omitempty"`
+}
+
+type BeneficiaryTerm struct {
+	Quota      string `json:"quota"`
+	UsedQuota  string `json:"used_quota"`
+	Expiration int64  `json:"expiration"`
+	// Remaining is what the beneficiary may still withdraw, 0 once expired, empty when the owner is the beneficiary
+	Remaining string `json:"remaining"`
+}
+
+type PendingBeneficiary struct {
+	NewBeneficiary        string `json:"new_beneficiary"`
+	NewQuota              string `json:"new_quota"`
+	NewExpiration         int64  `json:"new_expiration"`
+	ApprovedByBeneficiary bool   `json:"approved_by_beneficiary"`
+	ApprovedByNominee     bool   `json:"approved_by_nominee"`
+}
+
+// VestingSchedule projects the unlocks of the vesting funds per day or week, the amounts are in attoFIL
+type VestingSchedule struct {
+	Interval string          `json:"interval"` // day or week
+	Days     int             `json:"days"`
+	Head     int64           `json:"head"`
+	Miners   []MinerVesting  `json:"miners"`
+	Total    []VestingBucket `json:"total"`
+}
+
+// MinerVesting is the projection of one miner, Error is set instead when the miner could not be read
+type MinerVesting struct {
+	MinerId string `json:"miner_id"`
+	Error   string `json:"error,omitempty"`
+	// Locked is all the vesting funds, Unlocked the funds vested but not yet moved to the available balance
+	Locked   string          `json:"locked"`
+	Unlocked string          `json:"unlocked"`
+	Buckets  []VestingBucket `json:"buckets"`
+}
+
+// VestingBucket is the amount unlocking in the epochs [StartEpoch, EndEpoch), Date is the UTC day of StartEpoch
+type VestingBucket struct {
+	Date       string `json:"date"`
+	StartEpoch int64  `json:"start_epoch"`
+	EndEpoch   int64  `json:"end_epoch"`
+	Amount     string `json:"amount"`
+}
+
+// AutomationRule of kind withdraw (the default) withdraws the available balance of MinerId once it exceeds Threshold,
+// a rule of kind topup tops the worker and control addresses of MinerId below Threshold up to Target from Funder
+type AutomationRule struct {
+	// ID defaults to <kind>-<miner id>, setting an existing id updates the rule
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	MinerId string `json:"miner_id"`
+	// Threshold, Amount, Target and FunderLow are in FIL, an empty Amount withdraws all that can be withdrawn
+	Threshold string `json:"threshold"`
+	Amount    string `json:"amount"`
+	// Sender is owner or beneficiary (the default), From is the proposer when the sender is a msig
+	Sender    string `json:"sender"`
+	From      string `json:"from"`
+	FeePreset string `json:"fee_preset"`
+	// Funder must be held by the wallet, a balance.low event is published when it falls below FunderLow
+	Funder    string `json:"funder"`
+	Target    string `json:"target"`
+	FunderLow string `json:"funder_low"`
+	// Interval is the least time between two runs of the rule, e.g. 24h, the default is 24h for withdrawals and 1h for top-ups
+	Interval  string `json:"interval"`
+	Enable    bool   `json:"enable"`
+	CreatedAt int64  `json:"created_at"` // unix seconds
+	LastRun   int64  `json:"last_run"`   // unix seconds
+}
+
+type AutomationRuleRemoveRequest struct {
+	ID string `json:"id"`
+}
+
+type AutomationRun struct {
+	RuleID  string `json:"rule_id"`
+	Kind    string `json:"kind"`
+	MinerId string `json:"miner_id"`
+	Time    int64  `json:"time"` // unix seconds
+	// Action is pushed, proposal, skipped or failed
+	Action string `json:"action"`
+	// Available is the miner available balance of a withdrawal and the balance of To before a top-up,
+	// Available and Amount are in attoFIL
+	Available string `json:"available"`
+	Amount    string `json:"amount"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Nonce     uint64 `json:"nonce"`
+	TxCid     string `json:"tx_cid"`
+	// Message is the unsigned msig proposal, it is signed and pushed with sign_send
+	Message *chain.Message `json:"message,omitempty"`
+	Detail  string         `json:"detail"`
+}
+
+type Workflow struct {
+	ID      string `json:"id"`
+	Kind    string `json:"kind"`
+	MinerId string `json:"miner_id"`
+	Target  string `json:"target"`
+	// State is started, waiting, ready, done or cancelled
+	State string `json:"state"`
+	// NextAction is sent by NextSender from ReadyEpoch, ReadyTime estimates when in unix seconds
+	NextAction  string `json:"next_action"`
+	NextSender  string `json:"next_sender"`
+	ReadyEpoch  int64  `json:"ready_epoch"`
+	ReadyTime   int64  `json:"ready_time"`
+	AutoConfirm bool   `json:"auto_confirm"`
+	// ConfirmMessage is the unsigned confirm message built by auto confirm, it is signed and pushed with sign_send
+	ConfirmMessage *chain.Message `json:"confirm_message,omitempty"`
+	Detail         string         `json:"detail"`
+	CreatedAt      int64          `json:"created_at"`
+	UpdatedAt      int64          `json:"updated_at"`
+}
+
+type WorkflowCancelRequest struct {
+	ID string `json:"id"`
+}
+
 type StatusInfo struct {
 	Lock    bool   `json:"lock"`
+	Sealed  bool   `json:"sealed"`
 	Offline bool   `json:"offline"`
 	Version string `json:"version"`
 }
@@ -190,10 +538,11 @@ type MsigCreateRequest struct {
 }
 
 type MsigBaseRequest struct {
-	BaseParams  buildmessage.BaseParams `json:"base_params"`
-	From        string                  `json:"from"`
-	MsigAddress string                  `json:"msig_address"`
-	TxId        string                  `json:"tx_id"`
+	BaseParams  buildmessage.BaseParams     `json:"base_params"`
+	From        string                      `json:"from"`
+	MsigAddress string                      `json:"msig_address"`
+	TxId        string                      `json:"tx_id"`
+	Expect      buildmessage.TxnExpectation `json:"expect"`
 }
 
 type MsigTransferProposeRequest struct {
@@ -231,6 +580,33 @@ type MsigAddSignerCancelRequest struct {
 	IncreaseThreshold bool                    `json:"increase_threshold"`
 }
 
+type MsigRemoveSignerProposeRequest struct {
+	BaseParams        buildmessage.BaseParams `json:"base_params"`
+	From              string                  `json:"from"`
+	MsigAddress       string                  `json:"msig_address"`
+	SignerAddress     string                  `json:"signer_address"`
+	DecreaseThreshold bool                    `json:"decrease_threshold"`
+}
+
+type MsigRemoveSignerApproveRequest struct {
+	BaseParams        buildmessage.BaseParams `json:"base_params"`
+	From              string                  `json:"from"`
+	MsigAddress       string                  `json:"msig_address"`
+	ProposerAddress   string                  `json:"proposer_address"`
+	TxId              string                  `json:"tx_id"`
+	SignerAddress     string                  `json:"signer_address"`
+	DecreaseThreshold bool                    `json:"decrease_threshold"`
+}
+
+type MsigRemoveSignerCancelRequest struct {
+	BaseParams        buildmessage.BaseParams `json:"base_params"`
+	From              string                  `json:"from"`
+	MsigAddress       string                  `json:"msig_address"`
+	TxId              string                  `json:"tx_id"`
+	SignerAddress     string                  `json:"signer_address"`
+	DecreaseThreshold bool                    `json:"decrease_threshold"`
+}
+
 type MsigSwapProposeRequest struct {
 	BaseParams  buildmessage.BaseParams `json:"base_params"`
 	From        string                  `json:"from"`
@@ -441,10 +817,12 @@ type MsigLockInfo struct {
 }
 
 type MsigTransaction struct {
-	Txid     int64    `json:"txid"`
-	To       string   `json:"to"`
-	Value    string   `json:"value"`
-	Method   string   `json:"method"`
-	Params   string   `json:"params"`
-	Approved []string `json:"approved"`
+	Txid      int64    `json:"txid"`
+	To        string   `json:"to"`
+	Value     string   `json:"value"`
+	Method    string   `json:"method"`
+	MethodNum uint64   `json:"method_num"`
+	Params    string   `json:"params"`
+	RawParams string   `json:"raw_params"`
+	Approved  []string `json:"approved"`
 }
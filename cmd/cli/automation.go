@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/urfave/cli/v2"
+	"text/tabwriter"
+	"time"
+)
+
+var automationCmd = &cli.Command{
+	Name:  "automation",
+	Usage: "rules the daemon runs on its own: withdraw the miner available balance, top up the worker and control addresses",
+	Subcommands: []*cli.Command{
+		automationListCmd,
+		automationSetCmd,
+		automationEnableCmd,
+		automationDisableCmd,
+		automationRemoveCmd,
+		automationRunsCmd,
+	},
+}
+
+var automationListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the automation rules",
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		rules, err := walletAPI.AutomationRules()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "ID\tKind\tMiner\tThreshold\tAction\tInterval\tEnable\tLastRun\n")
+		for _, rule := range rules {
+			lastRun := "-"
+			if rule.LastRun != 0 {
+				lastRun = time.Unix(rule.LastRun, 0).Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\n", rule.ID, rule.Kind, rule.MinerId, rule.Threshold, ruleAction(rule), rule.Interval, rule.Enable, lastRun)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
+
+// ruleAction describes what the rule does when it runs
+func ruleAction(rule client.AutomationRule) string {
+	if rule.Kind == "topup" {
+		return fmt.Sprintf("top up to %s from %s", rule.Target, rule.Funder)
+	}
+
+	amount := rule.Amount
+	if amount == "" {
+		amount = "all"
+	}
+	sender := rule.Sender
+	if sender == "" {
+		sender = buildmessage.WithdrawByBeneficiary
+	}
+	if rule.From != "" {
+		sender += " proposed by " + rule.From
+	}
+	return fmt.Sprintf("withdraw %s by %s", amount, sender)
+}
+
+var automationSetCmd = &cli.Command{
+	Name: "set",
+	Usage: "add a rule, an existing id is updated. A withdraw rule withdraws the miner available balance once it exceeds the threshold, " +
+		"a topup rule tops the worker and control addresses below the threshold up to the target",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "id",
+			Usage: "id of the rule, default <kind>-<miner id>",
+		},
+		&cli.StringFlag{
+			Name:  "kind",
+			Usage: "withdraw or topup",
+			Value: "withdraw",
+		},
+		&cli.StringFlag{
+			Name:     "miner",
+			Usage:    "miner id",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "threshold",
+			Usage:    "FIL the available balance must exceed to withdraw, or the address balance must fall below to top up",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "amount",
+			Usage: "FIL withdrawn by each run, default all that can be withdrawn",
+		},
+		&cli.StringFlag{
+			Name:  "sender",
+			Usage: "who sends the withdrawal, owner or beneficiary, the funds always go to the beneficiary",
+			Value: buildmessage.WithdrawByBeneficiary,
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "signer of the msig that proposes the withdrawal when the sender is a msig",
+		},
+		&cli.StringFlag{
+			Name:  "funder",
+			Usage: "address held by the wallet that sends the top-ups",
+		},
+		&cli.StringFlag{
+			Name:  "target",
+			Usage: "FIL a top-up brings the address back to",
+		},
+		&cli.StringFlag{
+			Name:  "funder-low",
+			Usage: "raise a balance.low event when the funder falls below this amount of FIL",
+		},
+		&cli.StringFlag{
+			Name:  "fee-preset",
+			Usage: "slow, normal or fast, default lets the node estimate the fee",
+		},
+		&cli.DurationFlag{
+			Name:  "interval",
+			Usage: "least time between two runs of the rule, default 24h for withdraw and 1h for topup",
+		},
+		&cli.BoolFlag{
+			Name:  "disable",
+			Usage: "save the rule without running it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		interval := ""
+		if cctx.IsSet("interval") {
+			interval = cctx.Duration("interval").String()
+		}
+
+		rule, err := walletAPI.AutomationRuleSet(client.AutomationRule{
+			ID:        cctx.String("id"),
+			Kind:      cctx.String("kind"),
+			MinerId:   cctx.String("miner"),
+			Threshold: cctx.String("threshold"),
+			Amount:    cctx.String("amount"),
+			Sender:    cctx.String("sender"),
+			From:      cctx.String("from"),
+			FeePreset: cctx.String("fee-preset"),
+			Funder:    cctx.String("funder"),
+			Target:    cctx.String("target"),
+			FunderLow: cctx.String("funder-low"),
+			Interval:  interval,
+			Enable:    !cctx.Bool("disable"),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("set rule %s success\n", rule.ID)
+		return nil
+	},
+}
+
+var automationEnableCmd = &cli.Command{
+	Name:      "enable",
+	Usage:     "run the rule again",
+	ArgsUsage: "[id]",
+	Action: func(cctx *cli.Context) error {
+		return setRuleEnable(cctx, true)
+	},
+}
+
+var automationDisableCmd = &cli.Command{
+	Name:      "disable",
+	Usage:     "stop running the rule, it is kept",
+	ArgsUsage: "[id]",
+	Action: func(cctx *cli.Context) error {
+		return setRuleEnable(cctx, false)
+	},
+}
+
+func setRuleEnable(cctx *cli.Context, enable bool) error {
+	if !cctx.Args().Present() {
+		return fmt.Errorf("must have id param")
+	}
+	id := cctx.Args().First()
+
+	walletAPI, err := client.GetOpenFilAPI(cctx)
+	if err != nil {
+		return err
+	}
+
+	rules, err := walletAPI.AutomationRules()
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		if rule.ID != id {
+			continue
+		}
+
+		rule.Enable = enable
+		_, err = walletAPI.AutomationRuleSet(rule)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("rule %s enable: %t\n", id, enable)
+		return nil
+	}
+
+	return fmt.Errorf("rule %s does not exist", id)
+}
+
+var automationRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "remove a rule, its runs are kept",
+	ArgsUsage: "[id]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have id param")
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		err = walletAPI.AutomationRuleRemove(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("remove rule success")
+		return nil
+	},
+}
+
+var automationRunsCmd = &cli.Command{
+	Name:  "runs",
+	Usage: "list the runs of the rules, newest first",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rule",
+			Usage: "only list the runs of this rule",
+		},
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "number of runs, 0 lists all",
+			Value: 20,
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the runs in json, with the unsigned proposals to sign with sign-tx",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		runs, err := walletAPI.AutomationRuns(cctx.String("rule"), cctx.Int("limit"))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			data, err := json.MarshalIndent(runs, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cctx.App.Writer, string(data))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Time\tRule\tMiner\tAction\tAvailable\tAmount\tFrom\tTo\tTxCid\tDetail\n")
+		for _, run := range runs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", time.Unix(run.Time, 0).Format(time.RFC3339), run.RuleID, run.MinerId, run.Action,
+				filString(run.Available), filString(run.Amount), run.From, run.To, run.TxCid, run.Detail)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
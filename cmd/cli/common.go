@@ -31,6 +31,18 @@ func getBaseParams(cctx *cli.Context) (buildmessage.BaseParams, error) {
 	baseParams.GasLimit = cctx.Int64("gas-limit")
 	baseParams.MaxFee = cctx.String("max-fee")
 	baseParams.Nonce = cctx.Uint64("nonce")
+	baseParams.ExplicitNonce = cctx.IsSet("nonce")
+
+	baseParams.Simulate = cctx.Bool("simulate")
+	baseParams.Force = cctx.Bool("force")
+
+	if cctx.IsSet("fee-preset") {
+		preset, err := buildmessage.ParseFeePreset(cctx.String("fee-preset"))
+		if err != nil {
+			return buildmessage.BaseParams{}, err
+		}
+		baseParams.FeePreset = string(preset)
+	}
 
 	log.Debugw("getBaseParams", "BaseParams", baseParams.String())
 
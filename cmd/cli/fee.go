@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/urfave/cli/v2"
+	"text/tabwriter"
+)
+
+var feeCmd = &cli.Command{
+	Name:  "fee",
+	Usage: "gas fee estimation",
+	Subcommands: []*cli.Command{
+		feeEstimateCmd,
+	},
+}
+
+var feeEstimateCmd = &cli.Command{
+	Name:  "estimate",
+	Usage: "estimate the slow, normal and fast fee presets of a message",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "from",
+			Usage:    "sender of the message",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "receiver of the message, default the sender",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "value of the message in FIL",
+		},
+		&cli.Uint64Flag{
+			Name:  "method",
+			Usage: "method number of the message",
+		},
+		&cli.StringFlag{
+			Name:  "params",
+			Usage: "hex encoded params of the message",
+		},
+		&cli.Int64Flag{
+			Name:  "gas-limit",
+			Usage: "use this gas limit instead of estimating it",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		info, err := walletAPI.FeeEstimate(client.FeeEstimateRequest{
+			From:     cctx.String("from"),
+			To:       cctx.String("to"),
+			Value:    cctx.String("value"),
+			Method:   cctx.Uint64("method"),
+			Params:   cctx.String("params"),
+			GasLimit: cctx.Int64("gas-limit"),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Base fee:        ", info.BaseFee)
+		fmt.Printf("Recent base fee:  min %s, median %s, max %s\n", info.BaseFeeMin, info.BaseFeeMedian, info.BaseFeeMax)
+		fmt.Println()
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Preset\tGasLimit\tGasFeeCap\tGasPremium\tExpectedBurn\tExpectedFee\tWorstCaseFee\n")
+		for _, p := range info.Presets {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\n", p.Preset, p.GasLimit, p.GasFeeCap, p.GasPremium, p.ExpectedBurn, p.ExpectedFee, p.WorstCaseFee)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
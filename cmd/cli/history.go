@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/urfave/cli/v2"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// exportPageSize is how many records are fetched per request while exporting
+const exportPageSize = 500
+
+var historyCmd = &cli.Command{
+	Name:  "history",
+	Usage: "query and export the tx history of owned and watched addresses",
+	Subcommands: []*cli.Command{
+		historyListCmd,
+		historyExportCmd,
+	},
+}
+
+var historyFilterFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:    "address",
+		Aliases: []string{"addr"},
+		Usage:   "addresses to query, all owned and watched addresses when not set",
+	},
+	&cli.StringSliceFlag{
+		Name:  "method",
+		Usage: "method numbers or names, e.g. 0 or Send",
+	},
+	&cli.StringSliceFlag{
+		Name:  "status",
+		Usage: "pending, success or failed",
+	},
+	&cli.StringSliceFlag{
+		Name:  "direction",
+		Usage: "out, in or self",
+	},
+	&cli.StringFlag{
+		Name:  "counterparty",
+		Usage: "the other side of the messages",
+	},
+	&cli.Int64Flag{
+		Name:  "min-epoch",
+		Usage: "first epoch",
+	},
+	&cli.Int64Flag{
+		Name:  "max-epoch",
+		Usage: "last epoch",
+	},
+	&cli.TimestampFlag{
+		Name:   "since",
+		Usage:  "first day, e.g. 2024-01-01",
+		Layout: "2006-01-02",
+	},
+	&cli.TimestampFlag{
+		Name:   "until",
+		Usage:  "last day, e.g. 2024-12-31",
+		Layout: "2006-01-02",
+	},
+	&cli.StringFlag{
+		Name:  "sort",
+		Usage: "epoch, time, value or nonce",
+		Value: "epoch",
+	},
+	&cli.BoolFlag{
+		Name:  "desc",
+		Usage: "sort in descending order",
+	},
+}
+
+func getHistoryQuery(cctx *cli.Context) client.HistoryQuery {
+	q := client.HistoryQuery{
+		Addresses:    cctx.StringSlice("address"),
+		Methods:      cctx.StringSlice("method"),
+		Status:       cctx.StringSlice("status"),
+		Directions:   cctx.StringSlice("direction"),
+		Counterparty: cctx.String("counterparty"),
+		MinEpoch:     cctx.Int64("min-epoch"),
+		MaxEpoch:     cctx.Int64("max-epoch"),
+		Sort:         cctx.String("sort"),
+		Desc:         cctx.Bool("desc"),
+	}
+
+	if since := cctx.Timestamp("since"); since != nil {
+		q.Since = since.Unix()
+	}
+	// until includes the whole day
+	if until := cctx.Timestamp("until"); until != nil {
+		q.Until = until.Add(24*time.Hour).Unix() - 1
+	}
+
+	return q
+}
+
+var historyListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the tx history one page at a time",
+	Flags: append([]cli.Flag{
+		&cli.IntFlag{
+			Name:  "limit",
+			Usage: "records per page",
+			Value: 50,
+		},
+		&cli.StringFlag{
+			Name:  "cursor",
+			Usage: "the cursor printed with the previous page",
+		},
+	}, historyFilterFlags...),
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		q := getHistoryQuery(cctx)
+		q.Limit = cctx.Int("limit")
+		q.Cursor = cctx.String("cursor")
+
+		page, err := walletAPI.TxHistoryQuery(q)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Time\tAddress\tDirection\tCounterparty\tEpoch\tValue\tFee\tMethod\tMsgState\tMsgCid\n")
+		for _, tx := range page.Records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n", historyTime(tx), tx.Address, tx.Direction, counterparty(tx), tx.Epoch,
+				filString(tx.AttoValue), types.FIL(historyFee(tx)).Unitless(), historyMethod(tx), tx.TxState, tx.TxCid)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		if page.NextCursor != "" {
+			fmt.Println()
+			fmt.Println("Next page: --cursor", page.NextCursor)
+		}
+
+		return nil
+	},
+}
+
+var historyExportCmd = &cli.Command{
+	Name:  "export",
+	Usage: "export the tx history for accounting, amounts are in FIL",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "csv or json",
+			Value: "csv",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "a path to write the export to, stdout when not set",
+		},
+	}, historyFilterFlags...),
+	Action: func(cctx *cli.Context) error {
+		format := cctx.String("format")
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("unknown format %s, must be csv or json", format)
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		watches, err := walletAPI.WatchList()
+		if err != nil {
+			return err
+		}
+		labels := make(map[string]string, len(watches))
+		for _, watch := range watches {
+			labels[watch.Address] = watch.Label
+		}
+
+		q := getHistoryQuery(cctx)
+		q.Limit = exportPageSize
+
+		var rows []exportRow
+		for {
+			page, err := walletAPI.TxHistoryQuery(q)
+			if err != nil {
+				return err
+			}
+
+			for _, tx := range page.Records {
+				rows = append(rows, newExportRow(tx, labels))
+			}
+
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+
+		out := io.Writer(os.Stdout)
+		if cctx.IsSet("output") {
+			fi, err := os.Create(cctx.String("output"))
+			if err != nil {
+				return err
+			}
+			defer fi.Close()
+			out = fi
+		}
+
+		if format == "json" {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(rows)
+		}
+
+		return writeExportCSV(out, rows)
+	},
+}
+
+// exportRow is one record of the accounting export, the fees are only paid by the sender
+type exportRow struct {
+	Date               string `json:"date"`
+	Address            string `json:"address"`
+	Label              string `json:"label"`
+	Direction          string `json:"direction"`
+	Counterparty       string `json:"counterparty"`
+	CounterpartyLabel  string `json:"counterparty_label"`
+	Value              string `json:"value"`
+	BaseFeeBurn        string `json:"base_fee_burn"`
+	OverEstimationBurn string `json:"over_estimation_burn"`
+	MinerTip           string `json:"miner_tip"`
+	TotalFee           string `json:"total_fee"`
+	Method             string `json:"method"`
+	Status             string `json:"status"`
+	ExitCode           int64  `json:"exit_code"`
+	Epoch              int64  `json:"epoch"`
+	Cid                string `json:"cid"`
+}
+
+func newExportRow(tx client.HistoryResponse, labels map[string]string) exportRow {
+	row := exportRow{
+		Date:              historyTime(tx),
+		Address:           tx.Address,
+		Label:             labels[tx.Address],
+		Direction:         tx.Direction,
+		Counterparty:      counterparty(tx),
+		CounterpartyLabel: labels[counterparty(tx)],
+		Value:             filString(tx.AttoValue),
+		Method:            historyMethod(tx),
+		Status:            tx.TxState,
+		ExitCode:          tx.ExitCode,
+		Epoch:             tx.Epoch,
+		Cid:               tx.TxCid,
+	}
+
+	if tx.Direction != "in" {
+		row.BaseFeeBurn = filString(tx.BaseFeeBurn)
+		row.OverEstimationBurn = filString(tx.OverEstimationBurn)
+		row.MinerTip = filString(tx.MinerTip)
+		row.TotalFee = types.FIL(historyFee(tx)).Unitless()
+	}
+
+	return row
+}
+
+func writeExportCSV(out io.Writer, rows []exportRow) error {
+	w := csv.NewWriter(out)
+	err := w.Write([]string{"date", "address", "label", "direction", "counterparty", "counterparty_label", "value",
+		"base_fee_burn", "over_estimation_burn", "miner_tip", "total_fee", "method", "status", "exit_code", "epoch", "cid"})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		err := w.Write([]string{r.Date, r.Address, r.Label, r.Direction, r.Counterparty, r.CounterpartyLabel, r.Value,
+			r.BaseFeeBurn, r.OverEstimationBurn, r.MinerTip, r.TotalFee, r.Method, r.Status,
+			strconv.FormatInt(r.ExitCode, 10), strconv.FormatInt(r.Epoch, 10), r.Cid})
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func counterparty(tx client.HistoryResponse) string {
+	if tx.Direction == "in" {
+		return tx.From
+	}
+
+	return tx.To
+}
+
+// historyFee is the total fee paid by the sender in attoFIL
+func historyFee(tx client.HistoryResponse) big.Int {
+	fee := big.Zero()
+	for _, amount := range []string{tx.BaseFeeBurn, tx.OverEstimationBurn, tx.MinerTip} {
+		if v, err := big.FromString(amount); err == nil {
+			fee = big.Add(fee, v)
+		}
+	}
+
+	return fee
+}
+
+// filString converts an attoFIL amount to FIL, an unknown amount is empty
+func filString(atto string) string {
+	v, err := big.FromString(atto)
+	if err != nil {
+		return ""
+	}
+
+	return types.FIL(v).Unitless()
+}
@@ -11,6 +11,12 @@ import (
 var loginCmd = &cli.Command{
 	Name:  "login",
 	Usage: "login openfil wallet",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "user",
+			Usage: "login as the named user, the next commands use its token until logout, the login password is used if not set",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		walletAPI, err := client.GetOpenFilAPI(cctx)
 		if err != nil {
@@ -22,11 +28,19 @@ var loginCmd = &cli.Command{
 			return err
 		}
 
-		err = walletAPI.Login(password)
+		user := cctx.String("user")
+		token, err := walletAPI.Login(user, password)
 		if err != nil {
 			return err
 		}
 
+		// the next commands act as the user, logout goes back to the token of the repo
+		if user != "" {
+			if err := client.SetUserToken(cctx, token); err != nil {
+				return err
+			}
+		}
+
 		fmt.Println("login successful")
 		return nil
 	},
@@ -41,7 +55,11 @@ var logoutCmd = &cli.Command{
 			return err
 		}
 
+		// the token of the user is dropped even when the wallet refuses it
 		err = walletAPI.SignOut()
+		if err := client.SetUserToken(cctx, ""); err != nil {
+			return err
+		}
 		if err != nil {
 			return err
 		}
@@ -40,10 +40,15 @@ func main() {
 			chainCmd,
 			sendCmd,
 			signCmd,
+			nonceCmd,
+			watchCmd,
+			historyCmd,
+			feeCmd,
 			walletCmd,
 			fevmWalletCmd,
 			transferCmd,
 			minerCmd,
+			automationCmd,
 			multisigCmd,
 		},
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/fatih/color"
@@ -10,6 +11,9 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
 )
 
 var minerCmd = &cli.Command{
@@ -46,8 +50,24 @@ var minerCmd = &cli.Command{
 			Usage:   "the max tx fee allowed for this transaction",
 			Value:   "1 FIL",
 		},
+		&cli.StringFlag{
+			Name:    "fee-preset",
+			Aliases: []string{"fp"},
+			Usage:   "estimate the gas fee with a preset instead of the gas flags: slow, normal, fast",
+		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "run the built message through StateCall and refuse it when it fails",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "output the message even when the simulation fails",
+		},
 	},
 	Subcommands: []*cli.Command{
+		actorInfoCmd,
+		actorVestingCmd,
+		actorCreateCmd,
 		actorWithdrawCmd,
 		actorSetOwnerCmd,
 		actorControl,
@@ -55,12 +75,261 @@ var minerCmd = &cli.Command{
 		actorConfirmChangeWorker,
 		actorProposeChangeBeneficiary,
 		actorConfirmChangeBeneficiary,
+		actorWorkflowCmd,
+	},
+}
+
+var actorInfoCmd = &cli.Command{
+	Name:  "info",
+	Usage: "show the balances, pledge, power, sectors and beneficiary term of miners",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "actor",
+			Aliases:  []string{"a"},
+			Usage:    "specify the address of miner actor, repeat it for several miners",
+			Required: true,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		infos, err := walletAPI.MinerInfo(cctx.StringSlice("actor"))
+		if err != nil {
+			return err
+		}
+
+		for i, info := range infos {
+			if i != 0 {
+				fmt.Println()
+			}
+
+			if info.Error != "" {
+				fmt.Printf("Miner: %s\n", info.MinerId)
+				fmt.Printf("Error: %s\n", info.Error)
+				continue
+			}
+
+			w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "Miner:\t%s\n", info.MinerId)
+			fmt.Fprintf(w, "Owner:\t%s\n", info.Control.Owner.ID)
+			fmt.Fprintf(w, "Worker:\t%s\n", info.Control.Worker.ID)
+			fmt.Fprintf(w, "Beneficiary:\t%s\n", info.Control.Beneficiary.ID)
+			fmt.Fprintf(w, "Sector Size:\t%s\n", types.SizeStr(types.NewInt(info.SectorSize)))
+			fmt.Fprintf(w, "Raw Power:\t%s\n", sizeStr(info.RawPower))
+			fmt.Fprintf(w, "QA Power:\t%s\n", sizeStr(info.QualityAdjPower))
+			fmt.Fprintf(w, "Has Min Power:\t%t\n", info.HasMinPower)
+			fmt.Fprintf(w, "Sectors:\tlive %d, active %d, faulty %d\n", info.LiveSectors, info.ActiveSectors, info.FaultySectors)
+			fmt.Fprintf(w, "Balance:\t%s\n", info.Balance)
+			fmt.Fprintf(w, "Available:\t%s\n", info.AvailableBalance)
+			fmt.Fprintf(w, "Initial Pledge:\t%s\n", info.InitialPledge)
+			fmt.Fprintf(w, "Vesting:\t%s\n", info.LockedFunds)
+			fmt.Fprintf(w, "PreCommit Deposits:\t%s\n", info.PreCommitDeposits)
+			fmt.Fprintf(w, "Fee Debt:\t%s\n", info.FeeDebt)
+			fmt.Fprintf(w, "Beneficiary Quota:\t%s\n", info.BeneficiaryTerm.Quota)
+			fmt.Fprintf(w, "Beneficiary Used:\t%s\n", info.BeneficiaryTerm.UsedQuota)
+			fmt.Fprintf(w, "Beneficiary Expiration:\t%d\n", info.BeneficiaryTerm.Expiration)
+			fmt.Fprintf(w, "Beneficiary Remaining:\t%s\n", quotaRemaining(info.BeneficiaryTerm))
+			if pending := info.PendingBeneficiary; pending != nil {
+				fmt.Fprintf(w, "Pending Beneficiary:\t%s, quota %s, expiration %d, approved by beneficiary %t, by nominee %t\n",
+					pending.NewBeneficiary, pending.NewQuota, pending.NewExpiration, pending.ApprovedByBeneficiary, pending.ApprovedByNominee)
+			}
+
+			if err := w.Flush(); err != nil {
+				return fmt.Errorf("flushing output: %+v", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+func quotaRemaining(term client.BeneficiaryTerm) string {
+	if term.Remaining == "" {
+		return "unlimited, the owner is the beneficiary"
+	}
+
+	return term.Remaining
+}
+
+// sizeStr formats a power in bytes, an unknown power is printed as is
+func sizeStr(bytes string) string {
+	v, err := types.BigFromString(bytes)
+	if err != nil {
+		return bytes
+	}
+
+	return types.SizeStr(v)
+}
+
+var actorVestingCmd = &cli.Command{
+	Name:  "vesting",
+	Usage: "project when the vesting funds of miners unlock, amounts are in FIL",
+	Flags: []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:     "actor",
+			Aliases:  []string{"a"},
+			Usage:    "specify the address of miner actor, repeat it to add up several miners",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "interval",
+			Usage: "day or week",
+			Value: "day",
+		},
+		&cli.IntFlag{
+			Name:  "days",
+			Usage: "how many days to project",
+			Value: 180,
+		},
+		&cli.BoolFlag{
+			Name:  "csv",
+			Usage: "output csv",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "a path to write the output to, stdout when not set",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		schedule, err := walletAPI.MinerVesting(cctx.StringSlice("actor"), cctx.String("interval"), cctx.Int("days"))
+		if err != nil {
+			return err
+		}
+
+		out := cctx.App.Writer
+		if cctx.IsSet("output") {
+			fi, err := os.Create(cctx.String("output"))
+			if err != nil {
+				return err
+			}
+			defer fi.Close()
+			out = fi
+		}
+
+		// the miners that could not be read have no buckets, their column stays empty
+		header := []string{"date", "start_epoch", "end_epoch"}
+		for _, mv := range schedule.Miners {
+			header = append(header, mv.MinerId)
+			if mv.Error != "" {
+				fmt.Fprintf(cctx.App.ErrWriter, "miner %s: %s\n", mv.MinerId, mv.Error)
+			}
+		}
+		header = append(header, "total")
+
+		rows := [][]string{header}
+		for i, total := range schedule.Total {
+			row := []string{total.Date, strconv.FormatInt(total.StartEpoch, 10), strconv.FormatInt(total.EndEpoch, 10)}
+			for _, mv := range schedule.Miners {
+				amount := ""
+				if i < len(mv.Buckets) {
+					amount = filString(mv.Buckets[i].Amount)
+				}
+				row = append(row, amount)
+			}
+			rows = append(rows, append(row, filString(total.Amount)))
+		}
+
+		if cctx.Bool("csv") {
+			return csv.NewWriter(out).WriteAll(rows)
+		}
+
+		w := tabwriter.NewWriter(out, 8, 4, 2, ' ', 0)
+		for _, mv := range schedule.Miners {
+			if mv.Error == "" {
+				fmt.Fprintf(w, "%s locked:\t%s FIL\tvested, not yet moved:\t%s FIL\n", mv.MinerId, filString(mv.Locked), filString(mv.Unlocked))
+			}
+		}
+		fmt.Fprintln(w)
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
+
+var actorCreateCmd = &cli.Command{
+	Name:  "create",
+	Usage: "create a miner actor, the new miner is watched once the message lands",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "owner",
+			Usage:    "owner of the miner, an account or a multisig",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "worker",
+			Usage:    "worker of the miner, a BLS address that already has an actor on chain",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "sector-size",
+			Usage:    "sector size of the miner: 32GiB or 64GiB",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "peer-id",
+			Usage: "libp2p peer id of the miner",
+		},
+		&cli.StringSliceFlag{
+			Name:  "multiaddr",
+			Usage: "multiaddr the miner listens on, can be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "sender of the message, default the owner, the signer proposing the creation when the owner is a multisig",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "a path to output tx message",
+			Value:   "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		baseParams, err := getBaseParams(cctx)
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.CreateMiner(client.CreateMinerRequest{
+			BaseParams: baseParams,
+			Owner:      cctx.String("owner"),
+			Worker:     cctx.String("worker"),
+			SectorSize: cctx.String("sector-size"),
+			PeerId:     cctx.String("peer-id"),
+			Multiaddrs: cctx.StringSlice("multiaddr"),
+			From:       cctx.String("from"),
+		})
+		if err != nil {
+			return err
+		}
+
+		return printMessage(cctx, msg)
 	},
 }
 
 var actorWithdrawCmd = &cli.Command{
 	Name:      "withdraw",
-	Usage:     "withdraw available balance",
+	Usage:     "withdraw available balance to the beneficiary, 0 withdraws all that can be withdrawn",
 	ArgsUsage: "[amount (FIL)]",
 	Flags: []cli.Flag{
 		&cli.StringFlag{
@@ -69,6 +338,15 @@ var actorWithdrawCmd = &cli.Command{
 			Usage:    "specify the address of miner actor",
 			Required: true,
 		},
+		&cli.StringFlag{
+			Name:  "sender",
+			Usage: "who sends the withdrawal: owner or beneficiary",
+			Value: "beneficiary",
+		},
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "the signer proposing the withdrawal when the sender is a multisig",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
@@ -98,7 +376,15 @@ var actorWithdrawCmd = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.Withdraw(baseParams, maddr.String(), cctx.Args().First())
+		infos, err := walletAPI.MinerInfo([]string{maddr.String()})
+		if err != nil {
+			return err
+		}
+		if len(infos) == 1 && infos[0].Error == "" {
+			fmt.Fprintf(cctx.App.ErrWriter, "Available: %s, beneficiary quota remaining: %s\n", infos[0].AvailableBalance, quotaRemaining(infos[0].BeneficiaryTerm))
+		}
+
+		msg, err := walletAPI.Withdraw(baseParams, maddr.String(), cctx.Args().First(), cctx.String("sender"), cctx.String("from"))
 		if err != nil {
 			return err
 		}
@@ -118,6 +404,10 @@ var actorSetOwnerCmd = &cli.Command{
 			Usage:    "specify the address of miner actor",
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:  "auto-confirm",
+			Usage: "build the confirm message of the new owner once the change is on chain, list it with miner workflow list",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
@@ -156,7 +446,7 @@ var actorSetOwnerCmd = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.ChangeOwner(baseParams, maddr.String(), na.String(), fa.String())
+		msg, err := walletAPI.ChangeOwner(baseParams, maddr.String(), na.String(), fa.String(), cctx.Bool("auto-confirm"))
 		if err != nil {
 			return err
 		}
@@ -293,6 +583,10 @@ var actorProposeChangeWorker = &cli.Command{
 			Usage:    "specify the address of miner actor",
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:  "auto-confirm",
+			Usage: "build the confirm message once the change worker delay has passed, list it with miner workflow list",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
@@ -326,7 +620,7 @@ var actorProposeChangeWorker = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.ChangeWorker(baseParams, maddr.String(), na.String())
+		msg, err := walletAPI.ChangeWorker(baseParams, maddr.String(), na.String(), cctx.Bool("auto-confirm"))
 		if err != nil {
 			return err
 		}
@@ -404,6 +698,10 @@ var actorProposeChangeBeneficiary = &cli.Command{
 			Usage:    "specify the address of miner actor",
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:  "auto-confirm",
+			Usage: "build the confirm message of the next approver once the proposal is on chain, list it with miner workflow list",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
@@ -432,7 +730,7 @@ var actorProposeChangeBeneficiary = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.ChangeBeneficiary(baseParams, maddr.String(), cctx.Args().Get(0), cctx.Args().Get(1), cctx.Args().Get(2), cctx.Bool("overwrite-pending-change"))
+		msg, err := walletAPI.ChangeBeneficiary(baseParams, maddr.String(), cctx.Args().Get(0), cctx.Args().Get(1), cctx.Args().Get(2), cctx.Bool("overwrite-pending-change"), cctx.Bool("auto-confirm"))
 		if err != nil {
 			return err
 		}
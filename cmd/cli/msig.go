@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 	"strconv"
+	"strings"
 	"text/tabwriter"
 )
 
@@ -46,6 +48,19 @@ var multisigCmd = &cli.Command{
 			Usage:   "the max tx fee allowed for this transaction",
 			Value:   "1 FIL",
 		},
+		&cli.StringFlag{
+			Name:    "fee-preset",
+			Aliases: []string{"fp"},
+			Usage:   "estimate the gas fee with a preset instead of the gas flags: slow, normal, fast",
+		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "run the built message through StateCall and refuse it when it fails",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "output the message even when the simulation fails",
+		},
 	},
 	Subcommands: []*cli.Command{
 		msigCreateCmd,
@@ -61,6 +76,9 @@ var multisigCmd = &cli.Command{
 		msigAddProposeCmd,
 		msigAddApproveCmd,
 		msigAddCancelCmd,
+		msigRemoveProposeCmd,
+		msigRemoveApproveCmd,
+		msigRemoveCancelCmd,
 		msigSwapProposeCmd,
 		msigSwapApproveCmd,
 		msigSwapCancelCmd,
@@ -363,7 +381,7 @@ var msigApproveCmd = &cli.Command{
 	Name:      "approve",
 	Usage:     "Approve a multisig message",
 	ArgsUsage: "[multisigAddress txId]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "from",
 			Aliases: []string{"f"},
@@ -375,7 +393,7 @@ var msigApproveCmd = &cli.Command{
 			Usage:   "a path to output tx message",
 			Value:   "",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must pass at least multisig address and message ID")
@@ -406,7 +424,12 @@ var msigApproveCmd = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.MsigApprove(baseParams, from.String(), cctx.Args().Get(0), cctx.Args().Get(1))
+		shown, err := printPendingTxn(cctx, walletAPI, cctx.Args().Get(0), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigApprove(baseParams, from.String(), cctx.Args().Get(0), cctx.Args().Get(1), getTxnExpectation(cctx, shown))
 		if err != nil {
 			return err
 		}
@@ -419,7 +442,7 @@ var msigCancelCmd = &cli.Command{
 	Name:      "cancel",
 	Usage:     "Cancel a multisig message",
 	ArgsUsage: "[multisigAddress txId]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "from",
 			Aliases: []string{"f"},
@@ -431,7 +454,7 @@ var msigCancelCmd = &cli.Command{
 			Usage:   "a path to output tx message",
 			Value:   "",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must pass at least multisig address and message ID")
@@ -462,7 +485,12 @@ var msigCancelCmd = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.MsigCancel(baseParams, from.String(), msig.String(), cctx.Args().Get(1))
+		shown, err := printPendingTxn(cctx, walletAPI, cctx.Args().Get(0), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigCancel(baseParams, from.String(), msig.String(), cctx.Args().Get(1), getTxnExpectation(cctx, shown))
 		if err != nil {
 			return err
 		}
@@ -536,7 +564,7 @@ var msigTransferApproveCmd = &cli.Command{
 	Name:      "transfer-approve",
 	Usage:     "Approve a multisig message",
 	ArgsUsage: "[multisigAddress txId]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "from",
 			Aliases: []string{"f"},
@@ -548,7 +576,7 @@ var msigTransferApproveCmd = &cli.Command{
 			Usage:   "a path to output tx message",
 			Value:   "",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must have multisig address and message ID")
@@ -579,7 +607,12 @@ var msigTransferApproveCmd = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.MsigTransferApprove(baseParams, from.String(), msig.String(), cctx.Args().Get(1))
+		shown, err := printPendingTxn(cctx, walletAPI, cctx.Args().Get(0), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigTransferApprove(baseParams, from.String(), msig.String(), cctx.Args().Get(1), getTxnExpectation(cctx, shown))
 		if err != nil {
 			return err
 		}
@@ -592,7 +625,7 @@ var msigTransferCancelCmd = &cli.Command{
 	Name:      "transfer-cancel",
 	Usage:     "Cancel transfer multisig message",
 	ArgsUsage: "[multisigAddress txId]",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "from",
 			Aliases: []string{"f"},
@@ -604,7 +637,7 @@ var msigTransferCancelCmd = &cli.Command{
 			Usage:   "a path to output tx message",
 			Value:   "",
 		},
-	},
+	}, msigExpectFlags...),
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
 			return fmt.Errorf("must have multisig address and txId")
@@ -635,7 +668,12 @@ var msigTransferCancelCmd = &cli.Command{
 			return err
 		}
 
-		msg, err := walletAPI.MsigTransferCancel(baseParams, from.String(), msig.String(), cctx.Args().Get(1))
+		shown, err := printPendingTxn(cctx, walletAPI, cctx.Args().Get(0), cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigTransferCancel(baseParams, from.String(), msig.String(), cctx.Args().Get(1), getTxnExpectation(cctx, shown))
 		if err != nil {
 			return err
 		}
@@ -842,6 +880,204 @@ var msigAddCancelCmd = &cli.Command{
 	},
 }
 
+var msigRemoveProposeCmd = &cli.Command{
+	Name:      "remove-propose",
+	Usage:     "Propose to remove a signer",
+	ArgsUsage: "[multisigAddress signer]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:    "decrease-threshold",
+			Aliases: []string{"dt"},
+			Usage:   "whether the number of required signers should be decreased",
+		},
+		&cli.StringFlag{
+			Name:    "from",
+			Aliases: []string{"f"},
+			Usage:   "account to send the propose message from",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "a path to output tx message",
+			Value:   "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return fmt.Errorf("must pass multisig address and signer address")
+		}
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		addr, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		from, err := address.NewFromString(cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		baseParams, err := getBaseParams(cctx)
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigRemovePropose(baseParams, from.String(), msig.String(), addr.String(), cctx.Bool("decrease-threshold"))
+		if err != nil {
+			return err
+		}
+
+		return printMessage(cctx, msg)
+	},
+}
+
+var msigRemoveApproveCmd = &cli.Command{
+	Name:      "remove-approve",
+	Usage:     "Approve a message to remove a signer",
+	ArgsUsage: "[multisigAddress proposerAddress txId signerAddress decreaseThreshold]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "from",
+			Aliases: []string{"f"},
+			Usage:   "account to send the approve message from",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "a path to output tx message",
+			Value:   "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 5 {
+			return fmt.Errorf("must pass multisig address, proposer address, transaction id, signer address, whether to decrease threshold")
+		}
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		prop, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		_, err = strconv.ParseUint(cctx.Args().Get(2), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		signer, err := address.NewFromString(cctx.Args().Get(3))
+		if err != nil {
+			return err
+		}
+
+		dec, err := strconv.ParseBool(cctx.Args().Get(4))
+		if err != nil {
+			return err
+		}
+
+		from, err := address.NewFromString(cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		baseParams, err := getBaseParams(cctx)
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigRemoveApprove(baseParams, from.String(), msig.String(), prop.String(), cctx.Args().Get(2), signer.String(), dec)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(cctx, msg)
+	},
+}
+
+var msigRemoveCancelCmd = &cli.Command{
+	Name:      "remove-cancel",
+	Usage:     "Cancel a message to remove a signer",
+	ArgsUsage: "[multisigAddress txId signerAddress decreaseThreshold]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "from",
+			Aliases: []string{"f"},
+			Usage:   "account to send the cancel message from",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "a path to output tx message",
+			Value:   "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 4 {
+			return fmt.Errorf("must pass multisig address, transaction id, signer address, whether to decrease threshold")
+		}
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		_, err = strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		signer, err := address.NewFromString(cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		dec, err := strconv.ParseBool(cctx.Args().Get(3))
+		if err != nil {
+			return err
+		}
+
+		from, err := address.NewFromString(cctx.String("from"))
+		if err != nil {
+			return err
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		baseParams, err := getBaseParams(cctx)
+		if err != nil {
+			return err
+		}
+
+		msg, err := walletAPI.MsigRemoveCancel(baseParams, from.String(), msig.String(), cctx.Args().Get(1), signer.String(), dec)
+		if err != nil {
+			return err
+		}
+
+		return printMessage(cctx, msg)
+	},
+}
+
 var msigSwapProposeCmd = &cli.Command{
 	Name:      "swap-propose",
 	Usage:     "Propose to swap signers",
@@ -2400,6 +2636,72 @@ var msigConfirmChangeBeneficiaryApproveCmd = &cli.Command{
 	},
 }
 
+var msigExpectFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "expect-to",
+		Usage: "fail unless the pending transaction is sent to this address (default: the displayed transaction)",
+	},
+	&cli.StringFlag{
+		Name:  "expect-value",
+		Usage: "fail unless the pending transaction sends this value in FIL (default: the displayed transaction)",
+	},
+	&cli.StringFlag{
+		Name:  "expect-method",
+		Usage: "fail unless the pending transaction calls this method number (default: the displayed transaction)",
+	},
+	&cli.StringFlag{
+		Name:  "expect-params",
+		Usage: "fail unless the pending transaction has these hex encoded params (default: the displayed transaction)",
+	},
+}
+
+// getTxnExpectation binds the message to the displayed transaction, overridden by any --expect-* flag
+func getTxnExpectation(cctx *cli.Context, shown buildmessage.TxnExpectation) buildmessage.TxnExpectation {
+	expect := shown
+	if cctx.IsSet("expect-to") {
+		expect.To = cctx.String("expect-to")
+	}
+	if cctx.IsSet("expect-value") {
+		expect.Value = cctx.String("expect-value")
+	}
+	if cctx.IsSet("expect-method") {
+		expect.Method = cctx.String("expect-method")
+	}
+	if cctx.IsSet("expect-params") {
+		expect.Params = cctx.String("expect-params")
+	}
+	return expect
+}
+
+// printPendingTxn shows the decoded pending transaction before the approve or cancel message is built
+// and returns it as an expectation, so a transaction replaced after it was shown is not approved
+func printPendingTxn(cctx *cli.Context, walletAPI *client.OpenFilAPI, msigAddress, txId string) (buildmessage.TxnExpectation, error) {
+	inspect, err := walletAPI.MsigInspect(msigAddress)
+	if err != nil {
+		return buildmessage.TxnExpectation{}, err
+	}
+
+	for _, tx := range inspect.Transactions {
+		if strconv.FormatInt(tx.Txid, 10) != txId {
+			continue
+		}
+
+		fmt.Fprintf(cctx.App.ErrWriter, "Transaction %d: to %s, value %s, method %s, approved by %s\n", tx.Txid, tx.To, tx.Value, tx.Method, strings.Join(tx.Approved, ", "))
+		fmt.Fprintf(cctx.App.ErrWriter, "Params: %s\n", tx.Params)
+		fmt.Fprintf(cctx.App.ErrWriter, "Raw params: 0x%s\n", tx.RawParams)
+
+		// the value is in attoFIL, and 0x still binds empty params
+		return buildmessage.TxnExpectation{
+			To:     tx.To,
+			Value:  tx.Value + " attoFIL",
+			Method: strconv.FormatUint(tx.MethodNum, 10),
+			Params: "0x" + tx.RawParams,
+		}, nil
+	}
+
+	return buildmessage.TxnExpectation{}, fmt.Errorf("transaction %s is not pending in %s", txId, msigAddress)
+}
+
 func getInputs(cctx *cli.Context) (address.Address, address.Address, address.Address, error) {
 	multisigAddr, err := address.NewFromString(cctx.String("multisig"))
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/urfave/cli/v2"
 	"text/tabwriter"
+	"time"
 )
 
 var nodeCmd = &cli.Command{
@@ -18,6 +19,7 @@ var nodeCmd = &cli.Command{
 		useNodeCmd,
 		nodeListCmd,
 		bestNodeCmd,
+		nodeHealthCmd,
 	},
 }
 
@@ -185,10 +187,11 @@ var nodeListCmd = &cli.Command{
 		}
 
 		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
-		fmt.Fprintf(w, "ID\tName\tEndpoint\tToken\n")
+		fmt.Fprintf(w, "ID\tName\tEndpoint\tToken\tHeight\tVersion\tNetwork\tError\n")
 
 		for i, nodeInfo := range nodeInfos {
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i, nodeInfo.Name, nodeInfo.Endpoint, nodeInfo.Token)
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i, nodeInfo.Name, nodeInfo.Endpoint, nodeInfo.Token,
+				nodeInfo.BlockHeight, nodeInfo.Version, nodeInfo.Network, nodeInfo.Error)
 		}
 
 		if err := w.Flush(); err != nil {
@@ -224,3 +227,39 @@ var bestNodeCmd = &cli.Command{
 		return nil
 	},
 }
+
+var nodeHealthCmd = &cli.Command{
+	Name:  "health",
+	Usage: "node health history of the background checker",
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		nodeHealths, err := walletAPI.NodeHealth()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Name\tUsing\tPinned\tHealthy\tLatency\tHeight\tHeadLag\tChecked\tError\n")
+
+		for _, nh := range nodeHealths {
+			if len(nh.History) == 0 {
+				fmt.Fprintf(w, "%s\t%t\t%t\t-\t-\t-\t-\t-\t-\n", nh.Name, nh.IsUsing, nh.IsPinned)
+				continue
+			}
+
+			h := nh.History[len(nh.History)-1]
+			fmt.Fprintf(w, "%s\t%t\t%t\t%t\t%dms\t%d\t%d\t%s\t%s\n", nh.Name, nh.IsUsing, nh.IsPinned, h.Healthy,
+				h.Latency, h.Height, h.HeadLag, time.Unix(h.CheckedAt, 0).Format(time.RFC3339), h.Error)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
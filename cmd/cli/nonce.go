@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/urfave/cli/v2"
+	"text/tabwriter"
+	"time"
+)
+
+var nonceCmd = &cli.Command{
+	Name:  "nonce",
+	Usage: "nonces reserved for built messages and signed by the wallet",
+	Subcommands: []*cli.Command{
+		nonceShowCmd,
+		nonceResetCmd,
+	},
+}
+
+var nonceShowCmd = &cli.Command{
+	Name:      "show",
+	Usage:     "show the chain, mpool, reserved and signed nonces of an address",
+	ArgsUsage: "[address]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have address param")
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		info, err := walletAPI.Nonce(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Address:     ", info.Address)
+		fmt.Println("Chain nonce: ", info.ChainNonce)
+		fmt.Println("Mpool nonce: ", info.MpoolNonce)
+		fmt.Println("Next nonce:  ", info.Next)
+		fmt.Println()
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Nonce\tState\tTime\tMsgCid\n")
+		for _, r := range info.Reserved {
+			fmt.Fprintf(w, "%d\treserved\t%s\t-\n", r.Nonce, time.Unix(r.ReservedAt, 0).Format(time.RFC3339))
+		}
+		for _, s := range info.Signed {
+			fmt.Fprintf(w, "%d\tsigned\t%s\t%s\n", s.Nonce, time.Unix(s.SignedAt, 0).Format(time.RFC3339), s.MsgCid)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
+
+var nonceResetCmd = &cli.Command{
+	Name:      "reset",
+	Usage:     "release the nonces reserved for built messages of an address",
+	ArgsUsage: "[address]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have address param")
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		err = walletAPI.NonceReset(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("nonce reservations released")
+		return nil
+	},
+}
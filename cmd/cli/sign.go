@@ -30,6 +30,14 @@ var signTxCmd = &cli.Command{
 			Value:    "",
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "run the message through StateCall before signing and refuse it when it fails",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "sign even when the simulation fails",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
@@ -60,11 +68,20 @@ var signTxCmd = &cli.Command{
 			return err
 		}
 
+		if err := checkSimulation(cctx, walletAPI, &msg); err != nil {
+			return err
+		}
+
 		signedMessage, err := walletAPI.Sign(msg)
 		if err != nil {
 			return err
 		}
 
+		if signedMessage.Warning != "" {
+			fmt.Fprintln(os.Stderr, "Warning:", signedMessage.Warning)
+			signedMessage.Warning = ""
+		}
+
 		return printMessage(cctx, signedMessage)
 	},
 }
@@ -79,6 +96,14 @@ var signTxAndSendCmd = &cli.Command{
 			Value:    "",
 			Required: true,
 		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "run the message through StateCall before signing and refuse it when it fails",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "sign even when the simulation fails",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		path := cctx.String("tx-path")
@@ -104,6 +129,10 @@ var signTxAndSendCmd = &cli.Command{
 			return err
 		}
 
+		if err := checkSimulation(cctx, walletAPI, &msg); err != nil {
+			return err
+		}
+
 		cid, err := walletAPI.SignAndSend(msg)
 		if err != nil {
 			return err
@@ -137,3 +166,34 @@ var signMsgCmd = &cli.Command{
 		return nil
 	},
 }
+
+// checkSimulation runs msg through StateCall with --simulate and refuses a message whose simulation failed,
+// at build time or now, unless --force is set
+func checkSimulation(cctx *cli.Context, walletAPI *client.OpenFilAPI, msg *chain.Message) error {
+	if cctx.Bool("simulate") {
+		sim, err := walletAPI.Simulate(*msg)
+		if err != nil {
+			return fmt.Errorf("simulating message: %w", err)
+		}
+		msg.Simulation = sim
+	}
+
+	sim := msg.Simulation
+	if sim == nil {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Simulation at height %d: exit code %d, gas used %d\n", sim.Height, sim.ExitCode, sim.GasUsed)
+	if sim.Return != "" {
+		fmt.Fprintf(os.Stderr, "Return: %s\n", sim.Return)
+	}
+
+	if sim.Failed() {
+		if !cctx.Bool("force") {
+			return fmt.Errorf("simulation failed: %s, use --force to sign anyway", sim.Error)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: simulation failed: %s\n", sim.Error)
+	}
+
+	return nil
+}
@@ -21,6 +21,7 @@ var statusCmd = &cli.Command{
 		}
 
 		fmt.Println("Wallet Lock:    ", si.Lock)
+		fmt.Println("Wallet Sealed:  ", si.Sealed)
 		fmt.Println("Wallet Offline: ", si.Offline)
 		fmt.Println("Wallet Version: ", si.Version)
 		return nil
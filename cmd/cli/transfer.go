@@ -43,6 +43,19 @@ var transferCmd = &cli.Command{
 			Usage:   "the max tx fee allowed for this transaction",
 			Value:   "1 FIL",
 		},
+		&cli.StringFlag{
+			Name:    "fee-preset",
+			Aliases: []string{"fp"},
+			Usage:   "estimate the gas fee with a preset instead of the gas flags: slow, normal, fast",
+		},
+		&cli.BoolFlag{
+			Name:  "simulate",
+			Usage: "run the built message through StateCall and refuse it when it fails",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "output the message even when the simulation fails",
+		},
 		&cli.StringFlag{
 			Name:    "output",
 			Aliases: []string{"o"},
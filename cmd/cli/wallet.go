@@ -5,6 +5,7 @@ import (
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/urfave/cli/v2"
 	"text/tabwriter"
+	"time"
 )
 
 var walletCmd = &cli.Command{
@@ -141,6 +142,12 @@ var walletHistoryCmd = &cli.Command{
 			Aliases: []string{"dp"},
 			Value:   false,
 		},
+		&cli.BoolFlag{
+			Name:    "display-fees",
+			Usage:   "display tx time, burned fees, miner tip and return",
+			Aliases: []string{"df"},
+			Value:   false,
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		addr := cctx.String("address")
@@ -155,18 +162,28 @@ var walletHistoryCmd = &cli.Command{
 		}
 		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
 		isDisplayParams := cctx.Bool("display-params")
+		isDisplayFees := cctx.Bool("display-fees")
+
+		header := "ID\tVersion\tDirection\tEpoch\tTo\tFrom\tNonce\tValue\tGasLimit\tGasFeeCap\tGasPremium\tGasUsed\tMethod"
 		if isDisplayParams {
-			fmt.Fprintf(w, "ID\tVersion\tTo\tFrom\tNonce\tValue\tGasLimit\tGasFeeCap\tGasPremium\tMethod\tParams\tMsgCid\tMsgState\n")
-		} else {
-			fmt.Fprintf(w, "ID\tVersion\tTo\tFrom\tNonce\tValue\tGasLimit\tGasFeeCap\tGasPremium\tMethod\tMsgCid\tMsgState\n")
+			header += "\tParams"
+		}
+		header += "\tMsgCid\tMsgState\tExitCode"
+		if isDisplayFees {
+			header += "\tTime\tBaseFeeBurn\tOverEstimationBurn\tMinerTip\tReturn"
 		}
+		fmt.Fprintln(w, header)
 
 		for i, tx := range txs {
+			row := fmt.Sprintf("%d\t%d\t%s\t%d\t%s\t%s\t%d\t%s\t%d\t%s\t%s\t%d\t%s", i, tx.Version, tx.Direction, tx.Epoch, tx.To, tx.From, tx.Nonce, tx.AttoValue, tx.GasLimit, tx.AttoGasFeeCap, tx.AttoGasPremium, tx.GasUsed, historyMethod(tx))
 			if isDisplayParams {
-				fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n", i, tx.Version, tx.To, tx.From, tx.Nonce, tx.Value, tx.GasLimit, tx.GasFeeCap, tx.GasPremium, tx.Method, tx.Params, tx.TxCid, tx.TxState)
-			} else {
-				fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t%s\n", i, tx.Version, tx.To, tx.From, tx.Nonce, tx.Value, tx.GasLimit, tx.GasFeeCap, tx.GasPremium, tx.Method, tx.TxCid, tx.TxState)
+				row += "\t" + tx.Params
 			}
+			row += fmt.Sprintf("\t%s\t%s\t%d", tx.TxCid, tx.TxState, tx.ExitCode)
+			if isDisplayFees {
+				row += fmt.Sprintf("\t%s\t%s\t%s\t%s\t%s", historyTime(tx), tx.BaseFeeBurn, tx.OverEstimationBurn, tx.MinerTip, tx.Return)
+			}
+			fmt.Fprintln(w, row)
 		}
 
 		if err := w.Flush(); err != nil {
@@ -176,3 +193,24 @@ var walletHistoryCmd = &cli.Command{
 		return nil
 	},
 }
+
+func historyMethod(tx client.HistoryResponse) string {
+	if tx.MethodName == "" {
+		return fmt.Sprint(tx.Method)
+	}
+
+	return fmt.Sprintf("%d (%s)", tx.Method, tx.MethodName)
+}
+
+// historyTime is the time the tx was included on chain, or submitted while it is pending
+func historyTime(tx client.HistoryResponse) string {
+	t := tx.Timestamp
+	if t == 0 {
+		t = tx.SubmitTime
+	}
+	if t == 0 {
+		return "-"
+	}
+
+	return time.Unix(t, 0).Format(time.RFC3339)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/urfave/cli/v2"
+	"text/tabwriter"
+	"time"
+)
+
+var watchCmd = &cli.Command{
+	Name:  "watch",
+	Usage: "addresses not owned by the wallet whose messages are indexed",
+	Subcommands: []*cli.Command{
+		watchListCmd,
+		watchAddCmd,
+		watchRemoveCmd,
+		watchStatusCmd,
+	},
+}
+
+var watchListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the watched addresses",
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		watches, err := walletAPI.WatchList()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "Address\tLabel\tAddedAt\n")
+		for _, watch := range watches {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", watch.Address, watch.Label, time.Unix(watch.AddedAt, 0).Format(time.RFC3339))
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
+
+var watchAddCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "watch an address",
+	ArgsUsage: "[address]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "label",
+			Usage: "label of the address",
+		},
+		&cli.Int64Flag{
+			Name:  "from-epoch",
+			Usage: "index the chain again from this epoch to backfill the history of the address",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have address param")
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		err = walletAPI.WatchAdd(client.WatchRequest{
+			Address:   cctx.Args().First(),
+			Label:     cctx.String("label"),
+			FromEpoch: cctx.Int64("from-epoch"),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("watch address success")
+		return nil
+	},
+}
+
+var watchRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "stop watching an address, its indexed history is kept",
+	ArgsUsage: "[address]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have address param")
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		err = walletAPI.WatchRemove(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("remove watch success")
+		return nil
+	},
+}
+
+var watchStatusCmd = &cli.Command{
+	Name:  "status",
+	Usage: "progress of the chain indexer",
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		status, err := walletAPI.IndexerStatus()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Enable:      ", status.Enable)
+		fmt.Println("Start epoch: ", status.StartEpoch)
+		fmt.Println("Height:      ", status.Height)
+		fmt.Println("Head:        ", status.Head)
+		if status.UpdatedAt != 0 {
+			fmt.Println("Updated at:  ", time.Unix(status.UpdatedAt, 0).Format(time.RFC3339))
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/urfave/cli/v2"
+	"text/tabwriter"
+	"time"
+)
+
+var actorWorkflowCmd = &cli.Command{
+	Name:  "workflow",
+	Usage: "follow the owner, worker and beneficiary changes until they are done on chain",
+	Subcommands: []*cli.Command{
+		workflowListCmd,
+		workflowCancelCmd,
+	},
+}
+
+var workflowListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "list the changes in progress with their next action and when it becomes possible",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "actor",
+			Aliases: []string{"a"},
+			Usage:   "only list the workflows of this miner",
+		},
+		&cli.BoolFlag{
+			Name:  "all",
+			Usage: "also list the done and cancelled workflows",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "print the workflows in json, with the confirm messages built by auto confirm to sign with sign-tx",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		wfs, err := walletAPI.Workflows(cctx.String("actor"), cctx.Bool("all"))
+		if err != nil {
+			return err
+		}
+
+		if cctx.Bool("json") {
+			data, err := json.MarshalIndent(wfs, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cctx.App.Writer, string(data))
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cctx.App.Writer, 8, 4, 2, ' ', 0)
+		fmt.Fprintf(w, "ID\tMiner\tTarget\tState\tNextAction\tSender\tReady\tConfirmBuilt\tDetail\n")
+		for _, wf := range wfs {
+			ready := "-"
+			if wf.ReadyEpoch != 0 {
+				ready = fmt.Sprintf("%d", wf.ReadyEpoch)
+				if wf.ReadyTime != 0 {
+					ready += " (" + time.Unix(wf.ReadyTime, 0).Format(time.RFC3339) + ")"
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%t\t%s\n", wf.ID, wf.MinerId, wf.Target, wf.State, wf.NextAction, wf.NextSender, ready, wf.ConfirmMessage != nil, wf.Detail)
+		}
+
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flushing output: %+v", err)
+		}
+
+		return nil
+	},
+}
+
+var workflowCancelCmd = &cli.Command{
+	Name:      "cancel",
+	Usage:     "stop following a workflow, nothing is sent on chain",
+	ArgsUsage: "[id]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have id param")
+		}
+
+		walletAPI, err := client.GetOpenFilAPI(cctx)
+		if err != nil {
+			return err
+		}
+
+		err = walletAPI.WorkflowCancel(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("cancel workflow success")
+		return nil
+	},
+}
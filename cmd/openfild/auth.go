@@ -57,7 +57,7 @@ var AuthCreateAdminToken = &cli.Command{
 			return fmt.Errorf("--perm flag has to be one of: %s", api.AllPermissions)
 		}
 
-		token, err := app.AuthNew(app.AllPermissions[:idx])
+		token, err := app.AuthNewOperator(app.AllPermissions[:idx])
 		if err != nil {
 			return err
 		}
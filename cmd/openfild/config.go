@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/repo"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/xerrors"
+)
+
+var configCmd = &cli.Command{
+	Name:  "config",
+	Usage: "Manage config.toml",
+	Subcommands: []*cli.Command{
+		configShowCmd,
+		configSetCmd,
+	},
+}
+
+var configShowCmd = &cli.Command{
+	Name:  "show",
+	Usage: "Show the effective config, env overrides included",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "file",
+			Usage: "only show config.toml, without env overrides",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		r, err := getRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		var cfg *config.Config
+		if cctx.Bool("file") {
+			cfg, err = config.FromFile(r.ConfigPath())
+		} else {
+			cfg, err = r.Config()
+		}
+		if err != nil {
+			return err
+		}
+
+		data, err := config.Encode(cfg)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(data))
+		return nil
+	},
+}
+
+var configSetCmd = &cli.Command{
+	Name:      "set",
+	Usage:     "Set a value in config.toml",
+	ArgsUsage: "<Section.Field> <value>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.NArg() != 2 {
+			return xerrors.Errorf("expected 2 arguments, keys: %v", config.Keys())
+		}
+
+		r, err := getRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.FromFile(r.ConfigPath())
+		if err != nil {
+			return err
+		}
+
+		key := cctx.Args().Get(0)
+		if err := config.Set(cfg, key, cctx.Args().Get(1)); err != nil {
+			return err
+		}
+
+		if err := config.Save(r.ConfigPath(), cfg); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s updated, send SIGHUP to a running openfild to reload it, API settings require a restart\n", key)
+		return nil
+	},
+}
+
+func getRepo(cctx *cli.Context) (*repo.FsRepo, error) {
+	r, err := repo.NewFS(cctx.String(repo.FlagWalletRepo))
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := r.Exists()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, xerrors.Errorf("repo at '%s' is not initialized, run 'openfild init' to set it up", cctx.String(repo.FlagWalletRepo))
+	}
+
+	return r, nil
+}
@@ -46,6 +46,9 @@ func main() {
 			walletCmd,
 			ethWalletCmd,
 			passwordCmd,
+			configCmd,
+			unsealCmd,
+			userCmd,
 		},
 	}
 
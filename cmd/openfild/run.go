@@ -4,8 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/OpenFilWallet/OpenFilWallet/account"
-	"github.com/OpenFilWallet/OpenFilWallet/crypto"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
 	"github.com/OpenFilWallet/OpenFilWallet/repo"
@@ -14,9 +13,12 @@ import (
 	"github.com/urfave/cli/v2"
 	"golang.org/x/xerrors"
 	"io/fs"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -27,7 +29,7 @@ var runCmd = &cli.Command{
 	Flags: []cli.Flag{
 		&cli.StringFlag{
 			Name:    "wallet-api",
-			Usage:   "wallet api port",
+			Usage:   "wallet api port, overrides API.ListenPort in config.toml",
 			EnvVars: []string{"OPEN_FIL_WALLET_API"},
 			Value:   "6678",
 		},
@@ -36,6 +38,15 @@ var runCmd = &cli.Command{
 			Usage: "offline wallet",
 			Value: false,
 		},
+		&cli.StringFlag{
+			Name:  "api-socket",
+			Usage: "also serve the wallet api on a Unix socket at the path, clients using the repo connect through it without a token",
+		},
+		&cli.StringFlag{
+			Name:    "master-password-file",
+			Usage:   "unseal at startup with the master password read from the file, a relative path is resolved in $CREDENTIALS_DIRECTORY when run as a systemd credential",
+			EnvVars: []string{"OPEN_FIL_WALLET_MASTER_PASSWORD_FILE"},
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		repoPath := cctx.String(repo.FlagWalletRepo)
@@ -52,14 +63,33 @@ var runCmd = &cli.Command{
 			return xerrors.Errorf("repo at '%s' is not initialized, run 'openfild init' to set it up", repo.FlagWalletRepo)
 		}
 
+		cfg, err := r.Config()
+		if err != nil {
+			return err
+		}
+
+		if cctx.IsSet("wallet-api") {
+			cfg.API.ListenPort = cctx.String("wallet-api")
+		}
+
 		lr, err := r.Lock()
 		if err != nil {
 			return err
 		}
 
-		endpoint := "localhost:" + cctx.String("wallet-api")
+		endpoint := "localhost:" + cfg.API.ListenPort
+		apiEndpoint := "http://" + endpoint
+
+		var apiSocket string
+		if cctx.IsSet("api-socket") {
+			apiSocket, err = filepath.Abs(cctx.String("api-socket"))
+			if err != nil {
+				return err
+			}
+			apiEndpoint = client.UnixSocketPrefix + apiSocket
+		}
 
-		err = lr.SetAPIEndpoint("http://" + endpoint)
+		err = lr.SetAPIEndpoint(apiEndpoint)
 		if err != nil {
 			return err
 		}
@@ -81,7 +111,7 @@ var runCmd = &cli.Command{
 		}
 
 		app.SetSecret(loginScrypt)
-		token, err := app.AuthNew(app.AllPermissions)
+		token, err := app.AuthNewOperator(app.AllPermissions)
 		if err != nil {
 			return err
 		}
@@ -100,30 +130,48 @@ var runCmd = &cli.Command{
 			return errors.New("mnemonic does not exist")
 		}
 
-		masterPassword, verified := verifyMasterPassword(db)
-		if !verified {
-			return errors.New("password verification failed")
+		var closeCh = make(chan struct{})
+		// new server
+		walletServer, err := wallet.NewWallet(cctx.Bool("offline"), db, cfg, closeCh)
+		if err != nil {
+			return fmt.Errorf("new Wallet fail: %s", err.Error())
 		}
 
-		_, err = account.LoadMnemonic(db, crypto.GenerateEncryptKey([]byte(masterPassword)))
-		if err != nil {
-			return fmt.Errorf("failed to decrypt mnemonic, err: %s", err.Error())
+		if cctx.IsSet("master-password-file") {
+			masterPassword, err := readMasterPasswordFile(cctx.String("master-password-file"))
+			if err != nil {
+				return err
+			}
+
+			if err := walletServer.UnsealWith(masterPassword); err != nil {
+				return fmt.Errorf("unseal wallet fail: %s", err.Error())
+			}
+		} else {
+			log.Info("wallet is sealed, run 'openfild unseal' or post the master password to /unseal")
 		}
 
-		var closeCh = make(chan struct{})
-		// new server
-		walletServer, err := wallet.NewWallet(cctx.Bool("offline"), masterPassword, db, closeCh)
+		unsealListener, err := lr.UnsealListener()
 		if err != nil {
-			return fmt.Errorf("new Wallet fail: %s", err.Error())
+			return fmt.Errorf("listen unseal socket fail: %s", err.Error())
 		}
 
-		router := walletServer.NewRouter()
+		unsealServer := &http.Server{Handler: walletServer.NewUnsealRouter()}
+		go func() {
+			if err := unsealServer.Serve(unsealListener); err != nil && err != http.ErrServerClosed {
+				log.Warnw("unseal socket serve fail", "err", err.Error())
+			}
+		}()
+
+		router, err := walletServer.NewRouter()
+		if err != nil {
+			return fmt.Errorf("new router fail: %s", err.Error())
+		}
 
 		s := &http.Server{
 			Addr:         endpoint,
-			Handler:      router,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
+			Handler:      wallet.EventStream(router),
+			ReadTimeout:  cfg.API.ReadTimeout.Duration(),
+			WriteTimeout: cfg.API.WriteTimeout.Duration(),
 		}
 
 		go func() {
@@ -150,7 +198,7 @@ var runCmd = &cli.Command{
 				mux.HandleFunc(router, redirectHandle)
 			}
 
-			srv := &http.Server{Addr: ":8080", Handler: mux}
+			srv := &http.Server{Addr: ":" + cfg.API.WebUIPort, Handler: mux}
 
 			if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 				log.Fatalf("gql.ListenAndServe(): %v", err)
@@ -164,9 +212,44 @@ var runCmd = &cli.Command{
 			}
 		}()
 
-		quit := make(chan os.Signal)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-		<-quit
+		var socketServer *http.Server
+		if apiSocket != "" {
+			socketListener, err := repo.ListenUnix(apiSocket)
+			if err != nil {
+				return fmt.Errorf("listen api socket fail: %s", err.Error())
+			}
+
+			socketServer = &http.Server{
+				Handler:      wallet.LocalSocket(wallet.EventStream(router)),
+				ReadTimeout:  cfg.API.ReadTimeout.Duration(),
+				WriteTimeout: cfg.API.WriteTimeout.Duration(),
+			}
+
+			log.Infow("start wallet server", "socket", apiSocket)
+			go func() {
+				if err := socketServer.Serve(socketListener); err != nil && err != http.ErrServerClosed {
+					log.Fatalf("socketServer.Serve err: %v", err)
+				}
+			}()
+		}
+
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		for sig := range quit {
+			if sig != syscall.SIGHUP {
+				break
+			}
+
+			newCfg, err := r.Config()
+			if err != nil {
+				log.Warnw("reload config fail", "err", err.Error())
+				continue
+			}
+
+			if err := walletServer.ReloadConfig(newCfg); err != nil {
+				log.Warnw("reload config fail", "err", err.Error())
+			}
+		}
 		close(closeCh)
 
 		log.Info("shutting down wallet server...")
@@ -177,6 +260,16 @@ var runCmd = &cli.Command{
 			log.Fatal("server forced to shutdown:", err)
 		}
 
+		if socketServer != nil {
+			if err := socketServer.Shutdown(ctx); err != nil {
+				log.Warnw("api socket shutdown fail", "err", err.Error())
+			}
+		}
+
+		if err := unsealServer.Shutdown(ctx); err != nil {
+			log.Warnw("unseal socket shutdown fail", "err", err.Error())
+		}
+
 		err = lr.Close()
 		if err != nil {
 			log.Warnw("wallet db close fail", "err", err.Error())
@@ -190,3 +283,18 @@ var runCmd = &cli.Command{
 		return nil
 	},
 }
+
+// readMasterPasswordFile reads the master password from path, with systemd
+// LoadCredential= a bare credential name is looked up in $CREDENTIALS_DIRECTORY
+func readMasterPasswordFile(path string) (string, error) {
+	if credDir, ok := os.LookupEnv("CREDENTIALS_DIRECTORY"); ok && !filepath.IsAbs(path) {
+		path = filepath.Join(credDir, path)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read master password file fail: %s", err.Error())
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
+	"github.com/urfave/cli/v2"
+)
+
+var unsealCmd = &cli.Command{
+	Name:  "unseal",
+	Usage: "Unseal a running openfild with the master password over the local unseal socket",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "master-password-file",
+			Usage: "read the master password from the file instead of prompting",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		r, err := getRepo(cctx)
+		if err != nil {
+			return err
+		}
+
+		var masterPassword string
+		if cctx.IsSet("master-password-file") {
+			masterPassword, err = readMasterPasswordFile(cctx.String("master-password-file"))
+		} else {
+			fmt.Println("Please enter master password")
+			masterPassword, err = app.Password(false)
+		}
+		if err != nil {
+			return err
+		}
+
+		req := client.UnsealRequest{
+			MasterPassword: masterPassword,
+		}
+
+		_, err = client.PostRequest(client.UnixSocketPrefix+r.UnsealSocketPath(), "/unseal", "", req)
+		if err != nil {
+			return fmt.Errorf("unseal fail: %s", err.Error())
+		}
+
+		fmt.Println("wallet unsealed")
+		return nil
+	},
+}
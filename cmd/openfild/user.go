@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/crypto"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
+	"github.com/filecoin-project/go-address"
+	"github.com/urfave/cli/v2"
+	"strings"
+)
+
+var userCmd = &cli.Command{
+	Name:  "user",
+	Usage: "Manage the named users of the web UI and api",
+	Subcommands: []*cli.Command{
+		userAddCmd,
+		userListCmd,
+		userRemoveCmd,
+		userPasswdCmd,
+	},
+}
+
+var userAddCmd = &cli.Command{
+	Name:      "add",
+	Usage:     "add a user, need master password",
+	ArgsUsage: "[name]",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "role",
+			Usage:    "role of the user, one of: viewer, builder, signer, admin",
+			Required: true,
+		},
+		&cli.StringSliceFlag{
+			Name:  "address",
+			Usage: "limit the user to the wallet or miner address, can be repeated",
+		},
+		&cli.StringSliceFlag{
+			Name:  "msig",
+			Usage: "limit the user to the msig address, can be repeated",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have name param")
+		}
+		name := cctx.Args().First()
+
+		role := app.Role(cctx.String("role"))
+		if _, err := app.RolePermissions(role); err != nil {
+			return err
+		}
+
+		addrs, err := parseUserAddresses(cctx.StringSlice("address"))
+		if err != nil {
+			return err
+		}
+
+		msigs, err := parseUserAddresses(cctx.StringSlice("msig"))
+		if err != nil {
+			return err
+		}
+
+		db, closer, err := getWalletDB(cctx, false)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := requirePassword(db); err != nil {
+			return err
+		}
+
+		_, verified := verifyMasterPassword(db)
+		if !verified {
+			return errors.New("password verification failed")
+		}
+
+		exist, err := db.HasUser(name)
+		if err != nil {
+			return err
+		}
+		if exist {
+			return fmt.Errorf("user %s already exists", name)
+		}
+
+		fmt.Printf("Please enter the password of user %s\n", name)
+		password, err := app.Password(true)
+		if err != nil {
+			return err
+		}
+
+		err = db.SetUser(&datastore.User{
+			Name:      name,
+			Role:      string(role),
+			Password:  crypto.Scrypt(password),
+			Addresses: addrs,
+			Msigs:     msigs,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("user added successfully")
+		return nil
+	},
+}
+
+var userListCmd = &cli.Command{
+	Name:  "list",
+	Usage: "user list",
+	Action: func(cctx *cli.Context) error {
+		db, closer, err := getWalletDB(cctx, true)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		users, err := db.UserList()
+		if err != nil {
+			return err
+		}
+
+		afmt := app.NewAppFmt(cctx.App)
+		for _, user := range users {
+			afmt.Println("User:      ", user.Name)
+			afmt.Println("Role:      ", user.Role)
+			if len(user.Addresses) != 0 {
+				afmt.Println("Addresses: ", strings.Join(user.Addresses, ", "))
+			}
+			if len(user.Msigs) != 0 {
+				afmt.Println("Msigs:     ", strings.Join(user.Msigs, ", "))
+			}
+			afmt.Println()
+		}
+
+		return nil
+	},
+}
+
+var userRemoveCmd = &cli.Command{
+	Name:      "remove",
+	Usage:     "remove a user, need master password",
+	ArgsUsage: "[name]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have name param")
+		}
+		name := cctx.Args().First()
+
+		db, closer, err := getWalletDB(cctx, false)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := requirePassword(db); err != nil {
+			return err
+		}
+
+		_, verified := verifyMasterPassword(db)
+		if !verified {
+			return errors.New("password verification failed")
+		}
+
+		exist, err := db.HasUser(name)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return fmt.Errorf("user %s does not exist", name)
+		}
+
+		err = db.DeleteUser(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("user removed successfully")
+		return nil
+	},
+}
+
+var userPasswdCmd = &cli.Command{
+	Name:      "passwd",
+	Usage:     "update the password of a user, need master password",
+	ArgsUsage: "[name]",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must have name param")
+		}
+		name := cctx.Args().First()
+
+		db, closer, err := getWalletDB(cctx, false)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := requirePassword(db); err != nil {
+			return err
+		}
+
+		_, verified := verifyMasterPassword(db)
+		if !verified {
+			return errors.New("password verification failed")
+		}
+
+		user, err := db.GetUser(name)
+		if err != nil {
+			return fmt.Errorf("user %s does not exist", name)
+		}
+
+		fmt.Printf("Please enter a new password of user %s\n", name)
+		password, err := app.Password(true)
+		if err != nil {
+			return err
+		}
+
+		user.Password = crypto.Scrypt(password)
+		err = db.UpdateUser(user)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("user password updated successfully")
+		return nil
+	},
+}
+
+func parseUserAddresses(addrs []string) ([]string, error) {
+	var res []string
+	for _, addrStr := range addrs {
+		if strings.HasPrefix(addrStr, "0x") {
+			res = append(res, strings.ToLower(addrStr))
+			continue
+		}
+
+		addr, err := address.NewFromString(addrStr)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, addr.String())
+	}
+
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+)
+
+const (
+	automationRulePrefix = "/automation/rule"
+	automationRunPrefix  = "/automation/run"
+)
+
+type AutomationStore struct {
+	ruleStore *StateStore
+	runStore  *StateStore
+}
+
+func newAutomationStore(ds datastore.Batching) *AutomationStore {
+	return &AutomationStore{
+		ruleStore: NewStateStore(namespace.Wrap(ds, datastore.NewKey(automationRulePrefix))),
+		runStore:  NewStateStore(namespace.Wrap(ds, datastore.NewKey(automationRunPrefix))),
+	}
+}
+
+func (db *AutomationStore) putRule(rule *AutomationRule) error {
+	return db.ruleStore.Begin(rule.ID, rule, true)
+}
+
+func (db *AutomationStore) getRule(id string) (*AutomationRule, error) {
+	var rule AutomationRule
+	val, err := db.ruleStore.Get(id).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(val, &rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
+
+func (db *AutomationStore) hasRule(id string) (bool, error) {
+	return db.ruleStore.Has(id)
+}
+
+func (db *AutomationStore) deleteRule(id string) error {
+	return db.ruleStore.Get(id).Delete()
+}
+
+func (db *AutomationStore) listRules() ([]AutomationRule, error) {
+	var rules []AutomationRule
+	err := db.ruleStore.List(&rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// putRun keys the run by its time so the runs of all rules list in order
+func (db *AutomationStore) putRun(run *AutomationRun) error {
+	return db.runStore.Begin(fmt.Sprintf("%020d", run.Time), run, true)
+}
+
+func (db *AutomationStore) listRuns() ([]AutomationRun, error) {
+	var runs []AutomationRun
+	err := db.runStore.List(&runs)
+	if err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
@@ -2,7 +2,6 @@ package datastore
 
 import (
 	"encoding/json"
-	"errors"
 	"github.com/ipfs/go-datastore"
 	"github.com/ipfs/go-datastore/namespace"
 	"path/filepath"
@@ -84,18 +83,28 @@ func (db *HistoryStore) list(addr string) ([]History, error) {
 	return msgs, nil
 }
 
+// listAll returns the history of every address
+func (db *HistoryStore) listAll() ([]History, error) {
+	var msgs []History
+	err := NewStateStore(namespace.Wrap(db.ds, datastore.NewKey(historyBasePrefix))).List(&msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+// getStore sets up the recorder of addr when missing, watched addresses and
+// addresses first found by the indexer have no recorder after a restart
 func (db *HistoryStore) getStore(addr string) (*StateStore, error) {
 	db.lk.Lock()
 	defer db.lk.Unlock()
-	var store *StateStore
 
-	if st, ok := db.recorder[addr]; !ok {
-		return nil, errors.New("history does not exist")
-	} else {
-		store = st
+	if _, ok := db.recorder[addr]; !ok {
+		db.setupRecorder(addr)
 	}
 
-	return store, nil
+	return db.recorder[addr], nil
 }
 
 func txHistoryKey(addr string) datastore.Key {
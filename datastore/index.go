@@ -0,0 +1,119 @@
+package datastore
+
+import (
+	"encoding/json"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	incomingBasePrefix = "/transaction/incoming"
+	watchPrefix        = "/watch/address"
+	indexerPrefix      = "/indexer/state"
+	indexerStateKey    = "state"
+)
+
+// IndexStore keeps what the chain indexer finds besides the outgoing messages,
+// incoming messages are keyed by message cid under the address receiving them
+type IndexStore struct {
+	ds       datastore.Batching
+	incoming map[string]*StateStore
+	lk       sync.Mutex
+
+	watchStore *StateStore
+	stateStore *StateStore
+}
+
+func newIndexStore(ds datastore.Batching) *IndexStore {
+	return &IndexStore{
+		ds:         ds,
+		incoming:   make(map[string]*StateStore),
+		watchStore: NewStateStore(namespace.Wrap(ds, datastore.NewKey(watchPrefix))),
+		stateStore: NewStateStore(namespace.Wrap(ds, datastore.NewKey(indexerPrefix))),
+	}
+}
+
+func (db *IndexStore) incomingStore(addr string) *StateStore {
+	db.lk.Lock()
+	defer db.lk.Unlock()
+
+	if _, ok := db.incoming[addr]; !ok {
+		db.incoming[addr] = NewStateStore(namespace.Wrap(db.ds, incomingKey(addr)))
+	}
+
+	return db.incoming[addr]
+}
+
+func (db *IndexStore) putIncoming(addr string, msg *History) error {
+	return db.incomingStore(addr).Begin(msg.TxCid, msg, true)
+}
+
+func (db *IndexStore) hasIncoming(addr string, txCid string) (bool, error) {
+	return db.incomingStore(addr).Has(txCid)
+}
+
+func (db *IndexStore) listIncoming(addr string) ([]History, error) {
+	var msgs []History
+	err := db.incomingStore(addr).List(&msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return msgs, nil
+}
+
+func (db *IndexStore) putWatch(watch *WatchedAddress) error {
+	return db.watchStore.Begin(watch.Address, watch, true)
+}
+
+func (db *IndexStore) hasWatch(addr string) (bool, error) {
+	return db.watchStore.Has(addr)
+}
+
+func (db *IndexStore) deleteWatch(addr string) error {
+	return db.watchStore.Get(addr).Delete()
+}
+
+func (db *IndexStore) listWatch() ([]WatchedAddress, error) {
+	var watches []WatchedAddress
+	err := db.watchStore.List(&watches)
+	if err != nil {
+		return nil, err
+	}
+
+	return watches, nil
+}
+
+func (db *IndexStore) putState(state *IndexerState) error {
+	return db.stateStore.Begin(indexerStateKey, state, true)
+}
+
+// getState returns an empty state before the first indexed epoch
+func (db *IndexStore) getState() (*IndexerState, error) {
+	has, err := db.stateStore.Has(indexerStateKey)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return &IndexerState{}, nil
+	}
+
+	val, err := db.stateStore.Get(indexerStateKey).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	var state IndexerState
+	err = json.Unmarshal(val, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func incomingKey(addr string) datastore.Key {
+	return datastore.NewKey(filepath.Join(incomingBasePrefix, addr))
+}
@@ -0,0 +1,47 @@
+package datastore
+
+import (
+	"encoding/json"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+)
+
+const noncePrefix = "/nonce/state"
+
+type NonceStore struct {
+	nonceStore *StateStore
+}
+
+func newNonceStore(ds datastore.Batching) *NonceStore {
+	return &NonceStore{
+		nonceStore: NewStateStore(namespace.Wrap(ds, datastore.NewKey(noncePrefix))),
+	}
+}
+
+func (db *NonceStore) put(state *NonceState) error {
+	return db.nonceStore.Begin(state.Address, state, true)
+}
+
+// get returns an empty state for an address without one
+func (db *NonceStore) get(addr string) (*NonceState, error) {
+	has, err := db.nonceStore.Has(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !has {
+		return &NonceState{Address: addr}, nil
+	}
+
+	var state NonceState
+	val, err := db.nonceStore.Get(addr).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(val, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
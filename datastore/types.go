@@ -26,6 +26,36 @@ type NodeInfo struct {
 	Token    string `json:"token"`
 }
 
+type User struct {
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+	Password []byte `json:"password"`
+	// Addresses and Msigs limit the wallets and miners the user can act on, empty means no limit,
+	// the miners of a limited user are listed in Addresses
+	Addresses []string `json:"addresses"`
+	Msigs     []string `json:"msigs"`
+}
+
+// NonceState tracks the nonces of an address that are not known to the chain yet
+type NonceState struct {
+	Address string `json:"address"`
+	// Reserved are the nonces given to built messages that were not pushed yet
+	Reserved []NonceReservation `json:"reserved"`
+	// Signed are the latest nonces signed by this wallet
+	Signed []SignedNonce `json:"signed"`
+}
+
+type NonceReservation struct {
+	Nonce      uint64 `json:"nonce"`
+	ReservedAt int64  `json:"reserved_at"`
+}
+
+type SignedNonce struct {
+	Nonce    uint64 `json:"nonce"`
+	MsgCid   string `json:"msg_cid"`
+	SignedAt int64  `json:"signed_at"`
+}
+
 type MsgState string
 
 const (
@@ -49,4 +79,168 @@ type History struct {
 	TxCid      string   `json:"tx_cid"`
 	TxState    MsgState `json:"tx_state"`
 	Detail     string   `json:"detail"`
+	// AttoValue, AttoGasFeeCap and AttoGasPremium are the amounts in attoFIL, Value, GasFeeCap and GasPremium
+	// overflow above ~9.22 FIL and are only exact for the records written before schema 2
+	AttoValue      string `json:"atto_value,omitempty"`
+	AttoGasFeeCap  string `json:"atto_gas_feecap,omitempty"`
+	AttoGasPremium string `json:"atto_gas_premium,omitempty"`
+	// Schema is the record layout version, records written before HistorySchema are migrated on start
+	Schema int `json:"schema,omitempty"`
+	// SubmitTime is when the message was pushed by this wallet, 0 for messages found by the indexer
+	SubmitTime int64 `json:"submit_time,omitempty"`
+	// the fields below are known once the message is found on chain, Epoch is the height of the tipset
+	// the message was executed in, InclusionEpoch, InclusionTipSet and Timestamp describe its parent that includes the message
+	Direction       Direction `json:"direction,omitempty"`
+	Epoch           int64     `json:"epoch,omitempty"`
+	InclusionEpoch  int64     `json:"inclusion_epoch,omitempty"`
+	InclusionTipSet string    `json:"inclusion_tipset,omitempty"`
+	Timestamp       int64     `json:"timestamp,omitempty"`
+	ExitCode        int64     `json:"exit_code,omitempty"`
+	GasUsed         int64     `json:"gas_used,omitempty"`
+	MethodName      string    `json:"method_name,omitempty"`
+	// BaseFeeBurn, OverEstimationBurn and MinerTip are in attoFIL
+	BaseFeeBurn        string `json:"base_fee_burn,omitempty"`
+	OverEstimationBurn string `json:"over_estimation_burn,omitempty"`
+	MinerTip           string `json:"miner_tip,omitempty"`
+	// Return is the decoded return in json, hex when it can not be decoded
+	Return string `json:"return,omitempty"`
+}
+
+const (
+	// HistoryReceiptSchema added the receipt and fee fields
+	HistoryReceiptSchema = 1
+	// HistorySchema is the current layout of History, 2 added the attoFIL amounts
+	HistorySchema = 2
+)
+
+type Direction string
+
+const (
+	Outgoing Direction = "out"
+	Incoming Direction = "in"
+	Self     Direction = "self"
+)
+
+// WatchedAddress is an address that is not owned by the wallet but whose messages are indexed
+type WatchedAddress struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+	AddedAt int64  `json:"added_at"`
+}
+
+// IndexerState is the progress of the chain indexer
+type IndexerState struct {
+	// StartEpoch is the configured start epoch the index was built from
+	StartEpoch int64 `json:"start_epoch"`
+	// Height is the last indexed epoch
+	Height    int64 `json:"height"`
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+type AutomationKind string
+
+const (
+	// AutomationWithdraw withdraws the available balance of a miner once it exceeds the threshold
+	AutomationWithdraw AutomationKind = "withdraw"
+	// AutomationTopUp tops the worker and control addresses of a miner below the threshold up to the target
+	AutomationTopUp AutomationKind = "topup"
+)
+
+// AutomationRule is run by the wallet without a user, rules saved before Kind existed are withdrawals
+type AutomationRule struct {
+	ID      string         `json:"id"`
+	Kind    AutomationKind `json:"kind,omitempty"`
+	MinerId string         `json:"miner_id"`
+	// Threshold and Amount are in FIL, an empty Amount withdraws all that can be withdrawn
+	Threshold string `json:"threshold"`
+	Amount    string `json:"amount"`
+	// Sender and From are the sender and msig proposer of the withdrawal, see buildmessage.NewWithdrawMessage
+	Sender    string `json:"sender"`
+	From      string `json:"from"`
+	FeePreset string `json:"fee_preset"`
+	// Funder sends the top-ups, Target is the balance in FIL they bring an address back to,
+	// an alert is raised when the balance of Funder falls below FunderLow in FIL
+	Funder    string `json:"funder,omitempty"`
+	Target    string `json:"target,omitempty"`
+	FunderLow string `json:"funder_low,omitempty"`
+	// Interval is the least number of seconds between two runs of the rule
+	Interval  int64 `json:"interval"`
+	Enable    bool  `json:"enable"`
+	CreatedAt int64 `json:"created_at"`
+	LastRun   int64 `json:"last_run"`
+}
+
+type AutomationAction string
+
+const (
+	// AutomationPushed is a message signed by the wallet and pushed
+	AutomationPushed AutomationAction = "pushed"
+	// AutomationProposal is an unsigned msig proposal left for a signer of the msig
+	AutomationProposal AutomationAction = "proposal"
+	// AutomationSkipped is a run stopped by the spending policy
+	AutomationSkipped AutomationAction = "skipped"
+	AutomationFailed  AutomationAction = "failed"
+)
+
+type AutomationRun struct {
+	RuleID  string         `json:"rule_id"`
+	Kind    AutomationKind `json:"kind,omitempty"`
+	MinerId string         `json:"miner_id"`
+	// Time is unix nanoseconds
+	Time   int64            `json:"time"`
+	Action AutomationAction `json:"action"`
+	// Available is the miner available balance of a withdrawal and the balance of To before a top-up,
+	// Available and Amount are in attoFIL
+	Available string `json:"available"`
+	Amount    string `json:"amount"`
+	From      string `json:"from"`
+	To        string `json:"to,omitempty"`
+	Nonce     uint64 `json:"nonce"`
+	TxCid     string `json:"tx_cid"`
+	// Message is the unsigned message in json of a proposal
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+}
+
+type WorkflowKind string
+
+const (
+	WorkflowChangeWorker      WorkflowKind = "change_worker"
+	WorkflowChangeOwner       WorkflowKind = "change_owner"
+	WorkflowChangeBeneficiary WorkflowKind = "change_beneficiary"
+)
+
+type WorkflowState string
+
+const (
+	// WorkflowStarted is an operation whose first message is built but not seen on chain yet
+	WorkflowStarted WorkflowState = "started"
+	// WorkflowWaiting is an operation on chain whose next action is not possible before ReadyEpoch
+	WorkflowWaiting WorkflowState = "waiting"
+	// WorkflowReady is an operation whose next action is possible
+	WorkflowReady     WorkflowState = "ready"
+	WorkflowDone      WorkflowState = "done"
+	WorkflowCancelled WorkflowState = "cancelled"
+)
+
+// Workflow is a two-step miner operation followed until it is done on chain
+type Workflow struct {
+	ID      string       `json:"id"`
+	Kind    WorkflowKind `json:"kind"`
+	MinerId string       `json:"miner_id"`
+	// Target is the id address of the new worker, owner or beneficiary
+	Target string        `json:"target"`
+	State  WorkflowState `json:"state"`
+	// NextAction is sent by NextSender from ReadyEpoch
+	NextAction string `json:"next_action"`
+	NextSender string `json:"next_sender"`
+	ReadyEpoch int64  `json:"ready_epoch"`
+	// AutoConfirm builds the confirm message once the workflow is ready, ConfirmMessage is
+	// the unsigned message in json and ConfirmSender its sender
+	AutoConfirm    bool   `json:"auto_confirm"`
+	ConfirmMessage string `json:"confirm_message"`
+	ConfirmSender  string `json:"confirm_sender"`
+	Detail         string `json:"detail"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
 }
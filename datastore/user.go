@@ -0,0 +1,56 @@
+package datastore
+
+import (
+	"encoding/json"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+)
+
+const userPrefix = "/user/info"
+
+type UserStore struct {
+	userStore *StateStore
+}
+
+func newUserStore(ds datastore.Batching) *UserStore {
+	return &UserStore{
+		userStore: NewStateStore(namespace.Wrap(ds, datastore.NewKey(userPrefix))),
+	}
+}
+
+func (db *UserStore) put(user *User, force bool) error {
+	return db.userStore.Begin(user.Name, user, force)
+}
+
+func (db *UserStore) get(name string) (*User, error) {
+	var user User
+	val, err := db.userStore.Get(name).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(val, &user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (db *UserStore) has(name string) (bool, error) {
+	return db.userStore.Has(name)
+}
+
+func (db *UserStore) delete(name string) error {
+	return db.userStore.Get(name).Delete()
+}
+
+func (db *UserStore) list() ([]User, error) {
+	var users []User
+	err := db.userStore.List(&users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
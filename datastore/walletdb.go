@@ -11,6 +11,11 @@ type WalletDB struct {
 	kStore  *KeyStore
 	nStore  *NodeStore
 	sStore  *ScryptStore
+	uStore  *UserStore
+	ncStore *NonceStore
+	iStore  *IndexStore
+	aStore  *AutomationStore
+	wStore  *WorkflowStore
 }
 
 func NewWalletDB(ds datastore.Batching) WalletDB {
@@ -20,6 +25,11 @@ func NewWalletDB(ds datastore.Batching) WalletDB {
 		kStore:  newKeyStore(ds),
 		nStore:  newNodeStore(ds),
 		sStore:  newScryptStore(ds),
+		uStore:  newUserStore(ds),
+		ncStore: newNonceStore(ds),
+		iStore:  newIndexStore(ds),
+		aStore:  newAutomationStore(ds),
+		wStore:  newWorkflowStore(ds),
 	}
 
 	walletLists, _ := walletDB.WalletList()
@@ -130,6 +140,70 @@ func (db *WalletDB) NodeList() ([]NodeInfo, error) {
 	return db.nStore.list()
 }
 
+// ------ user ------
+
+func (db *WalletDB) HasUser(name string) (bool, error) {
+	if name == "" {
+		return false, errors.New("user name cannot be empty")
+	}
+
+	return db.uStore.has(name)
+}
+
+func (db *WalletDB) GetUser(name string) (*User, error) {
+	if name == "" {
+		return nil, errors.New("user name cannot be empty")
+	}
+
+	return db.uStore.get(name)
+}
+
+func (db *WalletDB) SetUser(user *User) error {
+	if user.Name == "" {
+		return errors.New("user name cannot be empty")
+	}
+
+	return db.uStore.put(user, false)
+}
+
+func (db *WalletDB) UpdateUser(user *User) error {
+	if user.Name == "" {
+		return errors.New("user name cannot be empty")
+	}
+
+	return db.uStore.put(user, true)
+}
+
+func (db *WalletDB) DeleteUser(name string) error {
+	if name == "" {
+		return errors.New("user name cannot be empty")
+	}
+
+	return db.uStore.delete(name)
+}
+
+func (db *WalletDB) UserList() ([]User, error) {
+	return db.uStore.list()
+}
+
+// ------ nonce ------
+
+func (db *WalletDB) GetNonceState(addr string) (*NonceState, error) {
+	if addr == "" {
+		return nil, errors.New("addr cannot be empty")
+	}
+
+	return db.ncStore.get(addr)
+}
+
+func (db *WalletDB) SetNonceState(state *NonceState) error {
+	if state.Address == "" {
+		return errors.New("addr cannot be empty")
+	}
+
+	return db.ncStore.put(state)
+}
+
 // ------ history -------
 
 func (db *WalletDB) GetHistory(addr string, nonce uint64) (*History, error) {
@@ -164,6 +238,117 @@ func (db *WalletDB) HistoryList(addr string) ([]History, error) {
 	return db.hStore.list(addr)
 }
 
+// HistoryListAll returns the history of every address, it is used to migrate the records
+func (db *WalletDB) HistoryListAll() ([]History, error) {
+	return db.hStore.listAll()
+}
+
+// ------ index ------
+
+// SetIncoming records a message received by addr, it replaces the record of the same message
+func (db *WalletDB) SetIncoming(addr string, msg *History) error {
+	if addr == "" {
+		return errors.New("addr cannot be empty")
+	}
+	if msg.TxCid == "" {
+		return errors.New("tx cid cannot be empty")
+	}
+
+	return db.iStore.putIncoming(addr, msg)
+}
+
+func (db *WalletDB) HasIncoming(addr string, txCid string) (bool, error) {
+	return db.iStore.hasIncoming(addr, txCid)
+}
+
+func (db *WalletDB) IncomingList(addr string) ([]History, error) {
+	if addr == "" {
+		return nil, errors.New("addr cannot be empty")
+	}
+
+	return db.iStore.listIncoming(addr)
+}
+
+func (db *WalletDB) SetWatch(watch *WatchedAddress) error {
+	if watch.Address == "" {
+		return errors.New("addr cannot be empty")
+	}
+
+	return db.iStore.putWatch(watch)
+}
+
+func (db *WalletDB) HasWatch(addr string) (bool, error) {
+	return db.iStore.hasWatch(addr)
+}
+
+func (db *WalletDB) DeleteWatch(addr string) error {
+	return db.iStore.deleteWatch(addr)
+}
+
+func (db *WalletDB) WatchList() ([]WatchedAddress, error) {
+	return db.iStore.listWatch()
+}
+
+func (db *WalletDB) GetIndexerState() (*IndexerState, error) {
+	return db.iStore.getState()
+}
+
+func (db *WalletDB) SetIndexerState(state *IndexerState) error {
+	return db.iStore.putState(state)
+}
+
+// ------ automation ------
+
+func (db *WalletDB) GetAutomationRule(id string) (*AutomationRule, error) {
+	return db.aStore.getRule(id)
+}
+
+func (db *WalletDB) SetAutomationRule(rule *AutomationRule) error {
+	if rule.ID == "" {
+		return errors.New("rule id cannot be empty")
+	}
+
+	return db.aStore.putRule(rule)
+}
+
+func (db *WalletDB) HasAutomationRule(id string) (bool, error) {
+	return db.aStore.hasRule(id)
+}
+
+func (db *WalletDB) DeleteAutomationRule(id string) error {
+	return db.aStore.deleteRule(id)
+}
+
+func (db *WalletDB) AutomationRuleList() ([]AutomationRule, error) {
+	return db.aStore.listRules()
+}
+
+func (db *WalletDB) SetAutomationRun(run *AutomationRun) error {
+	return db.aStore.putRun(run)
+}
+
+func (db *WalletDB) AutomationRunList() ([]AutomationRun, error) {
+	return db.aStore.listRuns()
+}
+
+// ------ workflow ------
+
+func (db *WalletDB) GetWorkflow(id string) (*Workflow, error) {
+	return db.wStore.get(id)
+}
+
+func (db *WalletDB) SetWorkflow(wf *Workflow) error {
+	if wf.ID == "" {
+		return errors.New("workflow id cannot be empty")
+	}
+
+	return db.wStore.put(wf)
+}
+
+func (db *WalletDB) WorkflowList() ([]Workflow, error) {
+	return db.wStore.list()
+}
+
 // ------ keystore ------
 
 func (db *WalletDB) HasMnemonic() (bool, error) {
@@ -3,6 +3,8 @@ package datastore
 import (
 	"context"
 	"github.com/OpenFilWallet/OpenFilWallet/repo"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
 	"github.com/stretchr/testify/require"
 	"testing"
 )
@@ -36,3 +38,21 @@ func TestNewWalletDB(t *testing.T) {
 	require.Equal(t, db.SetLoginPassword([]byte("login password")).Error(), "scrypt already exist")
 
 }
+
+func TestHistoryListAll(t *testing.T) {
+	db := NewWalletDB(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	require.NoError(t, db.SetHistory(&History{From: "f01000", Nonce: 1, TxCid: "a"}))
+	require.NoError(t, db.SetHistory(&History{From: "f01000", Nonce: 2, TxCid: "b"}))
+	require.NoError(t, db.SetHistory(&History{From: "f01001", Nonce: 1, TxCid: "c"}))
+	require.NoError(t, db.SetIncoming("f01000", &History{From: "f01002", To: "f01000", TxCid: "d"}))
+
+	all, err := db.HistoryListAll()
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+
+	// an address without history has an empty list
+	none, err := db.HistoryList("f01002")
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
@@ -0,0 +1,48 @@
+package datastore
+
+import (
+	"encoding/json"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+)
+
+const workflowPrefix = "/workflow/state"
+
+type WorkflowStore struct {
+	workflowStore *StateStore
+}
+
+func newWorkflowStore(ds datastore.Batching) *WorkflowStore {
+	return &WorkflowStore{
+		workflowStore: NewStateStore(namespace.Wrap(ds, datastore.NewKey(workflowPrefix))),
+	}
+}
+
+func (db *WorkflowStore) put(wf *Workflow) error {
+	return db.workflowStore.Begin(wf.ID, wf, true)
+}
+
+func (db *WorkflowStore) get(id string) (*Workflow, error) {
+	var wf Workflow
+	val, err := db.workflowStore.Get(id).Get()
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(val, &wf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wf, nil
+}
+
+func (db *WorkflowStore) list() ([]Workflow, error) {
+	var wfs []Workflow
+	err := db.workflowStore.List(&wfs)
+	if err != nil {
+		return nil, err
+	}
+
+	return wfs, nil
+}
@@ -21,6 +21,33 @@ var (
 var AllPermissions = []Permission{PermRead, PermWrite, PermSign, PermAdmin}
 var SignPermissions = []Permission{PermRead, PermWrite, PermSign}
 
+type Role string
+
+var (
+	RoleViewer  Role = "viewer"
+	RoleBuilder Role = "builder"
+	RoleSigner  Role = "signer"
+	RoleAdmin   Role = "admin"
+)
+
+var AllRoles = []Role{RoleViewer, RoleBuilder, RoleSigner, RoleAdmin}
+
+// RolePermissions returns the permissions granted to role
+func RolePermissions(role Role) ([]Permission, error) {
+	switch role {
+	case RoleViewer:
+		return []Permission{PermRead}, nil
+	case RoleBuilder:
+		return []Permission{PermRead, PermWrite}, nil
+	case RoleSigner:
+		return SignPermissions, nil
+	case RoleAdmin:
+		return AllPermissions, nil
+	default:
+		return nil, fmt.Errorf("unknown role: %s, must be one of: %s", role, AllRoles)
+	}
+}
+
 const (
 	saltSize       = 8
 	saltBase64Size = 12
@@ -30,6 +57,17 @@ var apiSecret []byte
 
 type jwtPayload struct {
 	Allow []Permission // Restrict calls to certain methods
+	User  string       `json:",omitempty"` // Named user the token was issued to, empty for the login password
+	// Operator marks the tokens openfild writes to the repo or creates for the operator of the repo,
+	// they stay valid once named users exist
+	Operator bool `json:",omitempty"`
+}
+
+// Token is the verified content of a token
+type Token struct {
+	User     string
+	Allow    []Permission
+	Operator bool
 }
 
 func SetSecret(loginScrypt []byte) {
@@ -37,6 +75,19 @@ func SetSecret(loginScrypt []byte) {
 }
 
 func AuthNew(allow []Permission) ([]byte, error) {
+	return AuthNewUser("", allow)
+}
+
+func AuthNewUser(user string, allow []Permission) ([]byte, error) {
+	return authNew(jwtPayload{Allow: allow, User: user})
+}
+
+// AuthNewOperator issues a token to the operator of the repo
+func AuthNewOperator(allow []Permission) ([]byte, error) {
+	return authNew(jwtPayload{Allow: allow, Operator: true})
+}
+
+func authNew(p jwtPayload) ([]byte, error) {
 	if apiSecret == nil {
 		return nil, errors.New("must be call SetSecret")
 	}
@@ -45,10 +96,6 @@ func AuthNew(allow []Permission) ([]byte, error) {
 		panic(err)
 	}
 
-	p := jwtPayload{
-		allow,
-	}
-
 	token, err := jwt.Sign(&p, jwt.NewHS256(append(apiSecret, salt...)))
 	if err != nil {
 		return nil, err
@@ -58,6 +105,26 @@ func AuthNew(allow []Permission) ([]byte, error) {
 }
 
 func AuthVerify(token string) ([]Permission, error) {
+	_, allow, err := AuthVerifyUser(token)
+	return allow, err
+}
+
+// AuthVerifyUser returns the user and the permissions carried by token
+func AuthVerifyUser(token string) (string, []Permission, error) {
+	t, err := AuthVerifyToken(token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return t.User, t.Allow, nil
+}
+
+// AuthVerifyToken returns the user, the permissions and the operator claim carried by token
+func AuthVerifyToken(token string) (*Token, error) {
+	if len(token) < saltBase64Size {
+		return nil, errors.New("invalid token")
+	}
+
 	saltBase64 := []byte(token)[:saltBase64Size]
 	salt, err := base64.StdEncoding.DecodeString(string(saltBase64))
 	if err != nil {
@@ -70,5 +137,5 @@ func AuthVerify(token string) ([]Permission, error) {
 		return nil, fmt.Errorf("JWT Verification failed: %w", err)
 	}
 
-	return payload.Allow, nil
+	return &Token{User: payload.User, Allow: payload.Allow, Operator: payload.Operator}, nil
 }
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAuthNewUser(t *testing.T) {
+	SetSecret([]byte("secret"))
+
+	perms, err := RolePermissions(RoleBuilder)
+	require.NoError(t, err)
+	require.Equal(t, []Permission{PermRead, PermWrite}, perms)
+
+	_, err = RolePermissions("owner")
+	require.Error(t, err)
+
+	token, err := AuthNewUser("alice", perms)
+	require.NoError(t, err)
+
+	user, allow, err := AuthVerifyUser(string(token))
+	require.NoError(t, err)
+	require.Equal(t, "alice", user)
+	require.Equal(t, perms, allow)
+
+	token, err = AuthNew(AllPermissions)
+	require.NoError(t, err)
+
+	user, allow, err = AuthVerifyUser(string(token))
+	require.NoError(t, err)
+	require.Equal(t, "", user)
+	require.Equal(t, AllPermissions, allow)
+
+	tok, err := AuthVerifyToken(string(token))
+	require.NoError(t, err)
+	require.False(t, tok.Operator)
+
+	token, err = AuthNewOperator(AllPermissions)
+	require.NoError(t, err)
+
+	tok, err = AuthVerifyToken(string(token))
+	require.NoError(t, err)
+	require.True(t, tok.Operator)
+	require.Equal(t, "", tok.User)
+}
@@ -0,0 +1,154 @@
+package buildmessage
+
+import (
+	"context"
+	"fmt"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/build"
+	"github.com/filecoin-project/lotus/chain/types"
+	"math"
+	"sort"
+)
+
+type FeePreset string
+
+const (
+	FeeSlow   FeePreset = "slow"
+	FeeNormal FeePreset = "normal"
+	FeeFast   FeePreset = "fast"
+)
+
+var FeePresets = []FeePreset{FeeSlow, FeeNormal, FeeFast}
+
+// feeLookback is how many recent tipsets the parent base fees are read from
+const feeLookback = 10
+
+// gasLimitOverestimation is the lotus default applied on top of the estimated gas used
+const gasLimitOverestimation = 1.25
+
+type feePresetParams struct {
+	// inclusion is the number of blocks GasEstimateGasPremium targets
+	inclusion uint64
+	// capEpochs is how many epochs of maximal base fee increase the fee cap tolerates
+	capEpochs int64
+}
+
+var feePresetParamsMap = map[FeePreset]feePresetParams{
+	FeeSlow:   {inclusion: 10, capEpochs: 2},
+	FeeNormal: {inclusion: 3, capEpochs: 8},
+	FeeFast:   {inclusion: 1, capEpochs: 20},
+}
+
+func ParseFeePreset(s string) (FeePreset, error) {
+	preset := FeePreset(s)
+	if _, ok := feePresetParamsMap[preset]; !ok {
+		return "", fmt.Errorf("unknown fee preset %s, must be one of: slow, normal, fast", s)
+	}
+
+	return preset, nil
+}
+
+// BaseFees is the current parent base fee and its range over the recent tipsets
+type BaseFees struct {
+	Current abi.TokenAmount
+	Min     abi.TokenAmount
+	Median  abi.TokenAmount
+	Max     abi.TokenAmount
+}
+
+type FeeEstimate struct {
+	Preset     FeePreset
+	GasLimit   int64
+	GasFeeCap  abi.TokenAmount
+	GasPremium abi.TokenAmount
+	// WorstCaseFee is GasFeeCap * GasLimit, the most the message can cost
+	WorstCaseFee abi.TokenAmount
+	// ExpectedBurn is the current base fee * the estimated gas used
+	ExpectedBurn abi.TokenAmount
+	// ExpectedFee is ExpectedBurn plus the miner tip GasPremium * GasLimit
+	ExpectedFee abi.TokenAmount
+}
+
+// RecentBaseFees reads the parent base fees of the recent tipsets
+func RecentBaseFees(ctx context.Context, node api.FullNode) (*BaseFees, error) {
+	ts, err := node.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var fees []abi.TokenAmount
+	for i := 0; i < feeLookback; i++ {
+		fees = append(fees, ts.Blocks()[0].ParentBaseFee)
+		if ts.Height() == 0 {
+			break
+		}
+
+		ts, err = node.ChainGetTipSet(ctx, ts.Parents())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	current := fees[0]
+	sort.Slice(fees, func(i, j int) bool {
+		return fees[i].LessThan(fees[j])
+	})
+
+	return &BaseFees{
+		Current: current,
+		Min:     fees[0],
+		Median:  fees[len(fees)/2],
+		Max:     fees[len(fees)-1],
+	}, nil
+}
+
+// EstimateFee estimates the gas of msg for preset, the gas limit of msg is kept when set
+func EstimateFee(ctx context.Context, node api.FullNode, msg *types.Message, preset FeePreset, baseFees *BaseFees) (*FeeEstimate, error) {
+	params, ok := feePresetParamsMap[preset]
+	if !ok {
+		return nil, fmt.Errorf("unknown fee preset %s", preset)
+	}
+
+	gasLimit, gasUsed := msg.GasLimit, msg.GasLimit
+	if gasLimit == 0 {
+		estimate := *msg
+		estimate.GasFeeCap = types.EmptyInt
+		estimate.GasPremium = types.EmptyInt
+
+		var err error
+		gasUsed, err = node.GasEstimateGasLimit(ctx, &estimate, types.EmptyTSK)
+		if err != nil {
+			return nil, fmt.Errorf("estimating gas limit: %w", err)
+		}
+
+		gasLimit = int64(float64(gasUsed) * gasLimitOverestimation)
+		if gasLimit > build.BlockGasLimit {
+			gasLimit = build.BlockGasLimit
+		}
+	}
+
+	premium, err := node.GasEstimateGasPremium(ctx, params.inclusion, msg.From, gasLimit, types.EmptyTSK)
+	if err != nil {
+		return nil, fmt.Errorf("estimating gas premium: %w", err)
+	}
+
+	// the fee cap covers the highest recent base fee growing at the maximal rate for capEpochs
+	increase := math.Pow(1.+1./float64(build.BaseFeeMaxChangeDenom), float64(params.capEpochs))
+	feeCap := big.Div(big.Mul(baseFees.Max, big.NewInt(int64(increase*(1<<8)))), big.NewInt(1<<8))
+	feeCap = big.Add(feeCap, premium)
+
+	limit := big.NewInt(gasLimit)
+	burn := big.Mul(baseFees.Current, big.NewInt(gasUsed))
+
+	return &FeeEstimate{
+		Preset:       preset,
+		GasLimit:     gasLimit,
+		GasFeeCap:    feeCap,
+		GasPremium:   premium,
+		WorstCaseFee: big.Mul(feeCap, limit),
+		ExpectedBurn: burn,
+		ExpectedFee:  big.Add(burn, big.Mul(premium, limit)),
+	}, nil
+}
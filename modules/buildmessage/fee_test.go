@@ -0,0 +1,54 @@
+package buildmessage
+
+import (
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type gasNode struct {
+	api.FullNode
+}
+
+func (g *gasNode) GasEstimateGasLimit(ctx context.Context, msg *types.Message, tsk types.TipSetKey) (int64, error) {
+	return 1000000, nil
+}
+
+func (g *gasNode) GasEstimateGasPremium(ctx context.Context, nblocksincl uint64, sender address.Address, gaslimit int64, tsk types.TipSetKey) (types.BigInt, error) {
+	return big.NewInt(int64(100 / nblocksincl)), nil
+}
+
+func TestEstimateFee(t *testing.T) {
+	from, _ := address.NewIDAddress(1000)
+	msg := &types.Message{From: from, To: from, Value: big.Zero()}
+	baseFees := &BaseFees{Current: big.NewInt(100), Min: big.NewInt(100), Median: big.NewInt(100), Max: big.NewInt(200)}
+
+	var last *FeeEstimate
+	for _, preset := range FeePresets {
+		fee, err := EstimateFee(context.Background(), &gasNode{}, msg, preset, baseFees)
+		require.NoError(t, err)
+		require.Equal(t, int64(1250000), fee.GasLimit)
+		require.Equal(t, big.NewInt(100*1000000), fee.ExpectedBurn)
+		require.Equal(t, big.Mul(fee.GasFeeCap, big.NewInt(fee.GasLimit)), fee.WorstCaseFee)
+		// the fee cap covers at least the highest recent base fee
+		require.True(t, fee.GasFeeCap.GreaterThan(baseFees.Max))
+
+		if last != nil {
+			require.True(t, fee.GasFeeCap.GreaterThan(last.GasFeeCap))
+			require.True(t, fee.GasPremium.GreaterThanEqual(last.GasPremium))
+		}
+		last = fee
+	}
+
+	msg.GasLimit = 500000
+	fee, err := EstimateFee(context.Background(), &gasNode{}, msg, FeeNormal, baseFees)
+	require.NoError(t, err)
+	require.Equal(t, int64(500000), fee.GasLimit)
+
+	_, err = ParseFeePreset("fastest")
+	require.Error(t, err)
+}
@@ -11,9 +11,14 @@ import (
 	miner13 "github.com/filecoin-project/go-state-types/builtin/v13/miner"
 	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/actors"
+	lotusbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
 	"github.com/filecoin-project/lotus/chain/types"
 	specsminer8 "github.com/filecoin-project/specs-actors/v8/actors/builtin/miner"
+	specspower8 "github.com/filecoin-project/specs-actors/v8/actors/builtin/power"
 	logging "github.com/ipfs/go-log/v2"
+	"github.com/libp2p/go-libp2p/core/peer"
+	ma "github.com/multiformats/go-multiaddr"
 	"golang.org/x/xerrors"
 	"strconv"
 	"strings"
@@ -27,10 +32,17 @@ type BaseParams struct {
 	GasPremium string `json:"gas_premium"`
 	GasLimit   int64  `json:"gas_limit"`
 	Nonce      uint64 `json:"nonce"`
+	// ExplicitNonce uses Nonce even when it is 0, otherwise a nonce of 0 asks the node for the next nonce
+	ExplicitNonce bool `json:"explicit_nonce"`
+	// FeePreset is slow, normal or fast, it replaces GasFeeCap, GasPremium and MaxFee, GasLimit is kept when set
+	FeePreset string `json:"fee_preset"`
+	// Simulate runs the built message through StateCall, a failed simulation fails the build unless Force is set
+	Simulate bool `json:"simulate"`
+	Force    bool `json:"force"`
 }
 
 func (b BaseParams) String() string {
-	return fmt.Sprintf("max_fee: %s, gas_feecap: %s, gas_premium: %s, gas_limit: %d, nonce: %d", b.MaxFee, b.GasFeeCap, b.GasPremium, b.GasLimit, b.Nonce)
+	return fmt.Sprintf("max_fee: %s, gas_feecap: %s, gas_premium: %s, gas_limit: %d, nonce: %d, explicit_nonce: %t, fee_preset: %s, simulate: %t, force: %t", b.MaxFee, b.GasFeeCap, b.GasPremium, b.GasLimit, b.Nonce, b.ExplicitNonce, b.FeePreset, b.Simulate, b.Force)
 }
 
 func LotusMessageToString(msg *types.Message) string {
@@ -68,7 +80,30 @@ func NewTransferMessage(node api.FullNode, baseParams BaseParams, from, to strin
 	return msg, nil
 }
 
-func NewWithdrawMessage(node api.FullNode, baseParams BaseParams, minerId string, amount string) (*types.Message, *specsminer8.WithdrawBalanceParams, error) {
+const (
+	WithdrawByOwner       = "owner"
+	WithdrawByBeneficiary = "beneficiary"
+)
+
+// BeneficiaryRemaining is what may still be withdrawn under the beneficiary term at epoch,
+// nil when the owner is the beneficiary and no quota applies
+func BeneficiaryRemaining(mi api.MinerInfo, epoch abi.ChainEpoch) *abi.TokenAmount {
+	if mi.Beneficiary == mi.Owner {
+		return nil
+	}
+
+	remaining := big.Zero()
+	if term := mi.BeneficiaryTerm; term != nil && term.Expiration > epoch && term.Quota.GreaterThan(term.UsedQuota) {
+		remaining = big.Sub(term.Quota, term.UsedQuota)
+	}
+
+	return &remaining
+}
+
+// NewWithdrawMessage withdraws amount, 0 is all that can be withdrawn, the funds always go to the beneficiary.
+// sender picks who calls WithdrawBalance, owner or beneficiary (the default). When the sender is a multisig
+// the message is a proposal of from, a signer of the multisig, and the params are its ProposeParams
+func NewWithdrawMessage(node api.FullNode, baseParams BaseParams, minerId string, amount string, sender string, from string) (*types.Message, interface{}, error) {
 	minerAddr, err := address.NewFromString(minerId)
 	if err != nil {
 		return nil, nil, err
@@ -81,18 +116,36 @@ func NewWithdrawMessage(node api.FullNode, baseParams BaseParams, minerId string
 
 	ctx := context.Background()
 
-	mi, err := node.StateMinerInfo(ctx, minerAddr, types.EmptyTSK)
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mi, err := node.StateMinerInfo(ctx, minerAddr, head.Key())
 	if err != nil {
 		return nil, nil, err
 	}
 
-	available, err := node.StateMinerAvailableBalance(ctx, minerAddr, types.EmptyTSK)
+	available, err := node.StateMinerAvailableBalance(ctx, minerAddr, head.Key())
 	if err != nil {
 		return nil, nil, err
 	}
 
+	// the actor caps the withdrawal by the remaining quota of the beneficiary term whoever sends it
+	limit := available
+	if remaining := BeneficiaryRemaining(mi, head.Height()); remaining != nil {
+		if remaining.IsZero() {
+			return nil, nil, xerrors.Errorf("minerId: %s beneficiary: %s term is used up or expired, quota: %s, used: %s, expiration: %d",
+				minerId, mi.Beneficiary, types.FIL(mi.BeneficiaryTerm.Quota), types.FIL(mi.BeneficiaryTerm.UsedQuota), mi.BeneficiaryTerm.Expiration)
+		}
+		limit = big.Min(limit, *remaining)
+	}
+
 	if value.Int64() == 0 {
-		value = types.FIL(available)
+		value = types.FIL(limit)
+	}
+	if big.Int(value).GreaterThan(limit) {
+		return nil, nil, xerrors.Errorf("minerId: %s can withdraw at most %s, available: %s", minerId, types.FIL(limit), types.FIL(available))
 	}
 
 	params := &specsminer8.WithdrawBalanceParams{
@@ -104,19 +157,52 @@ func NewWithdrawMessage(node api.FullNode, baseParams BaseParams, minerId string
 		return nil, nil, err
 	}
 
-	// todo Whether to use beneficiary withdrawal?
-	from, err := node.StateAccountKey(ctx, mi.Beneficiary, types.EmptyTSK)
+	var senderAddr address.Address
+	switch sender {
+	case WithdrawByOwner:
+		senderAddr = mi.Owner
+	case WithdrawByBeneficiary, "":
+		senderAddr = mi.Beneficiary
+	default:
+		return nil, nil, xerrors.Errorf("unknown sender: %s, must be %s or %s", sender, WithdrawByOwner, WithdrawByBeneficiary)
+	}
+
+	act, err := node.StateGetActor(ctx, senderAddr, head.Key())
 	if err != nil {
-		if strings.Contains(err.Error(), "multisig") {
-			err = xerrors.Errorf("minerId: %s owner: %s is multisig account, please use Msig Withdraw", minerId, mi.Owner.String())
+		return nil, nil, err
+	}
+
+	if lotusbuiltin.IsMultisigActor(act.Code) {
+		if from == "" {
+			return nil, nil, xerrors.Errorf("minerId: %s %s: %s is multisig account, a signer is required to propose the withdrawal", minerId, sender, senderAddr)
+		}
+
+		fromAddr, err := address.NewFromString(from)
+		if err != nil {
+			return nil, nil, err
 		}
 
+		msg, proposeParams, err := NewMsiger(node).MsigPropose(senderAddr, minerAddr, big.Zero(), fromAddr, uint64(builtin.MethodsMiner.WithdrawBalance), sp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("MsigPropose: %w", err)
+		}
+
+		msg, err = buildMessage(node, msg, baseParams)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return msg, proposeParams, nil
+	}
+
+	fromAddr, err := node.StateAccountKey(ctx, senderAddr, head.Key())
+	if err != nil {
 		return nil, nil, err
 	}
 
 	msg := &types.Message{
 		To:     minerAddr,
-		From:   from,
+		From:   fromAddr,
 		Value:  types.NewInt(0),
 		Method: builtin.MethodsMiner.WithdrawBalance,
 		Params: sp,
@@ -421,6 +507,17 @@ func NewChangeBeneficiaryProposeMessage(node api.FullNode, baseParams BaseParams
 	return msg, params, nil
 }
 
+// BeneficiaryApprover is who approves the pending beneficiary term next: the nominee, then the current
+// beneficiary unless it is the owner, the term must be pending
+func BeneficiaryApprover(mi api.MinerInfo) address.Address {
+	term := mi.PendingBeneficiaryTerm
+	if !term.ApprovedByNominee || mi.Beneficiary == mi.Owner || term.ApprovedByBeneficiary {
+		return term.NewBeneficiary
+	}
+
+	return mi.Beneficiary
+}
+
 func NewConfirmChangeBeneficiary(node api.FullNode, baseParams BaseParams, minerId string) (*types.Message, *miner13.ChangeBeneficiaryParams, error) {
 	ctx := context.Background()
 
@@ -438,12 +535,7 @@ func NewConfirmChangeBeneficiary(node api.FullNode, baseParams BaseParams, miner
 		return nil, nil, fmt.Errorf("no pending beneficiary term found for miner %s", minerAddr)
 	}
 
-	var fromAddr address.Address
-	if mi.Owner.String() == mi.Beneficiary.String() || mi.PendingBeneficiaryTerm != nil {
-		fromAddr = mi.PendingBeneficiaryTerm.NewBeneficiary
-	} else {
-		fromAddr = mi.Beneficiary
-	}
+	fromAddr := BeneficiaryApprover(mi)
 
 	params := &miner13.ChangeBeneficiaryParams{
 		NewBeneficiary: mi.PendingBeneficiaryTerm.NewBeneficiary,
@@ -477,6 +569,153 @@ func NewConfirmChangeBeneficiary(node api.FullNode, baseParams BaseParams, miner
 	return msg, params, nil
 }
 
+// sectorSizes are the sector sizes a miner can be created with
+var sectorSizes = []abi.SectorSize{2 << 10, 8 << 20, 512 << 20, 32 << 30, 64 << 30}
+
+// ParseSectorSize parses a sector size like 32GiB or its size in bytes
+func ParseSectorSize(s string) (abi.SectorSize, error) {
+	for _, size := range sectorSizes {
+		if strings.EqualFold(s, size.ShortString()) || s == strconv.FormatUint(uint64(size), 10) {
+			return size, nil
+		}
+	}
+
+	return 0, xerrors.Errorf("unsupported sector size: %s, must be one of 2KiB, 8MiB, 512MiB, 32GiB and 64GiB", s)
+}
+
+// NewCreateMinerMessage creates a miner actor through the power actor. The worker must be a BLS account on chain,
+// from is the sender and defaults to the owner. When the owner is a multisig the message is a proposal of from,
+// a signer of the multisig, and the params are its ProposeParams
+func NewCreateMinerMessage(node api.FullNode, baseParams BaseParams, owner, worker, sectorSize, peerId string, multiaddrs []string, from string) (*types.Message, interface{}, error) {
+	ownerAddr, err := address.NewFromString(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	workerAddr, err := address.NewFromString(worker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ssize, err := ParseSectorSize(sectorSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := context.Background()
+
+	ownerId, err := node.StateLookupID(ctx, ownerAddr, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("owner %s has no actor on chain: %w", ownerAddr, err)
+	}
+
+	workerId, err := node.StateLookupID(ctx, workerAddr, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("worker %s has no actor on chain, send it some FIL first: %w", workerAddr, err)
+	}
+
+	workerKey, err := node.StateAccountKey(ctx, workerId, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, err
+	}
+	if workerKey.Protocol() != address.BLS {
+		return nil, nil, xerrors.Errorf("worker %s must be a BLS address", workerKey)
+	}
+
+	nv, err := node.StateNetworkVersion(ctx, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	postProof, err := miner.WindowPoStProofTypeFromSectorSize(ssize, nv)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params := &specspower8.CreateMinerParams{
+		Owner:               ownerId,
+		Worker:              workerId,
+		WindowPoStProofType: postProof,
+	}
+
+	if peerId != "" {
+		pid, err := peer.Decode(peerId)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("parsing peer id %s: %w", peerId, err)
+		}
+		params.Peer = abi.PeerID(pid)
+	}
+
+	for _, m := range multiaddrs {
+		maddr, err := ma.NewMultiaddr(m)
+		if err != nil {
+			return nil, nil, xerrors.Errorf("parsing multiaddr %s: %w", m, err)
+		}
+		params.Multiaddrs = append(params.Multiaddrs, maddr.Bytes())
+	}
+
+	sp, err := actors.SerializeParams(params)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("serializing params: %w", err)
+	}
+
+	act, err := node.StateGetActor(ctx, ownerId, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if lotusbuiltin.IsMultisigActor(act.Code) {
+		if from == "" {
+			return nil, nil, xerrors.Errorf("owner %s is multisig account, a signer is required to propose the creation", ownerAddr)
+		}
+
+		fromAddr, err := address.NewFromString(from)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		msg, proposeParams, err := NewMsiger(node).MsigPropose(ownerId, builtin.StoragePowerActorAddr, big.Zero(), fromAddr, uint64(builtin.MethodsPower.CreateMiner), sp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("MsigPropose: %w", err)
+		}
+
+		msg, err = buildMessage(node, msg, baseParams)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return msg, proposeParams, nil
+	}
+
+	sender := ownerId
+	if from != "" {
+		sender, err = address.NewFromString(from)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fromAddr, err := node.StateAccountKey(ctx, sender, types.EmptyTSK)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg := &types.Message{
+		To:     builtin.StoragePowerActorAddr,
+		From:   fromAddr,
+		Value:  big.Zero(),
+		Method: builtin.MethodsPower.CreateMiner,
+		Params: sp,
+	}
+
+	msg, err = buildMessage(node, msg, baseParams)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return msg, params, nil
+}
+
 func buildMessage(node api.FullNode, msg *types.Message, baseParams BaseParams) (*types.Message, error) {
 	log.Debugw("buildMessage: start", "baseParams", baseParams.String())
 
@@ -486,7 +725,26 @@ func buildMessage(node api.FullNode, msg *types.Message, baseParams BaseParams)
 	msg.Nonce = baseParams.Nonce
 
 	ctx := context.Background()
-	if msg.GasLimit == 0 || msg.GasPremium == types.EmptyInt || types.BigCmp(msg.GasPremium, types.NewInt(0)) == 0 ||
+	if baseParams.FeePreset != "" {
+		preset, err := ParseFeePreset(baseParams.FeePreset)
+		if err != nil {
+			return nil, err
+		}
+
+		baseFees, err := RecentBaseFees(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+
+		fee, err := EstimateFee(ctx, node, msg, preset, baseFees)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.GasLimit = fee.GasLimit
+		msg.GasFeeCap = fee.GasFeeCap
+		msg.GasPremium = fee.GasPremium
+	} else if msg.GasLimit == 0 || msg.GasPremium == types.EmptyInt || types.BigCmp(msg.GasPremium, types.NewInt(0)) == 0 ||
 		msg.GasFeeCap == types.EmptyInt || types.BigCmp(msg.GasFeeCap, types.NewInt(0)) == 0 {
 
 		maxFee, err := types.ParseFIL(baseParams.MaxFee)
@@ -501,7 +759,7 @@ func buildMessage(node api.FullNode, msg *types.Message, baseParams BaseParams)
 		}
 	}
 
-	if msg.Nonce == 0 {
+	if msg.Nonce == 0 && !baseParams.ExplicitNonce {
 		mpoolNonce, err := node.MpoolGetNonce(ctx, msg.From)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,67 @@
+package buildmessage
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestBeneficiaryRemaining(t *testing.T) {
+	owner, _ := address.NewIDAddress(1000)
+	beneficiary, _ := address.NewIDAddress(1001)
+
+	// the owner is its own beneficiary, no quota applies
+	require.Nil(t, BeneficiaryRemaining(api.MinerInfo{Owner: owner, Beneficiary: owner}, 10))
+
+	mi := api.MinerInfo{
+		Owner:       owner,
+		Beneficiary: beneficiary,
+		BeneficiaryTerm: &miner.BeneficiaryTerm{
+			Quota:      abi.NewTokenAmount(100),
+			UsedQuota:  abi.NewTokenAmount(30),
+			Expiration: 20,
+		},
+	}
+	require.Equal(t, abi.NewTokenAmount(70), *BeneficiaryRemaining(mi, 10))
+	require.True(t, BeneficiaryRemaining(mi, 20).IsZero())
+
+	mi.BeneficiaryTerm.UsedQuota = abi.NewTokenAmount(100)
+	require.True(t, BeneficiaryRemaining(mi, 10).IsZero())
+}
+
+func TestBeneficiaryApprover(t *testing.T) {
+	owner, _ := address.NewIDAddress(1000)
+	beneficiary, _ := address.NewIDAddress(1001)
+	nominee, _ := address.NewIDAddress(1002)
+
+	mi := api.MinerInfo{
+		Owner:                  owner,
+		Beneficiary:            beneficiary,
+		PendingBeneficiaryTerm: &miner.PendingBeneficiaryChange{NewBeneficiary: nominee},
+	}
+	require.Equal(t, nominee, BeneficiaryApprover(mi))
+
+	// the current beneficiary approves after the nominee
+	mi.PendingBeneficiaryTerm.ApprovedByNominee = true
+	require.Equal(t, beneficiary, BeneficiaryApprover(mi))
+
+	// the owner as beneficiary approved by proposing
+	mi.Beneficiary = owner
+	require.Equal(t, nominee, BeneficiaryApprover(mi))
+}
+
+func TestParseSectorSize(t *testing.T) {
+	ssize, err := ParseSectorSize("32GiB")
+	require.NoError(t, err)
+	require.Equal(t, abi.SectorSize(32<<30), ssize)
+
+	ssize, err = ParseSectorSize("68719476736")
+	require.NoError(t, err)
+	require.Equal(t, abi.SectorSize(64<<30), ssize)
+
+	_, err = ParseSectorSize("16GiB")
+	require.Error(t, err)
+}
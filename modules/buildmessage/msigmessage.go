@@ -1,7 +1,9 @@
 package buildmessage
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
@@ -20,6 +22,7 @@ import (
 	"github.com/minio/blake2b-simd"
 	"golang.org/x/xerrors"
 	"strconv"
+	"strings"
 )
 
 type Msiger struct {
@@ -71,7 +74,25 @@ func (m *Msiger) NewMsigCreateMessage(baseParams BaseParams, required, duration
 	return msg, params, nil
 }
 
-func (m *Msiger) NewMsigApproveMessage(baseParams BaseParams, msigAddress, txId string, from string) (*types.Message, *multisig13.TxnIDParams, error) {
+// TxnExpectation is what the signer expects the pending transaction to be, an empty field is not checked
+type TxnExpectation struct {
+	To     string `json:"to"`
+	Value  string `json:"value"`
+	Method string `json:"method"`
+	Params string `json:"params"`
+}
+
+func (m *Msiger) NewMsigApproveMessage(baseParams BaseParams, msigAddress, txId string, from string, expect TxnExpectation) (*types.Message, *multisig13.TxnIDParams, error) {
+	return m.newMsigPendingMessage(api.MsigApprove, baseParams, msigAddress, txId, from, expect)
+}
+
+func (m *Msiger) NewMsigCancelMessage(baseParams BaseParams, msigAddress, txId string, from string, expect TxnExpectation) (*types.Message, *multisig13.TxnIDParams, error) {
+	return m.newMsigPendingMessage(api.MsigCancel, baseParams, msigAddress, txId, from, expect)
+}
+
+// newMsigPendingMessage approves or cancels the pending transaction as it is on chain,
+// the message carries its proposal hash so it fails if the transaction is not the one that was checked
+func (m *Msiger) newMsigPendingMessage(operation api.MsigProposeResponse, baseParams BaseParams, msigAddress, txId string, from string, expect TxnExpectation) (*types.Message, *multisig13.TxnIDParams, error) {
 	msig, err := address.NewFromString(msigAddress)
 	if err != nil {
 		return nil, nil, err
@@ -82,73 +103,153 @@ func (m *Msiger) NewMsigApproveMessage(baseParams BaseParams, msigAddress, txId
 		return nil, nil, err
 	}
 
-	ctx := context.Background()
-
-	act, err := m.node.StateGetActor(ctx, msig, types.EmptyTSK)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to look up multisig %s: %w", msig, err)
-	}
-
-	if !lotusbuiltin.IsMultisigActor(act.Code) {
-		return nil, nil, fmt.Errorf("actor %s is not a multisig actor", msig)
-	}
-
 	sendAddr, err := address.NewFromString(from)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	msg, approveParams, err := m.MsigApprove(msig, txid, sendAddr)
+	txn, err := m.PendingTxn(msig, txid)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	msg, err = buildMessage(m.node, msg, baseParams)
-	if err != nil {
+	if err := m.checkPendingTxn(txn, expect); err != nil {
 		return nil, nil, err
 	}
 
-	return msg, approveParams, nil
-}
+	if len(txn.Approved) == 0 {
+		return nil, nil, fmt.Errorf("transaction %d of %s has no proposer", txid, msig)
+	}
 
-func (m *Msiger) NewMsigCancelMessage(baseParams BaseParams, msigAddress, txId string, from string) (*types.Message, *multisig13.TxnIDParams, error) {
-	msig, err := address.NewFromString(msigAddress)
-	if err != nil {
-		return nil, nil, err
+	var msg *types.Message
+	var txnIDParams *multisig13.TxnIDParams
+	switch operation {
+	case api.MsigApprove:
+		msg, txnIDParams, err = m.MsigApproveTxnHash(msig, txid, txn.Approved[0], txn.To, txn.Value, sendAddr, uint64(txn.Method), txn.Params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("MsigApproveTxnHash: %w", err)
+		}
+	case api.MsigCancel:
+		msg, txnIDParams, err = m.MsigCancelTxnHash(msig, txid, txn.To, txn.Value, sendAddr, uint64(txn.Method), txn.Params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("MsigCancelTxnHash: %w", err)
+		}
+	default:
+		return nil, nil, xerrors.Errorf("Invalid operation for msigApproveOrCancel")
 	}
 
-	txid, err := strconv.ParseUint(txId, 10, 64)
+	msg, err = buildMessage(m.node, msg, baseParams)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	return msg, txnIDParams, nil
+}
+
+// PendingTxn returns the pending transaction txID of msig as it is on chain
+func (m *Msiger) PendingTxn(msig address.Address, txID uint64) (*api.MsigTransaction, error) {
 	ctx := context.Background()
 
 	act, err := m.node.StateGetActor(ctx, msig, types.EmptyTSK)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to look up multisig %s: %w", msig, err)
+		return nil, fmt.Errorf("failed to look up multisig %s: %w", msig, err)
 	}
 
 	if !lotusbuiltin.IsMultisigActor(act.Code) {
-		return nil, nil, fmt.Errorf("actor %s is not a multisig actor", msig)
+		return nil, fmt.Errorf("actor %s is not a multisig actor", msig)
 	}
 
-	sendAddr, err := address.NewFromString(from)
+	pending, err := m.node.MsigGetPending(ctx, msig, types.EmptyTSK)
 	if err != nil {
-		return nil, nil, err
+		return nil, fmt.Errorf("failed to get pending transactions of %s: %w", msig, err)
 	}
 
-	msg, cancelParams, err := m.MsigCancel(msig, txid, sendAddr)
-	if err != nil {
-		return nil, nil, err
+	for _, txn := range pending {
+		if txn.ID == int64(txID) {
+			return txn, nil
+		}
 	}
 
-	msg, err = buildMessage(m.node, msg, baseParams)
+	return nil, fmt.Errorf("transaction %d is not pending in %s", txID, msig)
+}
+
+// checkPendingTxn resolves the expected and pending destination to ID addresses before comparing the transaction
+func (m *Msiger) checkPendingTxn(txn *api.MsigTransaction, expect TxnExpectation) error {
+	if expect.To == "" {
+		return CheckTxn(txn, expect)
+	}
+
+	expectTo, err := address.NewFromString(expect.To)
 	if err != nil {
-		return nil, nil, err
+		return fmt.Errorf("parse expected to: %w", err)
 	}
 
-	return msg, cancelParams, nil
+	resolved := *txn
+	if expectTo != txn.To {
+		expectTo, err = m.node.StateLookupID(context.Background(), expectTo, types.EmptyTSK)
+		if err != nil {
+			return fmt.Errorf("look up expected to %s: %w", expect.To, err)
+		}
+		resolved.To, err = m.node.StateLookupID(context.Background(), txn.To, types.EmptyTSK)
+		if err != nil {
+			return fmt.Errorf("look up %s: %w", txn.To, err)
+		}
+	}
+
+	expect.To = expectTo.String()
+	return CheckTxn(&resolved, expect)
+}
+
+// CheckTxn returns an error naming every field of the pending transaction that differs from the expectation,
+// value is in FIL, method is the method number and params are hex encoded
+func CheckTxn(txn *api.MsigTransaction, expect TxnExpectation) error {
+	var mismatches []string
+
+	if expect.To != "" {
+		to, err := address.NewFromString(expect.To)
+		if err != nil {
+			return fmt.Errorf("parse expected to: %w", err)
+		}
+		if to != txn.To {
+			mismatches = append(mismatches, fmt.Sprintf("to is %s, expected %s", txn.To, to))
+		}
+	}
+
+	if expect.Value != "" {
+		value, err := types.ParseFIL(expect.Value)
+		if err != nil {
+			return fmt.Errorf("parse expected value: %w", err)
+		}
+		if !txn.Value.Equals(types.BigInt(value)) {
+			mismatches = append(mismatches, fmt.Sprintf("value is %s, expected %s", types.FIL(txn.Value), value))
+		}
+	}
+
+	if expect.Method != "" {
+		method, err := strconv.ParseUint(expect.Method, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parse expected method: %w", err)
+		}
+		if abi.MethodNum(method) != txn.Method {
+			mismatches = append(mismatches, fmt.Sprintf("method is %d, expected %d", txn.Method, method))
+		}
+	}
+
+	if expect.Params != "" {
+		params, err := hex.DecodeString(strings.TrimPrefix(expect.Params, "0x"))
+		if err != nil {
+			return fmt.Errorf("parse expected params: %w", err)
+		}
+		if !bytes.Equal(params, txn.Params) {
+			mismatches = append(mismatches, fmt.Sprintf("params are %x, expected %x", txn.Params, params))
+		}
+	}
+
+	if len(mismatches) != 0 {
+		return fmt.Errorf("pending transaction %d does not match: %s", txn.ID, strings.Join(mismatches, "; "))
+	}
+
+	return nil
 }
 
 func (m *Msiger) NewMsigTransferProposeMessage(baseParams BaseParams, msigAddress, destinationAddress, amount string, from string) (*types.Message, *multisig13.ProposeParams, error) {
@@ -185,25 +286,72 @@ func (m *Msiger) NewMsigTransferProposeMessage(baseParams BaseParams, msigAddres
 	return msg, proposeParams, nil
 }
 
-func (m *Msiger) NewMsigTransferApproveMessage(baseParams BaseParams, msigAddress, txId string, from string) (*types.Message, *multisig13.TxnIDParams, error) {
+func (m *Msiger) NewMsigTransferApproveMessage(baseParams BaseParams, msigAddress, txId string, from string, expect TxnExpectation) (*types.Message, *multisig13.TxnIDParams, error) {
+	return m.newMsigPendingMessage(api.MsigApprove, baseParams, msigAddress, txId, from, expect)
+}
+
+func (m *Msiger) NewMsigTransferCancelMessage(baseParams BaseParams, msigAddress, txId string, from string, expect TxnExpectation) (*types.Message, *multisig13.TxnIDParams, error) {
+	return m.newMsigPendingMessage(api.MsigCancel, baseParams, msigAddress, txId, from, expect)
+}
+
+func (m *Msiger) NewMsigAddSignerProposeMessage(baseParams BaseParams, msigAddress, signerAddress string, increaseThreshold bool, from string) (*types.Message, *multisig13.ProposeParams, error) {
+	msig, err := address.NewFromString(msigAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := address.NewFromString(signerAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sendAddr, err := address.NewFromString(from)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, proposeParams, err := m.MsigAddPropose(msig, sendAddr, signer, increaseThreshold)
+	if err != nil {
+		return nil, nil, fmt.Errorf("MsigAddPropose: %w", err)
+	}
+
+	msg, err = buildMessage(m.node, msg, baseParams)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return msg, proposeParams, nil
+}
+
+func (m *Msiger) NewMsigAddSignerApproveMessage(baseParams BaseParams, msigAddress, proposerAddress, txId, signerAddress string, increaseThreshold bool, from string) (*types.Message, *multisig13.TxnIDParams, error) {
 	msig, err := address.NewFromString(msigAddress)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	prop, err := address.NewFromString(proposerAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	txid, err := strconv.ParseUint(txId, 10, 64)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	signer, err := address.NewFromString(signerAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	sendAddr, err := address.NewFromString(from)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	msg, approveParams, err := m.MsigApprove(msig, txid, sendAddr)
+	msg, approveParams, err := m.MsigAddApprove(msig, sendAddr, txid, prop, signer, increaseThreshold)
 	if err != nil {
-		return nil, nil, fmt.Errorf("MsigApprove: %w", err)
+		return nil, nil, fmt.Errorf("MsigAddApprove: %w", err)
 	}
 
 	msg, err = buildMessage(m.node, msg, baseParams)
@@ -214,7 +362,7 @@ func (m *Msiger) NewMsigTransferApproveMessage(baseParams BaseParams, msigAddres
 	return msg, approveParams, nil
 }
 
-func (m *Msiger) NewMsigTransferCancelMessage(baseParams BaseParams, msigAddress, txId string, from string) (*types.Message, *multisig13.TxnIDParams, error) {
+func (m *Msiger) NewMsigAddSignerCancelMessage(baseParams BaseParams, msigAddress, txId, signerAddress string, increaseThreshold bool, from string) (*types.Message, *multisig13.TxnIDParams, error) {
 	msig, err := address.NewFromString(msigAddress)
 	if err != nil {
 		return nil, nil, err
@@ -225,14 +373,19 @@ func (m *Msiger) NewMsigTransferCancelMessage(baseParams BaseParams, msigAddress
 		return nil, nil, err
 	}
 
+	signer, err := address.NewFromString(signerAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	sendAddr, err := address.NewFromString(from)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	msg, cancelParams, err := m.MsigCancel(msig, txid, sendAddr)
+	msg, cancelParams, err := m.MsigAddCancel(msig, sendAddr, txid, signer, increaseThreshold)
 	if err != nil {
-		return nil, nil, fmt.Errorf("MsigCancel: %w", err)
+		return nil, nil, fmt.Errorf("MsigAddCancel: %w", err)
 	}
 
 	msg, err = buildMessage(m.node, msg, baseParams)
@@ -243,7 +396,7 @@ func (m *Msiger) NewMsigTransferCancelMessage(baseParams BaseParams, msigAddress
 	return msg, cancelParams, nil
 }
 
-func (m *Msiger) NewMsigAddSignerProposeMessage(baseParams BaseParams, msigAddress, signerAddress string, increaseThreshold bool, from string) (*types.Message, *multisig13.ProposeParams, error) {
+func (m *Msiger) NewMsigRemoveSignerProposeMessage(baseParams BaseParams, msigAddress, signerAddress string, decreaseThreshold bool, from string) (*types.Message, *multisig13.ProposeParams, error) {
 	msig, err := address.NewFromString(msigAddress)
 	if err != nil {
 		return nil, nil, err
@@ -259,9 +412,9 @@ func (m *Msiger) NewMsigAddSignerProposeMessage(baseParams BaseParams, msigAddre
 		return nil, nil, err
 	}
 
-	msg, proposeParams, err := m.MsigAddPropose(msig, sendAddr, signer, increaseThreshold)
+	msg, proposeParams, err := m.MsigRemoveSigner(msig, sendAddr, signer, decreaseThreshold)
 	if err != nil {
-		return nil, nil, fmt.Errorf("MsigAddPropose: %w", err)
+		return nil, nil, fmt.Errorf("MsigRemoveSigner: %w", err)
 	}
 
 	msg, err = buildMessage(m.node, msg, baseParams)
@@ -272,7 +425,7 @@ func (m *Msiger) NewMsigAddSignerProposeMessage(baseParams BaseParams, msigAddre
 	return msg, proposeParams, nil
 }
 
-func (m *Msiger) NewMsigAddSignerApproveMessage(baseParams BaseParams, msigAddress, proposerAddress, txId, signerAddress string, increaseThreshold bool, from string) (*types.Message, *multisig13.TxnIDParams, error) {
+func (m *Msiger) NewMsigRemoveSignerApproveMessage(baseParams BaseParams, msigAddress, proposerAddress, txId, signerAddress string, decreaseThreshold bool, from string) (*types.Message, *multisig13.TxnIDParams, error) {
 	msig, err := address.NewFromString(msigAddress)
 	if err != nil {
 		return nil, nil, err
@@ -298,9 +451,9 @@ func (m *Msiger) NewMsigAddSignerApproveMessage(baseParams BaseParams, msigAddre
 		return nil, nil, err
 	}
 
-	msg, approveParams, err := m.MsigAddApprove(msig, sendAddr, txid, prop, signer, increaseThreshold)
+	msg, approveParams, err := m.MsigRemoveApprove(msig, sendAddr, txid, prop, signer, decreaseThreshold)
 	if err != nil {
-		return nil, nil, fmt.Errorf("MsigAddApprove: %w", err)
+		return nil, nil, fmt.Errorf("MsigRemoveApprove: %w", err)
 	}
 
 	msg, err = buildMessage(m.node, msg, baseParams)
@@ -311,7 +464,7 @@ func (m *Msiger) NewMsigAddSignerApproveMessage(baseParams BaseParams, msigAddre
 	return msg, approveParams, nil
 }
 
-func (m *Msiger) NewMsigAddSignerCancelMessage(baseParams BaseParams, msigAddress, txId, signerAddress string, increaseThreshold bool, from string) (*types.Message, *multisig13.TxnIDParams, error) {
+func (m *Msiger) NewMsigRemoveSignerCancelMessage(baseParams BaseParams, msigAddress, txId, signerAddress string, decreaseThreshold bool, from string) (*types.Message, *multisig13.TxnIDParams, error) {
 	msig, err := address.NewFromString(msigAddress)
 	if err != nil {
 		return nil, nil, err
@@ -332,9 +485,9 @@ func (m *Msiger) NewMsigAddSignerCancelMessage(baseParams BaseParams, msigAddres
 		return nil, nil, err
 	}
 
-	msg, cancelParams, err := m.MsigAddCancel(msig, sendAddr, txid, signer, increaseThreshold)
+	msg, cancelParams, err := m.MsigRemoveCancel(msig, sendAddr, txid, signer, decreaseThreshold)
 	if err != nil {
-		return nil, nil, fmt.Errorf("MsigAddCancel: %w", err)
+		return nil, nil, fmt.Errorf("MsigRemoveCancel: %w", err)
 	}
 
 	msg, err = buildMessage(m.node, msg, baseParams)
@@ -1632,6 +1785,16 @@ func (m *Msiger) messageBuilder(from address.Address) (multisig.MessageBuilder,
 	return multisig.Message(av, from), nil
 }
 
+// checkQuorum compares the signers of msig on all nodes when the message is built in quorum mode
+func (m *Msiger) checkQuorum(msig address.Address) error {
+	q, ok := m.node.(*QuorumNode)
+	if !ok {
+		return nil
+	}
+
+	return q.CheckMsigSigners(context.Background(), msig)
+}
+
 func (m *Msiger) MsigCreate(req uint64, addrs []address.Address, duration abi.ChainEpoch, val types.BigInt, src address.Address) (*types.Message, *multisig13.ConstructorParams, error) {
 	mb, err := m.messageBuilder(src)
 	if err != nil {
@@ -1666,6 +1829,10 @@ func (m *Msiger) MsigCreate(req uint64, addrs []address.Address, duration abi.Ch
 }
 
 func (m *Msiger) MsigPropose(msig address.Address, to address.Address, amt types.BigInt, src address.Address, method uint64, params []byte) (*types.Message, *multisig13.ProposeParams, error) {
+	if err := m.checkQuorum(msig); err != nil {
+		return nil, nil, err
+	}
+
 	mb, err := m.messageBuilder(src)
 	if err != nil {
 		return nil, nil, err
@@ -1766,6 +1933,24 @@ func (m *Msiger) MsigRemoveSigner(msig address.Address, proposer address.Address
 	return m.MsigPropose(msig, msig, types.NewInt(0), proposer, uint64(multisig.Methods.RemoveSigner), enc)
 }
 
+func (m *Msiger) MsigRemoveApprove(msig address.Address, src address.Address, txID uint64, proposer address.Address, toRemove address.Address, decrease bool) (*types.Message, *multisig13.TxnIDParams, error) {
+	enc, actErr := serializeRemoveParams(toRemove, decrease)
+	if actErr != nil {
+		return nil, nil, actErr
+	}
+
+	return m.MsigApproveTxnHash(msig, txID, proposer, msig, big.Zero(), src, uint64(multisig.Methods.RemoveSigner), enc)
+}
+
+func (m *Msiger) MsigRemoveCancel(msig address.Address, src address.Address, txID uint64, toRemove address.Address, decrease bool) (*types.Message, *multisig13.TxnIDParams, error) {
+	enc, actErr := serializeRemoveParams(toRemove, decrease)
+	if actErr != nil {
+		return nil, nil, actErr
+	}
+
+	return m.MsigCancelTxnHash(msig, txID, msig, big.Zero(), src, uint64(multisig.Methods.RemoveSigner), enc)
+}
+
 func (m *Msiger) MsigApproveOrCancelSimple(operation api.MsigProposeResponse, msig address.Address, txID uint64, src address.Address) (*types.Message, *multisig13.TxnIDParams, error) {
 	if msig == address.Undef {
 		return nil, nil, xerrors.Errorf("must provide multisig address")
@@ -1775,6 +1960,10 @@ func (m *Msiger) MsigApproveOrCancelSimple(operation api.MsigProposeResponse, ms
 		return nil, nil, xerrors.Errorf("must provide source address")
 	}
 
+	if err := m.checkQuorum(msig); err != nil {
+		return nil, nil, err
+	}
+
 	mb, err := m.messageBuilder(src)
 	if err != nil {
 		return nil, nil, err
@@ -1810,6 +1999,10 @@ func (m *Msiger) MsigApproveOrCancelTxnHash(operation api.MsigProposeResponse, m
 		return nil, nil, xerrors.Errorf("must provide source address")
 	}
 
+	if err := m.checkQuorum(msig); err != nil {
+		return nil, nil, err
+	}
+
 	if proposer.Protocol() != address.ID {
 		proposerID, err := m.node.StateLookupID(context.Background(), proposer, types.EmptyTSK)
 		if err != nil {
@@ -0,0 +1,59 @@
+package buildmessage
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCheckTxn(t *testing.T) {
+	to, _ := address.NewIDAddress(1001)
+	proposer, _ := address.NewIDAddress(1002)
+	txn := &api.MsigTransaction{
+		ID:       3,
+		To:       to,
+		Value:    types.NewInt(1500000000000000000),
+		Method:   builtin.MethodsMiner.WithdrawBalance,
+		Params:   []byte{0x81, 0x40},
+		Approved: []address.Address{proposer},
+	}
+
+	// nothing expected, nothing checked
+	require.NoError(t, CheckTxn(txn, TxnExpectation{}))
+
+	require.NoError(t, CheckTxn(txn, TxnExpectation{
+		To:     "f01001",
+		Value:  "1.5",
+		Method: "16",
+		Params: "0x8140",
+	}))
+
+	require.NoError(t, CheckTxn(txn, TxnExpectation{Value: "1.5 FIL", Params: "8140"}))
+
+	err := CheckTxn(txn, TxnExpectation{To: "f01003", Value: "1"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "to is f01001, expected f01003")
+	require.Contains(t, err.Error(), "value is 1.5 FIL, expected 1 FIL")
+
+	err = CheckTxn(txn, TxnExpectation{Method: "0", Params: "80"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "method is 16, expected 0")
+	require.Contains(t, err.Error(), "params are 8140, expected 80")
+
+	require.Error(t, CheckTxn(txn, TxnExpectation{Method: "withdraw"}))
+	require.Error(t, CheckTxn(txn, TxnExpectation{To: "not an address"}))
+
+	// the cli binds the displayed transaction with an attoFIL value and 0x for empty params
+	require.NoError(t, CheckTxn(txn, TxnExpectation{Value: "1500000000000000000 attoFIL"}))
+	require.Error(t, CheckTxn(txn, TxnExpectation{Params: "0x"}))
+
+	txn.Method = abi.MethodNum(0)
+	require.NoError(t, CheckTxn(txn, TxnExpectation{Method: "0"}))
+
+	txn.Params = nil
+	require.NoError(t, CheckTxn(txn, TxnExpectation{Params: "0x"}))
+}
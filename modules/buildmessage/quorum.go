@@ -0,0 +1,233 @@
+package buildmessage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/blockstore"
+	"github.com/filecoin-project/lotus/chain/actors/adt"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
+	"github.com/filecoin-project/lotus/chain/types"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// quorumLookback is how many epochs behind the head the state is compared,
+// so that peers a couple of epochs behind the primary node still have the tipset
+const quorumLookback = 2
+
+var ErrQuorumDisagree = errors.New("nodes disagree")
+
+type QuorumPeer struct {
+	Name string
+	Node api.FullNode
+}
+
+// QuorumNode reads the state a message is built from on several nodes at the same tipset
+// and refuses to return it when the nodes disagree, all other calls go to the primary node
+type QuorumNode struct {
+	api.FullNode
+
+	name  string
+	peers []QuorumPeer
+	tsk   types.TipSetKey
+}
+
+func NewQuorumNode(ctx context.Context, primary QuorumPeer, peers ...QuorumPeer) (*QuorumNode, error) {
+	head, err := primary.Node.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ts := head
+	if head.Height() > quorumLookback {
+		ts, err = primary.Node.ChainGetTipSetByHeight(ctx, head.Height()-quorumLookback, head.Key())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &QuorumNode{
+		FullNode: primary.Node,
+		name:     primary.Name,
+		peers:    peers,
+		tsk:      ts.Key(),
+	}, nil
+}
+
+// TipSetKey is the tipset all nodes are compared at
+func (q *QuorumNode) TipSetKey() types.TipSetKey {
+	return q.tsk
+}
+
+func (q *QuorumNode) StateMinerInfo(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (api.MinerInfo, error) {
+	res, err := q.query(ctx, fmt.Sprintf("miner info of %s", maddr), func(n api.FullNode) (interface{}, error) {
+		return n.StateMinerInfo(ctx, maddr, q.at(tsk))
+	})
+	if err != nil {
+		return api.MinerInfo{}, err
+	}
+
+	return res.(api.MinerInfo), nil
+}
+
+func (q *QuorumNode) StateAccountKey(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
+	res, err := q.query(ctx, fmt.Sprintf("account key of %s", addr), func(n api.FullNode) (interface{}, error) {
+		return n.StateAccountKey(ctx, addr, q.at(tsk))
+	})
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return res.(address.Address), nil
+}
+
+func (q *QuorumNode) StateLookupID(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
+	res, err := q.query(ctx, fmt.Sprintf("id of %s", addr), func(n api.FullNode) (interface{}, error) {
+		return n.StateLookupID(ctx, addr, q.at(tsk))
+	})
+	if err != nil {
+		return address.Undef, err
+	}
+
+	return res.(address.Address), nil
+}
+
+func (q *QuorumNode) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	res, err := q.query(ctx, fmt.Sprintf("actor %s", actor), func(n api.FullNode) (interface{}, error) {
+		return n.StateGetActor(ctx, actor, q.at(tsk))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(*types.Actor), nil
+}
+
+// MsigSigners is the signers and the threshold of a msig
+type MsigSigners struct {
+	Signers   []address.Address
+	Threshold uint64
+}
+
+// CheckMsigSigners compares the signers and the threshold of msig on all nodes
+func (q *QuorumNode) CheckMsigSigners(ctx context.Context, msig address.Address) error {
+	_, err := q.query(ctx, fmt.Sprintf("signers of %s", msig), func(n api.FullNode) (interface{}, error) {
+		return msigSigners(ctx, n, msig, q.tsk)
+	})
+
+	return err
+}
+
+func msigSigners(ctx context.Context, node api.FullNode, msig address.Address, tsk types.TipSetKey) (MsigSigners, error) {
+	act, err := node.StateGetActor(ctx, msig, tsk)
+	if err != nil {
+		return MsigSigners{}, err
+	}
+
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(node)))
+	mstate, err := multisig.Load(store, act)
+	if err != nil {
+		return MsigSigners{}, err
+	}
+
+	signers, err := mstate.Signers()
+	if err != nil {
+		return MsigSigners{}, err
+	}
+
+	threshold, err := mstate.Threshold()
+	if err != nil {
+		return MsigSigners{}, err
+	}
+
+	return MsigSigners{Signers: signers, Threshold: threshold}, nil
+}
+
+func (q *QuorumNode) at(tsk types.TipSetKey) types.TipSetKey {
+	if tsk == types.EmptyTSK {
+		return q.tsk
+	}
+	return tsk
+}
+
+// query calls all nodes in parallel and returns the result of the primary node when every peer returns the same,
+// an error of the primary node is returned as is, it would fail the build anyway
+func (q *QuorumNode) query(ctx context.Context, what string, call func(n api.FullNode) (interface{}, error)) (interface{}, error) {
+	type result struct {
+		res interface{}
+		err error
+	}
+
+	results := make([]result, len(q.peers))
+	var wg sync.WaitGroup
+	for i, peer := range q.peers {
+		wg.Add(1)
+		go func(i int, peer QuorumPeer) {
+			defer wg.Done()
+			res, err := call(peer.Node)
+			results[i] = result{res, err}
+		}(i, peer)
+	}
+
+	res, err := call(q.FullNode)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, peer := range q.peers {
+		if results[i].err != nil {
+			return nil, fmt.Errorf("%w: %s: node %s failed: %s", ErrQuorumDisagree, what, peer.Name, results[i].err)
+		}
+
+		if fields := diffFields(res, results[i].res); len(fields) != 0 {
+			return nil, fmt.Errorf("%w: %s: node %s differs from node %s in %s", ErrQuorumDisagree, what, peer.Name, q.name, strings.Join(fields, ", "))
+		}
+	}
+
+	return res, nil
+}
+
+// diffFields returns the names of the fields that differ between two structs of the same type,
+// values that are not structs are compared as a whole and reported as "value"
+func diffFields(a, b interface{}) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return []string{"type"}
+	}
+
+	for va.Kind() == reflect.Ptr {
+		if va.IsNil() || vb.IsNil() {
+			if va.IsNil() != vb.IsNil() {
+				return []string{"value"}
+			}
+			return nil
+		}
+		va, vb = va.Elem(), vb.Elem()
+	}
+
+	if va.Kind() != reflect.Struct || va.Type() == reflect.TypeOf(address.Address{}) || va.Type() == reflect.TypeOf(abi.TokenAmount{}) {
+		if !reflect.DeepEqual(va.Interface(), vb.Interface()) {
+			return []string{"value"}
+		}
+		return nil
+	}
+
+	var fields []string
+	for i := 0; i < va.NumField(); i++ {
+		if !va.Type().Field(i).IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			fields = append(fields, va.Type().Field(i).Name)
+		}
+	}
+
+	return fields
+}
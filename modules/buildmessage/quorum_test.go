@@ -0,0 +1,61 @@
+package buildmessage
+
+import (
+	"context"
+	"errors"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type fakeNode struct {
+	api.FullNode
+	info api.MinerInfo
+	err  error
+}
+
+func (f *fakeNode) StateMinerInfo(ctx context.Context, maddr address.Address, tsk types.TipSetKey) (api.MinerInfo, error) {
+	return f.info, f.err
+}
+
+func TestQuorumNode(t *testing.T) {
+	owner, _ := address.NewIDAddress(1000)
+	worker, _ := address.NewIDAddress(1001)
+	miner, _ := address.NewIDAddress(1002)
+
+	info := api.MinerInfo{Owner: owner, Worker: worker, Beneficiary: owner}
+	q := &QuorumNode{
+		FullNode: &fakeNode{info: info},
+		name:     "primary",
+		peers:    []QuorumPeer{{Name: "a", Node: &fakeNode{info: info}}},
+	}
+
+	mi, err := q.StateMinerInfo(context.Background(), miner, types.EmptyTSK)
+	require.NoError(t, err)
+	require.Equal(t, owner, mi.Owner)
+
+	lying := info
+	lying.Owner = worker
+	lying.Beneficiary = worker
+	q.peers = append(q.peers, QuorumPeer{Name: "b", Node: &fakeNode{info: lying}})
+
+	_, err = q.StateMinerInfo(context.Background(), miner, types.EmptyTSK)
+	require.True(t, errors.Is(err, ErrQuorumDisagree))
+	require.Contains(t, err.Error(), "node b")
+	require.Contains(t, err.Error(), "Owner, Beneficiary")
+
+	q.peers[1] = QuorumPeer{Name: "b", Node: &fakeNode{err: errors.New("unavailable")}}
+	_, err = q.StateMinerInfo(context.Background(), miner, types.EmptyTSK)
+	require.True(t, errors.Is(err, ErrQuorumDisagree))
+}
+
+func TestDiffFields(t *testing.T) {
+	a, _ := address.NewIDAddress(1000)
+	b, _ := address.NewIDAddress(1001)
+
+	require.Empty(t, diffFields(a, a))
+	require.Equal(t, []string{"value"}, diffFields(a, b))
+	require.Equal(t, []string{"Threshold"}, diffFields(MsigSigners{Signers: []address.Address{a}, Threshold: 1}, MsigSigners{Signers: []address.Address{a}, Threshold: 2}))
+}
@@ -0,0 +1,196 @@
+package buildmessage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v13/account"
+	"github.com/filecoin-project/go-state-types/builtin/v13/cron"
+	"github.com/filecoin-project/go-state-types/builtin/v13/datacap"
+	"github.com/filecoin-project/go-state-types/builtin/v13/eam"
+	"github.com/filecoin-project/go-state-types/builtin/v13/ethaccount"
+	"github.com/filecoin-project/go-state-types/builtin/v13/evm"
+	_init "github.com/filecoin-project/go-state-types/builtin/v13/init"
+	"github.com/filecoin-project/go-state-types/builtin/v13/market"
+	"github.com/filecoin-project/go-state-types/builtin/v13/miner"
+	"github.com/filecoin-project/go-state-types/builtin/v13/multisig"
+	"github.com/filecoin-project/go-state-types/builtin/v13/paych"
+	"github.com/filecoin-project/go-state-types/builtin/v13/power"
+	"github.com/filecoin-project/go-state-types/builtin/v13/reward"
+	"github.com/filecoin-project/go-state-types/builtin/v13/system"
+	"github.com/filecoin-project/go-state-types/builtin/v13/verifreg"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/go-state-types/manifest"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	lotusbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"reflect"
+)
+
+// actorMethods are the method tables of the builtin actors by manifest name, the lotus vm registry is
+// not used so that the cli and the client build without filecoin-ffi
+var actorMethods = map[string]map[abi.MethodNum]builtin.MethodMeta{
+	manifest.AccountKey:    account.Methods,
+	manifest.CronKey:       cron.Methods,
+	manifest.DatacapKey:    datacap.Methods,
+	manifest.EamKey:        eam.Methods,
+	manifest.EthAccountKey: ethaccount.Methods,
+	manifest.EvmKey:        evm.Methods,
+	manifest.InitKey:       _init.Methods,
+	manifest.MarketKey:     market.Methods,
+	manifest.MinerKey:      miner.Methods,
+	manifest.MultisigKey:   multisig.Methods,
+	manifest.PaychKey:      paych.Methods,
+	manifest.PowerKey:      power.Methods,
+	manifest.RewardKey:     reward.Methods,
+	manifest.SystemKey:     system.Methods,
+	manifest.VerifregKey:   verifreg.Methods,
+}
+
+// Simulate runs the unsigned msg through StateCall at the current head,
+// an error is only returned when the simulation could not run
+func Simulate(ctx context.Context, node api.FullNode, msg *types.Message) (*chain.Simulation, error) {
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := node.StateCall(ctx, msg, head.Key())
+	if err != nil {
+		return nil, fmt.Errorf("StateCall: %w", err)
+	}
+
+	sim := &chain.Simulation{
+		Error:  res.Error,
+		Height: int64(head.Height()),
+	}
+	if res.MsgRct == nil {
+		return sim, nil
+	}
+
+	sim.ExitCode = int64(res.MsgRct.ExitCode)
+	sim.GasUsed = res.MsgRct.GasUsed
+	if sim.ExitCode != 0 && sim.Error == "" {
+		sim.Error = exitcode.ExitCode(sim.ExitCode).Error()
+	}
+
+	if len(res.MsgRct.Return) == 0 {
+		return sim, nil
+	}
+
+	sim.Return = hex.EncodeToString(res.MsgRct.Return)
+	ret, err := decodeReturn(ctx, node, msg.To, msg.Method, res.MsgRct.Return, head.Key())
+	if err != nil {
+		log.Debugw("Simulate: decodeReturn", "err", err)
+		return sim, nil
+	}
+
+	if b, err := json.Marshal(ret); err == nil {
+		sim.Return = string(b)
+	}
+
+	// an applied msig proposal reports the exit code of the message it executed in its return
+	if applied, code, ok := msigApplied(ret); ok && applied && code != 0 && sim.Error == "" {
+		sim.Error = fmt.Sprintf("msig executed the proposal with exit code %d: %s", code, code.Error())
+	}
+
+	return sim, nil
+}
+
+// DecodeReturn decodes the return of method on the actor at to into json, it falls back to hex
+func DecodeReturn(ctx context.Context, node api.FullNode, to address.Address, method abi.MethodNum, ret []byte, tsk types.TipSetKey) string {
+	if len(ret) == 0 {
+		return ""
+	}
+
+	decoded, err := decodeReturn(ctx, node, to, method, ret, tsk)
+	if err != nil {
+		log.Debugw("DecodeReturn: decodeReturn", "err", err)
+		return hex.EncodeToString(ret)
+	}
+
+	b, err := json.Marshal(decoded)
+	if err != nil {
+		return hex.EncodeToString(ret)
+	}
+
+	return string(b)
+}
+
+func decodeReturn(ctx context.Context, node api.FullNode, to address.Address, methodNum abi.MethodNum, ret []byte, tsk types.TipSetKey) (interface{}, error) {
+	method, err := actorMethod(ctx, node, to, methodNum, tsk)
+	if err != nil {
+		return nil, err
+	}
+	ft := reflect.TypeOf(method.Method)
+	if ft == nil || ft.Kind() != reflect.Func || ft.NumOut() != 1 || ft.Out(0).Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("method %s has no return", method.Name)
+	}
+
+	rtyp, ok := reflect.New(ft.Out(0).Elem()).Interface().(cbg.CBORUnmarshaler)
+	if !ok {
+		return nil, fmt.Errorf("return of method %s can not be decoded", method.Name)
+	}
+
+	if err := rtyp.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		return nil, err
+	}
+
+	return rtyp, nil
+}
+
+// MethodName returns the name of method on the actor at to, a plain send is named Send
+func MethodName(ctx context.Context, node api.FullNode, to address.Address, method abi.MethodNum, tsk types.TipSetKey) (string, error) {
+	if method == builtin.MethodSend {
+		return "Send", nil
+	}
+
+	meta, err := actorMethod(ctx, node, to, method, tsk)
+	if err != nil {
+		return "", err
+	}
+
+	return meta.Name, nil
+}
+
+func actorMethod(ctx context.Context, node api.FullNode, to address.Address, method abi.MethodNum, tsk types.TipSetKey) (builtin.MethodMeta, error) {
+	act, err := node.StateGetActor(ctx, to, tsk)
+	if err != nil {
+		return builtin.MethodMeta{}, err
+	}
+
+	name := actors.CanonicalName(lotusbuiltin.ActorNameByCode(act.Code))
+	meta, ok := actorMethods[name][method]
+	if !ok {
+		return builtin.MethodMeta{}, fmt.Errorf("unknown method %d of actor %s", method, act.Code)
+	}
+
+	return meta, nil
+}
+
+// msigApplied reads Applied and Code of the propose and approve returns of any actors version
+func msigApplied(ret interface{}) (bool, exitcode.ExitCode, bool) {
+	v := reflect.ValueOf(ret)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false, 0, false
+	}
+
+	applied := v.FieldByName("Applied")
+	code := v.FieldByName("Code")
+	if !applied.IsValid() || applied.Kind() != reflect.Bool || !code.IsValid() || code.Type() != reflect.TypeOf(exitcode.Ok) {
+		return false, 0, false
+	}
+
+	return applied.Bool(), code.Interface().(exitcode.ExitCode), true
+}
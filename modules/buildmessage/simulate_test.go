@@ -0,0 +1,61 @@
+package buildmessage
+
+import (
+	"bytes"
+	"context"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	actorstypes "github.com/filecoin-project/go-state-types/actors"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/go-state-types/builtin/v13/miner"
+	multisig13 "github.com/filecoin-project/go-state-types/builtin/v13/multisig"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/go-state-types/manifest"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type actorNode struct {
+	api.FullNode
+	actor *types.Actor
+}
+
+func (a *actorNode) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	return a.actor, nil
+}
+
+func TestMsigApplied(t *testing.T) {
+	applied, code, ok := msigApplied(&multisig13.ApproveReturn{Applied: true, Code: exitcode.ErrForbidden})
+	require.True(t, ok)
+	require.True(t, applied)
+	require.Equal(t, exitcode.ErrForbidden, code)
+
+	applied, _, ok = msigApplied(&multisig13.ProposeReturn{TxnID: 3})
+	require.True(t, ok)
+	require.False(t, applied)
+
+	_, _, ok = msigApplied(&miner.GetBeneficiaryReturn{})
+	require.False(t, ok)
+}
+
+func TestDecodeReturn(t *testing.T) {
+	code, ok := actors.GetActorCodeID(actorstypes.Version13, manifest.MinerKey)
+	require.True(t, ok)
+	node := &actorNode{actor: &types.Actor{Code: code}}
+	maddr, _ := address.NewIDAddress(1000)
+
+	name, err := MethodName(context.Background(), node, maddr, builtin.MethodsMiner.WithdrawBalance, types.EmptyTSK)
+	require.NoError(t, err)
+	require.Equal(t, "WithdrawBalance", name)
+
+	amount := abi.NewTokenAmount(5)
+	buf := new(bytes.Buffer)
+	require.NoError(t, amount.MarshalCBOR(buf))
+	require.Equal(t, `"5"`, DecodeReturn(context.Background(), node, maddr, builtin.MethodsMiner.WithdrawBalance, buf.Bytes(), types.EmptyTSK))
+
+	// a method without a known return falls back to hex
+	require.Equal(t, "8140", DecodeReturn(context.Background(), node, maddr, 9999, []byte{0x81, 0x40}, types.EmptyTSK))
+}
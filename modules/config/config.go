@@ -0,0 +1,394 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const EnvPrefix = "OPEN_FIL_WALLET"
+
+type Config struct {
+	API        API
+	Wallet     Wallet
+	Node       Node
+	Tracker    Tracker
+	Indexer    Indexer
+	Events     Events
+	Metrics    Metrics
+	Automation Automation
+	Workflow   Workflow
+}
+
+type API struct {
+	// ListenPort is the port of the wallet api, it listens on localhost
+	ListenPort string
+	// WebUIPort is the port of the web ui static server
+	WebUIPort    string
+	ReadTimeout  Duration
+	WriteTimeout Duration
+}
+
+type Wallet struct {
+	// LockDuration is how long the wallet stays unlocked without any request, reloadable
+	LockDuration Duration
+	// NonceReservation is how long a nonce given to a built message stays reserved while the message is not pushed, reloadable
+	NonceReservation Duration
+}
+
+type Node struct {
+	// DefaultName, DefaultEndpoint and DefaultToken describe the built-in node, reloadable
+	DefaultName     string
+	DefaultEndpoint string
+	DefaultToken    string
+	// RequestTimeout bounds every lotus api request made by the wallet, reloadable
+	RequestTimeout Duration
+	// HealthInterval is how often all nodes are probed for failover, reloadable
+	HealthInterval Duration
+	// MaxHeadLag is how many epochs a node head may lag behind the wall clock and still be in sync, reloadable
+	MaxHeadLag int
+	// Quorum is how many nodes, the one in use included, must return the same miner and msig state
+	// before a miner or msig message is built, 0 or 1 disables quorum mode, reloadable
+	Quorum int
+	// QuorumNodes names the nodes asked in quorum mode, empty means all nodes, reloadable
+	QuorumNodes []string
+}
+
+type Tracker struct {
+	// PollInterval is how often a pending message is searched on chain, reloadable
+	PollInterval Duration
+	// ReceiverBuffer is the capacity of the pending message queue
+	ReceiverBuffer int
+}
+
+type Indexer struct {
+	// Enable turns on the chain indexer that records the messages of owned and watched addresses, reloadable
+	Enable bool
+	// StartEpoch is the first epoch indexed, changing it walks the chain again from the new epoch, reloadable
+	StartEpoch int
+	// Confidence is how many epochs the indexer stays behind the head, reloadable
+	Confidence int
+	// Interval is how often the indexer follows the head, reloadable
+	Interval Duration
+}
+
+type Events struct {
+	// Webhooks are the urls every event is posted to, they get the events of all addresses since
+	// the user scopes only apply to /events, reloadable
+	Webhooks []string
+	// WebhookSecret signs the webhook payloads with HMAC-SHA256, empty sends them unsigned, reloadable
+	WebhookSecret string
+	// WebhookRetries is how many times a failed delivery is retried with backoff, reloadable
+	WebhookRetries int
+	// WatchInterval is how often msig proposals, miner roles and balances are checked, 0 disables, reloadable
+	WatchInterval Duration
+	// LowBalance in FIL raises an event when an owned wallet falls below it, empty disables, reloadable
+	LowBalance string
+}
+
+type Metrics struct {
+	// Balances are the addresses and miners exported as balance gauges on /metrics, reloadable
+	Balances []string
+	// BalanceInterval is how often the balance gauges are refreshed, 0 disables, reloadable
+	BalanceInterval Duration
+}
+
+// Automation is the spending policy of the messages the automation rules sign without a user
+type Automation struct {
+	// Interval is how often the automation rules are checked, 0 disables them, reloadable
+	Interval Duration
+	// MaxAmount in FIL caps a single automated withdrawal, empty leaves it uncapped, reloadable
+	MaxAmount string
+	// DailyLimit in FIL caps the automated withdrawals signed in the last 24 hours, empty leaves it uncapped, reloadable
+	DailyLimit string
+	// TopUpMaxAmount in FIL caps a single top-up of a worker or control address, empty leaves it uncapped, reloadable
+	TopUpMaxAmount string
+	// TopUpDailyLimit in FIL caps the top-ups sent in the last 24 hours, empty leaves it uncapped, reloadable
+	TopUpDailyLimit string
+}
+
+type Workflow struct {
+	// Interval is how often the started miner workflows are followed on chain, 0 disables, reloadable
+	Interval Duration
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		API: API{
+			ListenPort:   "6678",
+			WebUIPort:    "8080",
+			ReadTimeout:  Duration(10 * time.Second),
+			WriteTimeout: Duration(10 * time.Second),
+		},
+		Wallet: Wallet{
+			LockDuration:     Duration(10 * time.Minute),
+			NonceReservation: Duration(time.Hour),
+		},
+		Node: Node{
+			DefaultName:     "glif",
+			DefaultEndpoint: "https://api.node.glif.io/rpc/v0",
+			DefaultToken:    "",
+			RequestTimeout:  Duration(10 * time.Second),
+			HealthInterval:  Duration(30 * time.Second),
+			MaxHeadLag:      5,
+		},
+		Tracker: Tracker{
+			PollInterval:   Duration(time.Minute),
+			ReceiverBuffer: 50,
+		},
+		Indexer: Indexer{
+			Enable:     false,
+			StartEpoch: 0,
+			Confidence: 5,
+			Interval:   Duration(30 * time.Second),
+		},
+		Events: Events{
+			WebhookRetries: 5,
+			WatchInterval:  Duration(time.Minute),
+		},
+		Metrics: Metrics{
+			BalanceInterval: Duration(time.Minute),
+		},
+		Automation: Automation{
+			Interval: Duration(5 * time.Minute),
+		},
+		Workflow: Workflow{
+			Interval: Duration(time.Minute),
+		},
+	}
+}
+
+// Load reads the config file at path on top of the defaults and applies the env overrides,
+// a missing file is not an error, repos initialized by older versions do not have one
+func Load(path string) (*Config, error) {
+	cfg, err := FromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ApplyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// FromFile reads the config file at path on top of the defaults without env overrides
+func FromFile(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if _, err := toml.Decode(string(data), cfg); err != nil {
+		return nil, fmt.Errorf("decoding config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+func Save(path string, cfg *Config) error {
+	data, err := Encode(cfg)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func Encode(cfg *Config) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ApplyEnv overrides fields with env vars named OPEN_FIL_WALLET_<SECTION>_<FIELD>,
+// e.g. OPEN_FIL_WALLET_TRACKER_POLLINTERVAL=30s
+func ApplyEnv(cfg *Config) error {
+	for _, key := range Keys() {
+		env := EnvName(key)
+		value, ok := os.LookupEnv(env)
+		if !ok {
+			continue
+		}
+
+		if err := Set(cfg, key, value); err != nil {
+			return fmt.Errorf("env %s: %w", env, err)
+		}
+	}
+
+	return nil
+}
+
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys returns all settable keys in the form Section.Field
+func Keys() []string {
+	var keys []string
+	rt := reflect.TypeOf(Config{})
+	for i := 0; i < rt.NumField(); i++ {
+		section := rt.Field(i)
+		for j := 0; j < section.Type.NumField(); j++ {
+			keys = append(keys, section.Name+"."+section.Type.Field(j).Name)
+		}
+	}
+
+	return keys
+}
+
+// disabledByZero are the durations that turn their feature off when set to 0
+var disabledByZero = map[string]bool{
+	"events.watchinterval":    true,
+	"automation.interval":     true,
+	"workflow.interval":       true,
+	"metrics.balanceinterval": true,
+}
+
+// Validate rejects the values the wallet can not run with: durations must be positive,
+// or not negative where 0 disables, and sizes must not be negative
+func (cfg *Config) Validate() error {
+	for _, key := range Keys() {
+		field, err := lookup(cfg, key)
+		if err != nil {
+			return err
+		}
+
+		if err := validateField(key, field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateField(key string, field reflect.Value) error {
+	if d, ok := field.Interface().(Duration); ok {
+		if d < 0 || (d == 0 && !disabledByZero[strings.ToLower(key)]) {
+			return fmt.Errorf("%s must be positive: %s", key, d.Duration())
+		}
+		return nil
+	}
+
+	if field.Kind() == reflect.Int && field.Int() < 0 {
+		return fmt.Errorf("%s must not be negative: %d", key, field.Int())
+	}
+
+	return nil
+}
+
+// Set assigns value to the field named by key (Section.Field, case-insensitive),
+// a value that does not pass validation leaves the field unchanged
+func Set(cfg *Config, key string, value string) error {
+	field, err := lookup(cfg, key)
+	if err != nil {
+		return err
+	}
+
+	switch field.Interface().(type) {
+	case Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", key, err)
+		}
+		if err := validateField(key, reflect.ValueOf(Duration(d))); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(Duration(d)))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", key, err)
+		}
+		if err := validateField(key, reflect.ValueOf(i)); err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", key, err)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type of %s: %s", key, field.Type())
+		}
+		// comma separated, an empty value clears the list
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type of %s: %s", key, field.Kind())
+	}
+
+	return nil
+}
+
+func lookup(cfg *Config, key string) (reflect.Value, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 2 {
+		return reflect.Value{}, errors.New("key must be in the form Section.Field")
+	}
+
+	v := reflect.ValueOf(cfg).Elem()
+	for _, part := range parts {
+		v = v.FieldByNameFunc(func(name string) bool {
+			return strings.EqualFold(name, part)
+		})
+		if !v.IsValid() {
+			return reflect.Value{}, fmt.Errorf("unknown config key: %s", key)
+		}
+	}
+
+	return v, nil
+}
+
+type Duration time.Duration
+
+// UnmarshalText implements interface for TOML decoding
+func (dur *Duration) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*dur = Duration(d)
+	return nil
+}
+
+func (dur Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(dur).String()), nil
+}
+
+func (dur Duration) Duration() time.Duration {
+	return time.Duration(dur)
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, DefaultConfig(), cfg)
+
+	require.NoError(t, Set(cfg, "wallet.lockduration", "30m"))
+	require.NoError(t, Set(cfg, "Tracker.ReceiverBuffer", "100"))
+	require.NoError(t, Set(cfg, "Node.QuorumNodes", "glif, local"))
+	require.Error(t, Set(cfg, "Tracker.Unknown", "1"))
+	require.Error(t, Set(cfg, "Tracker.PollInterval", "1"))
+	require.NoError(t, Save(path, cfg))
+
+	t.Setenv(EnvName("Tracker.PollInterval"), "30s")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, 30*time.Minute, loaded.Wallet.LockDuration.Duration())
+	require.Equal(t, 100, loaded.Tracker.ReceiverBuffer)
+	require.Equal(t, 30*time.Second, loaded.Tracker.PollInterval.Duration())
+	require.Equal(t, []string{"glif", "local"}, loaded.Node.QuorumNodes)
+
+	fromFile, err := FromFile(path)
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, fromFile.Tracker.PollInterval.Duration())
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, DefaultConfig().Validate())
+
+	cfg := DefaultConfig()
+	require.Error(t, Set(cfg, "Wallet.LockDuration", "0s"))
+	require.Equal(t, DefaultConfig().Wallet.LockDuration, cfg.Wallet.LockDuration)
+	require.Error(t, Set(cfg, "Tracker.PollInterval", "-1m"))
+	require.Error(t, Set(cfg, "Tracker.ReceiverBuffer", "-1"))
+	require.NoError(t, Set(cfg, "Automation.Interval", "0s"))
+	require.Error(t, Set(cfg, "automation.interval", "-1s"))
+	require.NoError(t, Set(cfg, "Metrics.BalanceInterval", "0s"))
+
+	cfg.Tracker.PollInterval = 0
+	require.Error(t, cfg.Validate())
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, Save(path, cfg))
+	_, err := Load(path)
+	require.Error(t, err)
+}
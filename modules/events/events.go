@@ -0,0 +1,112 @@
+package events
+
+import (
+	logging "github.com/ipfs/go-log/v2"
+	"sync"
+	"time"
+)
+
+var log = logging.Logger("events")
+
+type Type string
+
+const (
+	TxPending               Type = "tx.pending"
+	TxSuccess               Type = "tx.success"
+	TxFailed                Type = "tx.failed"
+	MsigProposal            Type = "msig.proposal"
+	MinerOwnerChanged       Type = "miner.owner_changed"
+	MinerWorkerChanged      Type = "miner.worker_changed"
+	MinerBeneficiaryChanged Type = "miner.beneficiary_changed"
+	BalanceLow              Type = "balance.low"
+	MinerWorkflowChanged    Type = "miner.workflow_changed"
+)
+
+// recentEvents is how many events are kept to replay to a reconnecting subscriber
+const recentEvents = 256
+
+type Event struct {
+	// ID grows across restarts, it is the nanosecond clock the bus was started at plus the sequence number
+	ID   uint64      `json:"id"`
+	Type Type        `json:"type"`
+	Time int64       `json:"time"` // unix seconds
+	Data interface{} `json:"data"`
+}
+
+// Bus fans the published events out to the subscribers, a subscriber that does not keep up misses events
+type Bus struct {
+	lk     sync.Mutex
+	nextID uint64
+	subs   map[int]chan Event
+	subID  int
+	recent []Event
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		nextID: uint64(time.Now().UnixNano()),
+		subs:   make(map[int]chan Event),
+	}
+}
+
+// Publish is a no-op on a nil bus
+func (b *Bus) Publish(typ Type, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	b.nextID++
+	evt := Event{
+		ID:   b.nextID,
+		Type: typ,
+		Time: time.Now().Unix(),
+		Data: data,
+	}
+
+	b.recent = append(b.recent, evt)
+	if len(b.recent) > recentEvents {
+		b.recent = b.recent[len(b.recent)-recentEvents:]
+	}
+
+	for id, ch := range b.subs {
+		select {
+		case ch <- evt:
+		default:
+			log.Warnw("Publish: subscriber is full, event dropped", "subscriber", id, "event", evt.ID, "type", evt.Type)
+		}
+	}
+
+	log.Debugw("Publish", "id", evt.ID, "type", evt.Type)
+}
+
+// Subscribe returns the events published after the call and the events kept since the event lastID,
+// 0 replays nothing, cancel must be called to release the subscription
+func (b *Bus) Subscribe(buffer int, lastID uint64) (<-chan Event, []Event, func()) {
+	b.lk.Lock()
+	defer b.lk.Unlock()
+
+	var replay []Event
+	if lastID != 0 {
+		for _, evt := range b.recent {
+			if evt.ID > lastID {
+				replay = append(replay, evt)
+			}
+		}
+	}
+
+	b.subID++
+	id := b.subID
+	ch := make(chan Event, buffer)
+	b.subs[id] = ch
+
+	cancel := func() {
+		b.lk.Lock()
+		defer b.lk.Unlock()
+		delete(b.subs, id)
+	}
+
+	return ch, replay, cancel
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	bus.Publish(TxPending, "a")
+
+	ch, replay, cancel := bus.Subscribe(1, 0)
+	require.Empty(t, replay)
+
+	bus.Publish(TxSuccess, "b")
+	first := <-ch
+	require.Equal(t, TxSuccess, first.Type)
+
+	// the subscriber is full, the event is dropped but kept for replay
+	bus.Publish(TxFailed, "c")
+	bus.Publish(TxFailed, "d")
+	<-ch
+	cancel()
+
+	_, replay, cancel = bus.Subscribe(1, first.ID)
+	defer cancel()
+	require.Len(t, replay, 2)
+	require.Equal(t, "c", replay[0].Data)
+	require.Equal(t, "d", replay[1].Data)
+
+	var nilBus *Bus
+	nilBus.Publish(TxPending, nil)
+}
+
+func TestWebhooks(t *testing.T) {
+	var (
+		lk       sync.Mutex
+		attempts int
+		received []Event
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		require.Equal(t, Sign("secret", timestamp, body), r.Header.Get(SignatureHeader))
+
+		lk.Lock()
+		defer lk.Unlock()
+		attempts++
+		// the first delivery fails and is retried
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		var evt Event
+		require.NoError(t, json.Unmarshal(body, &evt))
+		received = append(received, evt)
+	}))
+	defer srv.Close()
+
+	bus := NewBus()
+	wh := NewWebhooks(bus, func() WebhookConfig {
+		return WebhookConfig{URLs: []string{srv.URL}, Secret: "secret", Retries: 2}
+	})
+	wh.backoff = func(int) time.Duration { return time.Millisecond }
+
+	close := make(chan struct{})
+	defer func() { close <- struct{}{} }()
+	go wh.Run(close)
+
+	// let Run subscribe
+	time.Sleep(50 * time.Millisecond)
+	bus.Publish(MsigProposal, "a")
+	bus.Publish(BalanceLow, "b")
+
+	require.Eventually(t, func() bool {
+		lk.Lock()
+		defer lk.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Equal(t, MsigProposal, received[0].Type)
+	require.Equal(t, BalanceLow, received[1].Type)
+	require.Equal(t, 3, attempts)
+}
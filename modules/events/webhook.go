@@ -0,0 +1,177 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	EventHeader     = "X-OpenFil-Event"
+	DeliveryHeader  = "X-OpenFil-Delivery"
+	TimestampHeader = "X-OpenFil-Timestamp"
+	// SignatureHeader is sha256=<hex hmac>, see Sign
+	SignatureHeader = "X-OpenFil-Signature"
+)
+
+const (
+	webhookQueue      = 256
+	webhookTimeout    = 10 * time.Second
+	webhookMinBackoff = time.Second
+	webhookMaxBackoff = 5 * time.Minute
+)
+
+type WebhookConfig struct {
+	URLs    []string
+	Secret  string
+	Retries int
+}
+
+// Sign is the HMAC-SHA256 of timestamp.body keyed by secret, the timestamp lets receivers reject replays
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhooks posts every event of the bus to the configured urls, each url gets the events in order,
+// a failed delivery is retried with exponential backoff before it is dropped
+type Webhooks struct {
+	bus    *Bus
+	cfg    func() WebhookConfig
+	client *http.Client
+
+	lk      sync.Mutex
+	workers map[string]chan Event
+
+	// backoff is replaced in tests
+	backoff func(attempt int) time.Duration
+}
+
+func NewWebhooks(bus *Bus, cfg func() WebhookConfig) *Webhooks {
+	return &Webhooks{
+		bus:     bus,
+		cfg:     cfg,
+		client:  &http.Client{Timeout: webhookTimeout},
+		workers: make(map[string]chan Event),
+		backoff: backoff,
+	}
+}
+
+func backoff(attempt int) time.Duration {
+	d := webhookMinBackoff << uint(attempt)
+	if d <= 0 || d > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return d
+}
+
+// Run delivers the events until close, the urls are read from the config for every event
+func (wh *Webhooks) Run(close <-chan struct{}) {
+	ch, _, cancel := wh.bus.Subscribe(webhookQueue, 0)
+	defer cancel()
+
+	for {
+		select {
+		case evt := <-ch:
+			for _, url := range wh.cfg().URLs {
+				select {
+				case wh.worker(url, close) <- evt:
+				default:
+					log.Warnw("Webhooks: queue is full, event dropped", "url", url, "event", evt.ID)
+				}
+			}
+		case <-close:
+			return
+		}
+	}
+}
+
+func (wh *Webhooks) worker(url string, close <-chan struct{}) chan Event {
+	wh.lk.Lock()
+	defer wh.lk.Unlock()
+
+	if ch, ok := wh.workers[url]; ok {
+		return ch
+	}
+
+	ch := make(chan Event, webhookQueue)
+	wh.workers[url] = ch
+	go func() {
+		for {
+			select {
+			case evt := <-ch:
+				wh.deliver(url, evt, close)
+			case <-close:
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+func (wh *Webhooks) deliver(url string, evt Event, close <-chan struct{}) {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		log.Warnw("Webhooks: Marshal", "event", evt.ID, "err", err)
+		return
+	}
+
+	retries := wh.cfg().Retries
+	for attempt := 0; ; attempt++ {
+		err = wh.post(url, evt, body)
+		if err == nil {
+			return
+		}
+
+		if attempt >= retries {
+			log.Warnw("Webhooks: delivery failed, event dropped", "url", url, "event", evt.ID, "attempts", attempt+1, "err", err)
+			return
+		}
+
+		log.Debugw("Webhooks: delivery failed, retry", "url", url, "event", evt.ID, "attempt", attempt+1, "err", err)
+		select {
+		case <-time.After(wh.backoff(attempt)):
+		case <-close:
+			return
+		}
+	}
+}
+
+func (wh *Webhooks) post(url string, evt Event, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(evt.Type))
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(evt.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if secret := wh.cfg().Secret; secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+	}
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	_ "github.com/OpenFilWallet/OpenFilWallet/lib/sigs/bls"
 	_ "github.com/OpenFilWallet/OpenFilWallet/lib/sigs/secp"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/metrics"
 	"github.com/ethereum/go-ethereum/common"
 	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -19,6 +20,7 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"golang.org/x/xerrors"
 	"math/big"
+	"strconv"
 	"sync"
 )
 
@@ -98,6 +100,7 @@ func (s *SignerHouse) SignMsg(msg *types.Message) (*types.SignedMessage, error)
 	}
 
 	log.Infow("SignMsg", "message", buildmessage.LotusMessageToString(msg))
+	metrics.SignTotal.WithLabelValues(msg.From.String(), strconv.FormatUint(uint64(msg.Method), 10)).Inc()
 	return &types.SignedMessage{
 		Message:   *msg,
 		Signature: *sig,
@@ -121,6 +124,7 @@ func (s *SignerHouse) SignTx(sender string, transaction *ethtypes.Transaction) (
 	if err != nil {
 		return nil, err
 	}
+	metrics.SignTotal.WithLabelValues(sender, "eth_tx").Inc()
 
 	return transaction, nil
 }
@@ -158,6 +162,7 @@ func (s *SignerHouse) Sign(from string, data []byte) ([]byte, error) {
 	}
 
 	log.Infow("Sign", "data", hex.EncodeToString(sigBytes))
+	metrics.SignTotal.WithLabelValues(from, "sign_bytes").Inc()
 	return sigBytes, nil
 }
 
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "openfil"
+
+// Registry holds the wallet metrics, it is not the global registry so the lotus dependencies add nothing to it
+var Registry = prometheus.NewRegistry()
+
+var (
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Api requests by route, method and status code",
+	}, []string{"route", "method", "code"})
+
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Api request latency by route and method",
+		Buckets:   []float64{0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"route", "method"})
+
+	SignTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sign_total",
+		Help:      "Signatures by signing address and message method, raw data is method sign_bytes and eth transactions eth_tx",
+	}, []string{"address", "method"})
+
+	TrackerPending = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "tracker_pending_messages",
+		Help:      "Pushed messages the tracker is waiting for on chain",
+	})
+
+	TrackerPollErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tracker_poll_errors_total",
+		Help:      "Failed searches of tracked messages",
+	})
+
+	NodeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_latency_seconds",
+		Help:      "Latency of the last health probe by node",
+	}, []string{"node"})
+
+	NodeHeadLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_head_lag_epochs",
+		Help:      "Epochs the node head lags behind the wall clock at the last health probe",
+	}, []string{"node"})
+
+	NodeHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_healthy",
+		Help:      "1 when the last health probe of the node succeeded",
+	}, []string{"node"})
+
+	NodeInUse = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_in_use",
+		Help:      "1 for the node the wallet uses",
+	}, []string{"node"})
+
+	WalletLocked = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "wallet_locked",
+		Help:      "1 while the wallet is locked",
+	})
+
+	WalletSealed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "wallet_sealed",
+		Help:      "1 while the wallet is sealed",
+	})
+
+	Balance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "balance_fil",
+		Help:      "Balance in FIL of the configured addresses and miners",
+	}, []string{"address"})
+
+	MinerAvailableBalance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "miner_available_balance_fil",
+		Help:      "Withdrawable balance in FIL of the configured miners",
+	}, []string{"miner"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestsTotal,
+		RequestDuration,
+		SignTotal,
+		TrackerPending,
+		TrackerPollErrors,
+		NodeLatency,
+		NodeHeadLag,
+		NodeHealthy,
+		NodeInUse,
+		WalletLocked,
+		WalletSealed,
+		Balance,
+		MinerAvailableBalance,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+func ObserveRequest(route, method string, code int, cost time.Duration) {
+	RequestsTotal.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	RequestDuration.WithLabelValues(route, method).Observe(cost.Seconds())
+}
+
+func Bool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
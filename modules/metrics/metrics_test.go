@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	ObserveRequest("/balance", "GET", 200, 20*time.Millisecond)
+	ObserveRequest("/balance", "GET", 1001, time.Millisecond)
+	require.Equal(t, float64(1), testutil.ToFloat64(RequestsTotal.WithLabelValues("/balance", "GET", "1001")))
+
+	SignTotal.WithLabelValues("f01000", "0").Inc()
+	WalletLocked.Set(Bool(true))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`openfil_http_requests_total{code="200",method="GET",route="/balance"} 1`,
+		`openfil_sign_total{address="f01000",method="0"} 1`,
+		`openfil_wallet_locked 1`,
+	} {
+		require.True(t, strings.Contains(body, line), line)
+	}
+}
@@ -3,6 +3,7 @@ package repo
 import (
 	"bytes"
 	"errors"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
 	fslock "github.com/ipfs/go-fs-lock"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/mitchellh/go-homedir"
@@ -27,8 +28,11 @@ type Repo interface {
 const (
 	fsAPI       = "api"
 	fsAPIToken  = "token"
+	fsUserToken = "user_token"
+	fsConfig    = "config.toml"
 	fsDatastore = "datastore"
 	fsLock      = "repo.lock"
+	fsUnseal    = "unseal.sock"
 )
 
 var (
@@ -81,7 +85,33 @@ func (fsr *FsRepo) Init() error {
 		return err
 	}
 
-	return nil
+	return fsr.initConfig()
+}
+
+func (fsr *FsRepo) initConfig() error {
+	_, err := os.Stat(fsr.ConfigPath())
+	if err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	return config.Save(fsr.ConfigPath(), config.DefaultConfig())
+}
+
+// ConfigPath returns the path of config.toml in the repo
+func (fsr *FsRepo) ConfigPath() string {
+	return filepath.Join(fsr.path, fsConfig)
+}
+
+// Config loads config.toml with env overrides applied
+func (fsr *FsRepo) Config() (*config.Config, error) {
+	return config.Load(fsr.ConfigPath())
+}
+
+// UnsealSocketPath returns the path of the local socket openfild accepts the master password on
+func (fsr *FsRepo) UnsealSocketPath() string {
+	return filepath.Join(fsr.path, fsUnseal)
 }
 
 func (fsr *FsRepo) APIEndpoint() (string, error) {
@@ -122,6 +152,32 @@ func (fsr *FsRepo) APIToken() ([]byte, error) {
 	return bytes.TrimSpace(tb), nil
 }
 
+// UserToken returns the token of the user logged in with the cli, nil when no user is logged in
+func (fsr *FsRepo) UserToken() ([]byte, error) {
+	tb, err := ioutil.ReadFile(filepath.Join(fsr.path, fsUserToken))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSpace(tb), nil
+}
+
+// SetUserToken stores the token of the user logged in with the cli, an empty token logs the user out.
+// It does not need the repo lock, the cli stores the token while openfild is running
+func (fsr *FsRepo) SetUserToken(token []byte) error {
+	p := filepath.Join(fsr.path, fsUserToken)
+	if len(token) == 0 {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return ioutil.WriteFile(p, token, 0600)
+}
+
 func (fsr *FsRepo) Lock() (LockedRepo, error) {
 	locked, err := fslock.Locked(fsr.path, fsLock)
 	if err != nil {
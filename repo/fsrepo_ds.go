@@ -9,6 +9,7 @@ import (
 	"golang.org/x/xerrors"
 	"io"
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"sync"
@@ -37,6 +38,9 @@ type LockedRepo interface {
 	SetAPIEndpoint(string) error
 
 	SetAPIToken([]byte) error
+
+	// UnsealListener listens on the unseal socket of the repo, only the owner can connect
+	UnsealListener() (net.Listener, error)
 }
 
 type fsLockedRepo struct {
@@ -55,6 +59,12 @@ func (fsr *fsLockedRepo) Close() error {
 	if err != nil && !os.IsNotExist(err) {
 		return xerrors.Errorf("could not remove API file: %w", err)
 	}
+
+	err = os.Remove(fsr.join(fsUnseal))
+	if err != nil && !os.IsNotExist(err) {
+		return xerrors.Errorf("could not remove unseal socket: %w", err)
+	}
+
 	if fsr.ds != nil {
 		if err := fsr.ds.Close(); err != nil {
 			return xerrors.Errorf("could not close datastore: %w", err)
@@ -117,6 +127,43 @@ func (fsr *fsLockedRepo) SetAPIToken(token []byte) error {
 	return ioutil.WriteFile(fsr.join(fsAPIToken), token, 0600)
 }
 
+func (fsr *fsLockedRepo) UnsealListener() (net.Listener, error) {
+	if err := fsr.stillValid(); err != nil {
+		return nil, err
+	}
+
+	// the repo is locked, so a socket left behind can only be stale
+	return ListenUnix(fsr.join(fsUnseal))
+}
+
+// ListenUnix listens on the Unix socket at path with 0600 permissions,
+// a stale socket file at path is removed first
+func ListenUnix(path string) (net.Listener, error) {
+	fi, err := os.Lstat(path)
+	if err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, xerrors.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, xerrors.Errorf("could not remove stale socket: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
 func (fsr *fsLockedRepo) stillValid() error {
 	if fsr.closer == nil {
 		return ErrClosedRepo
@@ -9,7 +9,6 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/gin-gonic/gin"
-	"time"
 )
 
 // WalletCreate Post
@@ -76,8 +75,13 @@ func (w *Wallet) WalletList(c *gin.Context) {
 		return
 	}
 
+	user := w.requestUser(c)
 	walletListMap := make(map[string][]datastore.PrivateWallet)
 	for _, wallet := range walletList {
+		if !userAllowsAddress(user, wallet.Address) {
+			continue
+		}
+
 		if _, ok := walletListMap[walletType(wallet.Address)]; !ok {
 			walletListMap[walletType(wallet.Address)] = []datastore.PrivateWallet{wallet}
 			continue
@@ -87,7 +91,7 @@ func (w *Wallet) WalletList(c *gin.Context) {
 	}
 
 	data := make([]client.WalletListInfo, 0)
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
 	defer cancel()
 
 	for _, key := range []string{"secp256k1", "bls"} {
@@ -97,7 +101,7 @@ func (w *Wallet) WalletList(c *gin.Context) {
 				addr, _ := address.NewFromString(wallet.Address)
 
 				if isBalance {
-					amount, err = w.node.Api.WalletBalance(timeoutCtx, addr)
+					amount, err = w.node().Api.WalletBalance(timeoutCtx, addr)
 					if err != nil {
 						log.Warnw("Balance: WalletBalance", "err", err.Error())
 						ReturnError(c, NewError(500, err.Error()))
@@ -106,7 +110,7 @@ func (w *Wallet) WalletList(c *gin.Context) {
 				}
 
 				walletId := ""
-				id, err := w.node.Api.StateLookupID(timeoutCtx, addr, types.EmptyTSK)
+				id, err := w.node().Api.StateLookupID(timeoutCtx, addr, types.EmptyTSK)
 				if err != nil {
 					log.Infow("StateLookupID", "err", err.Error())
 					walletId = "NotFound"
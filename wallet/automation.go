@@ -0,0 +1,649 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+	"sort"
+	"time"
+)
+
+// the least time between two runs of a rule created without interval
+const (
+	defaultWithdrawInterval = 24 * time.Hour
+	defaultTopUpInterval    = time.Hour
+)
+
+// spendingPolicy bounds the withdrawals the wallet signs for the automation rules, a nil bound is uncapped
+type spendingPolicy struct {
+	maxAmount *big.Int
+	dailyLeft *big.Int
+}
+
+// newSpendingPolicy is the policy of the rules of kind, the amounts pushed by their runs
+// of the last 24 hours before now are taken off the daily limit
+func newSpendingPolicy(cfg config.Automation, kind datastore.AutomationKind, runs []datastore.AutomationRun, now time.Time) (*spendingPolicy, error) {
+	maxKey, maxAmountStr, limitKey, dailyLimit := "MaxAmount", cfg.MaxAmount, "DailyLimit", cfg.DailyLimit
+	if kind == datastore.AutomationTopUp {
+		maxKey, maxAmountStr, limitKey, dailyLimit = "TopUpMaxAmount", cfg.TopUpMaxAmount, "TopUpDailyLimit", cfg.TopUpDailyLimit
+	}
+
+	policy := &spendingPolicy{}
+	if maxAmountStr != "" {
+		maxAmount, err := types.ParseFIL(maxAmountStr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing Automation.%s: %w", maxKey, err)
+		}
+		policy.maxAmount = (*big.Int)(&maxAmount)
+	}
+
+	if dailyLimit != "" {
+		limit, err := types.ParseFIL(dailyLimit)
+		if err != nil {
+			return nil, fmt.Errorf("parsing Automation.%s: %w", limitKey, err)
+		}
+
+		left := big.Int(limit)
+		since := now.Add(-24 * time.Hour).UnixNano()
+		for _, run := range runs {
+			if automationKind(run.Kind) != kind || run.Action != datastore.AutomationPushed || run.Time < since {
+				continue
+			}
+
+			amount, err := big.FromString(run.Amount)
+			if err != nil {
+				continue
+			}
+			left = big.Sub(left, amount)
+		}
+		left = big.Max(left, big.Zero())
+		policy.dailyLeft = &left
+	}
+
+	return policy, nil
+}
+
+// cap returns the part of amount the policy allows
+func (p *spendingPolicy) cap(amount big.Int) big.Int {
+	if p.maxAmount != nil {
+		amount = big.Min(amount, *p.maxAmount)
+	}
+	if p.dailyLeft != nil {
+		amount = big.Min(amount, *p.dailyLeft)
+	}
+	return amount
+}
+
+func (p *spendingPolicy) spend(amount big.Int) {
+	if p.dailyLeft != nil {
+		left := big.Max(big.Sub(*p.dailyLeft, amount), big.Zero())
+		p.dailyLeft = &left
+	}
+}
+
+// automationKind is the kind of a rule or run, the ones saved before kinds existed are withdrawals
+func automationKind(kind datastore.AutomationKind) datastore.AutomationKind {
+	if kind == "" {
+		return datastore.AutomationWithdraw
+	}
+	return kind
+}
+
+func (w *Wallet) automationLoop(close <-chan struct{}) {
+	interval := func() time.Duration {
+		return w.automationConfig().Interval.Duration()
+	}
+
+	runEvery(interval, func() {
+		if w.offline || w.node() == nil || w.isSealed() {
+			return
+		}
+
+		w.runRules(w.automationConfig())
+	}, close)
+}
+
+// runRules runs every enabled rule whose interval has passed, a rule that withdraws nothing is not logged
+func (w *Wallet) runRules(cfg config.Automation) {
+	rules, err := w.db.AutomationRuleList()
+	if err != nil {
+		log.Warnw("runRules: AutomationRuleList", "err", err)
+		return
+	}
+
+	runs, err := w.db.AutomationRunList()
+	if err != nil {
+		log.Warnw("runRules: AutomationRunList", "err", err)
+		return
+	}
+
+	policies := make(map[datastore.AutomationKind]*spendingPolicy)
+	for _, kind := range []datastore.AutomationKind{datastore.AutomationWithdraw, datastore.AutomationTopUp} {
+		policy, err := newSpendingPolicy(cfg, kind, runs, time.Now())
+		if err != nil {
+			log.Warnw("runRules: newSpendingPolicy", "kind", kind, "err", err)
+			return
+		}
+		policies[kind] = policy
+	}
+
+	node, err := w.buildNode()
+	if err != nil {
+		log.Warnw("runRules: buildNode", "err", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if !rule.Enable || time.Since(time.Unix(rule.LastRun, 0)) < time.Duration(rule.Interval)*time.Second {
+			continue
+		}
+
+		kind := automationKind(rule.Kind)
+		var ruleRuns []*datastore.AutomationRun
+		switch kind {
+		case datastore.AutomationWithdraw:
+			if run := w.runWithdrawRule(node, rule, policies[kind]); run != nil {
+				ruleRuns = append(ruleRuns, run)
+			}
+		case datastore.AutomationTopUp:
+			ruleRuns = w.runTopUpRule(node, rule, policies[kind], runs)
+		}
+		if len(ruleRuns) == 0 {
+			continue
+		}
+
+		for _, run := range ruleRuns {
+			log.Infow("runRules: rule run", "rule", rule.ID, "kind", kind, "miner", rule.MinerId, "action", run.Action, "to", run.To, "amount", run.Amount, "txCid", run.TxCid, "detail", run.Detail)
+			if err := w.db.SetAutomationRun(run); err != nil {
+				log.Warnw("runRules: SetAutomationRun", "rule", rule.ID, "err", err)
+			}
+		}
+
+		rule.LastRun = time.Now().Unix()
+		if err := w.db.SetAutomationRule(&rule); err != nil {
+			log.Warnw("runRules: SetAutomationRule", "rule", rule.ID, "err", err)
+		}
+	}
+}
+
+// runWithdrawRule withdraws the available balance of the miner of rule once it exceeds the threshold. The withdrawal
+// is signed and pushed when the wallet holds the key of the sender, a msig sender gets an unsigned proposal
+func (w *Wallet) runWithdrawRule(node api.FullNode, rule datastore.AutomationRule, policy *spendingPolicy) *datastore.AutomationRun {
+	run := newAutomationRun(rule)
+	fail := func(err error) *datastore.AutomationRun {
+		run.Action = datastore.AutomationFailed
+		run.Detail = err.Error()
+		return run
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	minerAddr, err := address.NewFromString(rule.MinerId)
+	if err != nil {
+		return fail(err)
+	}
+
+	threshold, err := types.ParseFIL(rule.Threshold)
+	if err != nil {
+		return fail(err)
+	}
+
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	available, err := node.StateMinerAvailableBalance(ctx, minerAddr, head.Key())
+	if err != nil {
+		return fail(err)
+	}
+
+	if available.LessThanEqual(big.Int(threshold)) {
+		return nil
+	}
+	run.Available = available.String()
+
+	mi, err := node.StateMinerInfo(ctx, minerAddr, head.Key())
+	if err != nil {
+		return fail(err)
+	}
+
+	amount := available
+	if remaining := buildmessage.BeneficiaryRemaining(mi, head.Height()); remaining != nil {
+		amount = big.Min(amount, *remaining)
+	}
+	if rule.Amount != "" {
+		want, err := types.ParseFIL(rule.Amount)
+		if err != nil {
+			return fail(err)
+		}
+		amount = big.Min(amount, big.Int(want))
+	}
+
+	senderAddr := mi.Beneficiary
+	if rule.Sender == buildmessage.WithdrawByOwner {
+		senderAddr = mi.Owner
+	}
+
+	act, err := node.StateGetActor(ctx, senderAddr, head.Key())
+	if err != nil {
+		return fail(err)
+	}
+
+	proposal := builtin.IsMultisigActor(act.Code)
+	if !proposal {
+		keyAddr, err := node.StateAccountKey(ctx, senderAddr, head.Key())
+		if err != nil {
+			return fail(err)
+		}
+
+		if !w.signer.HasSigner(keyAddr.String()) {
+			return fail(fmt.Errorf("the wallet does not hold the key of %s", keyAddr))
+		}
+
+		amount = policy.cap(amount)
+	}
+	run.Amount = amount.String()
+
+	if amount.IsZero() {
+		run.Action = datastore.AutomationSkipped
+		run.Detail = "nothing left to withdraw under the spending policy and the beneficiary quota"
+		return run
+	}
+
+	// an amount of 0 would withdraw all that can be withdrawn, the amount is never 0 here
+	baseParams := buildmessage.BaseParams{FeePreset: rule.FeePreset}
+	msg, msgParams, err := buildmessage.NewWithdrawMessage(node, baseParams, rule.MinerId, types.FIL(amount).String(), rule.Sender, rule.From)
+	if err != nil {
+		return fail(err)
+	}
+	run.From = msg.From.String()
+	run.Nonce = msg.Nonce
+
+	myMsg, err := w.encodeBuilt(baseParams, msg, msgParams)
+	if err != nil {
+		return fail(err)
+	}
+
+	if proposal {
+		// the proposal is signed and pushed out of the wallet, it does not hold the nonce
+		w.releaseNonce(baseParams, msg)
+
+		data, err := json.Marshal(myMsg)
+		if err != nil {
+			return fail(err)
+		}
+
+		run.Action = datastore.AutomationProposal
+		run.Message = string(data)
+		return run
+	}
+
+	if err := w.pushAutomated(ctx, node, msg, myMsg, run); err != nil {
+		return fail(err)
+	}
+	policy.spend(amount)
+
+	return run
+}
+
+func newAutomationRun(rule datastore.AutomationRule) *datastore.AutomationRun {
+	return &datastore.AutomationRun{
+		RuleID:  rule.ID,
+		Kind:    automationKind(rule.Kind),
+		MinerId: rule.MinerId,
+		Time:    time.Now().UnixNano(),
+	}
+}
+
+// pushAutomated signs and pushes msg of run, the message is tracked in the tx history like the ones sent by users.
+// The nonce of a message that could not be signed or pushed is released, the automated messages never set one
+func (w *Wallet) pushAutomated(ctx context.Context, node api.FullNode, msg *types.Message, myMsg *chain.Message, run *datastore.AutomationRun) error {
+	signedMsg, err := w.signer.SignMsg(msg)
+	if err != nil {
+		w.releaseNonce(buildmessage.BaseParams{}, msg)
+		return err
+	}
+
+	w.nonces.recordSigned(msg)
+
+	cid, err := node.MpoolPush(ctx, signedMsg)
+	if err != nil {
+		w.releaseNonce(buildmessage.BaseParams{}, msg)
+		return err
+	}
+
+	w.txTracker.trackTx(&datastore.History{
+		Version:        signedMsg.Message.Version,
+		To:             signedMsg.Message.To.String(),
+		From:           signedMsg.Message.From.String(),
+		Nonce:          signedMsg.Message.Nonce,
+		Value:          signedMsg.Message.Value.Int64(),
+		GasLimit:       signedMsg.Message.GasLimit,
+		GasFeeCap:      signedMsg.Message.GasFeeCap.Int64(),
+		GasPremium:     signedMsg.Message.GasPremium.Int64(),
+		AttoValue:      signedMsg.Message.Value.String(),
+		AttoGasFeeCap:  signedMsg.Message.GasFeeCap.String(),
+		AttoGasPremium: signedMsg.Message.GasPremium.String(),
+		Method:         uint64(signedMsg.Message.Method),
+		Params:         myMsg.Params.Params,
+		ParamName:      myMsg.Params.Name,
+		TxCid:          cid.String(),
+		TxState:        datastore.Pending,
+	})
+
+	run.Action = datastore.AutomationPushed
+	run.TxCid = cid.String()
+	return nil
+}
+
+// AutomationRules Get
+func (w *Wallet) AutomationRules(c *gin.Context) {
+	rules, err := w.db.AutomationRuleList()
+	if err != nil {
+		log.Warnw("AutomationRules: AutomationRuleList", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	user := w.requestUser(c)
+	infos := make([]client.AutomationRule, 0, len(rules))
+	for _, rule := range rules {
+		if !userAllowsAddress(user, rule.MinerId) {
+			continue
+		}
+		infos = append(infos, automationRuleResponse(rule))
+	}
+
+	ReturnOk(c, infos)
+}
+
+// AutomationRuleSet Post, a rule signs withdrawals without asking so the route needs the sign permission
+func (w *Wallet) AutomationRuleSet(c *gin.Context) {
+	param := client.AutomationRule{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("AutomationRuleSet: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	rule, err := parseAutomationRule(param)
+	if err != nil {
+		log.Warnw("AutomationRuleSet: parseAutomationRule", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	has, err := w.db.HasAutomationRule(rule.ID)
+	if err != nil {
+		log.Warnw("AutomationRuleSet: HasAutomationRule", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	rule.CreatedAt = time.Now().Unix()
+	if has {
+		old, err := w.db.GetAutomationRule(rule.ID)
+		if err != nil {
+			log.Warnw("AutomationRuleSet: GetAutomationRule", "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+		if !userAllowsAddress(w.requestUser(c), old.MinerId) {
+			ReturnError(c, NewError(505, fmt.Sprintf("not allowed to use %s", old.MinerId)))
+			return
+		}
+		rule.CreatedAt = old.CreatedAt
+		rule.LastRun = old.LastRun
+	}
+
+	err = w.db.SetAutomationRule(rule)
+	if err != nil {
+		log.Warnw("AutomationRuleSet: SetAutomationRule", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, automationRuleResponse(*rule))
+}
+
+// AutomationRuleRemove Post
+func (w *Wallet) AutomationRuleRemove(c *gin.Context) {
+	param := client.AutomationRuleRemoveRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("AutomationRuleRemove: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	rule, err := w.db.GetAutomationRule(param.ID)
+	if err != nil {
+		log.Warnw("AutomationRuleRemove: GetAutomationRule", "id", param.ID, "err", err)
+		ReturnError(c, NewError(500, "rule does not exist"))
+		return
+	}
+
+	if !userAllowsAddress(w.requestUser(c), rule.MinerId) {
+		ReturnError(c, NewError(505, fmt.Sprintf("not allowed to use %s", rule.MinerId)))
+		return
+	}
+
+	err = w.db.DeleteAutomationRule(param.ID)
+	if err != nil {
+		log.Warnw("AutomationRuleRemove: DeleteAutomationRule", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, nil)
+}
+
+// AutomationRuns Get
+func (w *Wallet) AutomationRuns(c *gin.Context) {
+	limit, err := queryInt(c, "limit")
+	if err != nil || limit < 0 {
+		log.Warnw("AutomationRuns: queryInt", "limit", c.Query("limit"), "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	runs, err := w.db.AutomationRunList()
+	if err != nil {
+		log.Warnw("AutomationRuns: AutomationRunList", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	// newest first
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Time > runs[j].Time
+	})
+
+	user := w.requestUser(c)
+	ruleID := c.Query("rule_id")
+	infos := make([]client.AutomationRun, 0, len(runs))
+	for _, run := range runs {
+		if ruleID != "" && run.RuleID != ruleID {
+			continue
+		}
+		if !userAllowsAddress(user, run.MinerId) {
+			continue
+		}
+		if limit != 0 && len(infos) == int(limit) {
+			break
+		}
+
+		info, err := automationRunResponse(run)
+		if err != nil {
+			log.Warnw("AutomationRuns: automationRunResponse", "rule", run.RuleID, "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+		infos = append(infos, info)
+	}
+
+	ReturnOk(c, infos)
+}
+
+// parseAutomationRule checks the rule, the id defaults to <kind>-<miner id>, the interval to 24h for
+// withdrawals and 1h for top-ups
+func parseAutomationRule(param client.AutomationRule) (*datastore.AutomationRule, error) {
+	kind := automationKind(datastore.AutomationKind(param.Kind))
+	rule := &datastore.AutomationRule{
+		ID:        param.ID,
+		Kind:      kind,
+		Threshold: param.Threshold,
+		FeePreset: param.FeePreset,
+		Enable:    param.Enable,
+	}
+
+	minerAddr, err := address.NewFromString(param.MinerId)
+	if err != nil {
+		return nil, fmt.Errorf("miner_id: %w", err)
+	}
+	rule.MinerId = minerAddr.String()
+
+	threshold, err := types.ParseFIL(param.Threshold)
+	if err != nil {
+		return nil, fmt.Errorf("threshold: %w", err)
+	}
+
+	if param.FeePreset != "" {
+		if _, err := buildmessage.ParseFeePreset(param.FeePreset); err != nil {
+			return nil, err
+		}
+	}
+
+	interval := defaultWithdrawInterval
+	switch kind {
+	case datastore.AutomationWithdraw:
+		if param.Amount != "" {
+			amount, err := types.ParseFIL(param.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("amount: %w", err)
+			}
+			if big.Int(amount).LessThanEqual(big.Zero()) {
+				return nil, errors.New("amount must be positive, leave it empty to withdraw all")
+			}
+		}
+
+		switch param.Sender {
+		case buildmessage.WithdrawByOwner, buildmessage.WithdrawByBeneficiary, "":
+		default:
+			return nil, fmt.Errorf("unknown sender: %s, must be %s or %s", param.Sender, buildmessage.WithdrawByOwner, buildmessage.WithdrawByBeneficiary)
+		}
+
+		if param.From != "" {
+			if _, err := address.NewFromString(param.From); err != nil {
+				return nil, fmt.Errorf("from: %w", err)
+			}
+		}
+
+		rule.Amount = param.Amount
+		rule.Sender = param.Sender
+		rule.From = param.From
+	case datastore.AutomationTopUp:
+		if _, err := address.NewFromString(param.Funder); err != nil {
+			return nil, fmt.Errorf("funder: %w", err)
+		}
+
+		target, err := types.ParseFIL(param.Target)
+		if err != nil {
+			return nil, fmt.Errorf("target: %w", err)
+		}
+		if big.Int(target).LessThanEqual(big.Int(threshold)) {
+			return nil, errors.New("target must be above the threshold")
+		}
+
+		if param.FunderLow != "" {
+			if _, err := types.ParseFIL(param.FunderLow); err != nil {
+				return nil, fmt.Errorf("funder_low: %w", err)
+			}
+		}
+
+		rule.Funder = param.Funder
+		rule.Target = param.Target
+		rule.FunderLow = param.FunderLow
+		interval = defaultTopUpInterval
+	default:
+		return nil, fmt.Errorf("unknown kind: %s, must be %s or %s", kind, datastore.AutomationWithdraw, datastore.AutomationTopUp)
+	}
+
+	if param.Interval != "" {
+		interval, err = time.ParseDuration(param.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("interval: %w", err)
+		}
+		if interval < time.Minute {
+			return nil, errors.New("interval must be at least 1m")
+		}
+	}
+	rule.Interval = int64(interval / time.Second)
+
+	if rule.ID == "" {
+		rule.ID = string(kind) + "-" + rule.MinerId
+	}
+
+	return rule, nil
+}
+
+func automationRuleResponse(rule datastore.AutomationRule) client.AutomationRule {
+	return client.AutomationRule{
+		ID:        rule.ID,
+		Kind:      string(automationKind(rule.Kind)),
+		MinerId:   rule.MinerId,
+		Threshold: rule.Threshold,
+		Amount:    rule.Amount,
+		Sender:    rule.Sender,
+		From:      rule.From,
+		FeePreset: rule.FeePreset,
+		Funder:    rule.Funder,
+		Target:    rule.Target,
+		FunderLow: rule.FunderLow,
+		Interval:  (time.Duration(rule.Interval) * time.Second).String(),
+		Enable:    rule.Enable,
+		CreatedAt: rule.CreatedAt,
+		LastRun:   rule.LastRun,
+	}
+}
+
+func automationRunResponse(run datastore.AutomationRun) (client.AutomationRun, error) {
+	info := client.AutomationRun{
+		RuleID:    run.RuleID,
+		Kind:      string(automationKind(run.Kind)),
+		MinerId:   run.MinerId,
+		Time:      time.Unix(0, run.Time).Unix(),
+		Action:    string(run.Action),
+		Available: run.Available,
+		Amount:    run.Amount,
+		From:      run.From,
+		To:        run.To,
+		Nonce:     run.Nonce,
+		TxCid:     run.TxCid,
+		Detail:    run.Detail,
+	}
+
+	if run.Message != "" {
+		info.Message = &chain.Message{}
+		if err := json.Unmarshal([]byte(run.Message), info.Message); err != nil {
+			return client.AutomationRun{}, err
+		}
+	}
+
+	return info, nil
+}
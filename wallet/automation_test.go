@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/messagesigner"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSpendingPolicy(t *testing.T) {
+	fil := func(s string) big.Int {
+		v, err := types.ParseFIL(s)
+		require.NoError(t, err)
+		return big.Int(v)
+	}
+
+	now := time.Now()
+	runs := []datastore.AutomationRun{
+		{Action: datastore.AutomationPushed, Time: now.Add(-time.Hour).UnixNano(), Amount: fil("30").String()},
+		// proposals, failed runs and runs older than a day do not count
+		{Action: datastore.AutomationProposal, Time: now.Add(-time.Hour).UnixNano(), Amount: fil("30").String()},
+		{Action: datastore.AutomationFailed, Time: now.Add(-time.Hour).UnixNano(), Amount: fil("30").String()},
+		{Action: datastore.AutomationPushed, Time: now.Add(-25 * time.Hour).UnixNano(), Amount: fil("30").String()},
+		// top-ups count for the top-up limit only
+		{Kind: datastore.AutomationTopUp, Action: datastore.AutomationPushed, Time: now.Add(-time.Hour).UnixNano(), Amount: fil("4").String()},
+	}
+
+	cfg := config.Automation{MaxAmount: "50", DailyLimit: "100 FIL", TopUpDailyLimit: "5"}
+	policy, err := newSpendingPolicy(cfg, datastore.AutomationWithdraw, runs, now)
+	require.NoError(t, err)
+	require.Equal(t, fil("50"), policy.cap(fil("80")))
+	require.Equal(t, fil("20"), policy.cap(fil("20")))
+
+	policy.spend(fil("50"))
+	require.Equal(t, fil("20"), policy.cap(fil("80")))
+
+	policy.spend(fil("50"))
+	require.Equal(t, big.Zero(), policy.cap(fil("80")))
+
+	topUp, err := newSpendingPolicy(cfg, datastore.AutomationTopUp, runs, now)
+	require.NoError(t, err)
+	require.Equal(t, fil("1"), topUp.cap(fil("3")))
+
+	unbounded, err := newSpendingPolicy(config.Automation{}, datastore.AutomationWithdraw, runs, now)
+	require.NoError(t, err)
+	require.Equal(t, fil("1000"), unbounded.cap(fil("1000")))
+
+	_, err = newSpendingPolicy(config.Automation{TopUpMaxAmount: "lots"}, datastore.AutomationTopUp, nil, now)
+	require.Error(t, err)
+}
+
+func TestTopUpAddresses(t *testing.T) {
+	worker, _ := address.NewIDAddress(100)
+	control, _ := address.NewIDAddress(101)
+
+	addrs := topUpAddresses(api.MinerInfo{Worker: worker, ControlAddresses: []address.Address{control, worker, control}})
+	require.Equal(t, []address.Address{worker, control}, addrs)
+}
+
+type stubSigner struct {
+	messagesigner.Signer
+}
+
+func (stubSigner) SignMsg(msg *types.Message) (*types.SignedMessage, error) {
+	return &types.SignedMessage{Message: *msg}, nil
+}
+
+type failingPushNode struct {
+	mpoolNode
+}
+
+func (n *failingPushNode) MpoolPush(ctx context.Context, msg *types.SignedMessage) (cid.Cid, error) {
+	return cid.Undef, errors.New("mpool push failed")
+}
+
+func TestPushAutomatedReleasesNonce(t *testing.T) {
+	db := datastore.NewWalletDB(dssync.MutexWrap(ds.NewMapDatastore()))
+	w := &Wallet{
+		db:     db,
+		nonces: newNonceManager(db, func() time.Duration { return time.Hour }),
+		signer: stubSigner{},
+	}
+	node := &failingPushNode{mpoolNode{nonce: 5}}
+	from, _ := address.NewIDAddress(1000)
+
+	nonce, err := w.nonces.reserve(context.Background(), node, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+
+	msg := &types.Message{From: from, To: from, Nonce: nonce, Value: types.NewInt(1)}
+	run := &datastore.AutomationRun{}
+	require.Error(t, w.pushAutomated(context.Background(), node, msg, &chain.Message{}, run))
+
+	// the next message takes the nonce the failed push did not use
+	nonce, err = w.nonces.reserve(context.Background(), node, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+}
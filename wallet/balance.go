@@ -6,7 +6,6 @@ import (
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/gin-gonic/gin"
-	"time"
 )
 
 func (w *Wallet) Balance(c *gin.Context) {
@@ -25,8 +24,8 @@ func (w *Wallet) Balance(c *gin.Context) {
 		return
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*10)
-	amount, err := w.node.Api.WalletBalance(timeoutCtx, addr)
+	timeoutCtx, cancel := context.WithTimeout(ctx, w.requestTimeout())
+	amount, err := w.node().Api.WalletBalance(timeoutCtx, addr)
 	cancel()
 	if err != nil {
 		log.Warnw("Balance: WalletBalance", "err", err.Error())
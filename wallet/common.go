@@ -27,7 +27,11 @@ func ReturnOk(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, data)
 }
 
+// responseCodeKey keeps the code of an error response for the request metrics
+const responseCodeKey = "responseCode"
+
 func ReturnError(c *gin.Context, res *client.Response) {
+	c.Set(responseCodeKey, res.Code)
 	c.JSON(http.StatusOK, res)
 }
 
@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"time"
+)
+
+func (w *Wallet) config() *config.Config {
+	w.cfgLk.RLock()
+	defer w.cfgLk.RUnlock()
+	return w.cfg
+}
+
+// ReloadConfig applies the settings that can safely change at runtime,
+// the others only take effect after a restart, an invalid config is not applied
+func (w *Wallet) ReloadConfig(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	w.cfgLk.Lock()
+	old := w.cfg
+	reloaded := *old
+	reloaded.Wallet = cfg.Wallet
+	reloaded.Node = cfg.Node
+	reloaded.Tracker.PollInterval = cfg.Tracker.PollInterval
+	reloaded.Indexer = cfg.Indexer
+	reloaded.Events = cfg.Events
+	reloaded.Metrics = cfg.Metrics
+	reloaded.Automation = cfg.Automation
+	reloaded.Workflow = cfg.Workflow
+	w.cfg = &reloaded
+	w.cfgLk.Unlock()
+
+	if cfg.API != old.API || cfg.Tracker.ReceiverBuffer != old.Tracker.ReceiverBuffer {
+		log.Warn("ReloadConfig: API and Tracker.ReceiverBuffer changes take effect after restart")
+	}
+
+	log.Infow("ReloadConfig: config reloaded",
+		"lockDuration", reloaded.Wallet.LockDuration.Duration().String(),
+		"requestTimeout", reloaded.Node.RequestTimeout.Duration().String(),
+		"pollInterval", reloaded.Tracker.PollInterval.Duration().String(),
+		"defaultNode", reloaded.Node.DefaultName)
+
+	return nil
+}
+
+func (w *Wallet) lockDuration() time.Duration {
+	return w.config().Wallet.LockDuration.Duration()
+}
+
+func (w *Wallet) nonceReservation() time.Duration {
+	return w.config().Wallet.NonceReservation.Duration()
+}
+
+func (w *Wallet) requestTimeout() time.Duration {
+	return w.config().Node.RequestTimeout.Duration()
+}
+
+func (w *Wallet) pollInterval() time.Duration {
+	return w.config().Tracker.PollInterval.Duration()
+}
+
+func (w *Wallet) healthInterval() time.Duration {
+	return w.config().Node.HealthInterval.Duration()
+}
+
+func (w *Wallet) maxHeadLag() int64 {
+	return int64(w.config().Node.MaxHeadLag)
+}
+
+func (w *Wallet) defaultNodeInfo() *datastore.NodeInfo {
+	cfg := w.config()
+	return &datastore.NodeInfo{
+		Name:     cfg.Node.DefaultName,
+		Endpoint: cfg.Node.DefaultEndpoint,
+		Token:    cfg.Node.DefaultToken,
+	}
+}
+
+func (w *Wallet) quorum() (int, []string) {
+	cfg := w.config()
+	return cfg.Node.Quorum, cfg.Node.QuorumNodes
+}
+
+func (w *Wallet) indexerConfig() config.Indexer {
+	return w.config().Indexer
+}
+
+func (w *Wallet) eventsConfig() config.Events {
+	return w.config().Events
+}
+
+func (w *Wallet) metricsConfig() config.Metrics {
+	return w.config().Metrics
+}
+
+func (w *Wallet) automationConfig() config.Automation {
+	return w.config().Automation
+}
+
+func (w *Wallet) workflowConfig() config.Workflow {
+	return w.config().Workflow
+}
+
+func (w *Wallet) webhookConfig() events.WebhookConfig {
+	cfg := w.eventsConfig()
+	return events.WebhookConfig{
+		URLs:    cfg.Webhooks,
+		Secret:  cfg.WebhookSecret,
+		Retries: cfg.WebhookRetries,
+	}
+}
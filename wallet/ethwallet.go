@@ -8,7 +8,6 @@ import (
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/filecoin-project/lotus/chain/types/ethtypes"
 	"github.com/gin-gonic/gin"
-	"time"
 )
 
 // EthWalletCreate Post
@@ -72,8 +71,9 @@ func (w *Wallet) EthWalletList(c *gin.Context) {
 		return
 	}
 
+	user := w.requestUser(c)
 	data := make([]client.WalletListInfo, 0)
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
 	defer cancel()
 
 	for _, wallet := range walletList {
@@ -92,8 +92,12 @@ func (w *Wallet) EthWalletList(c *gin.Context) {
 			return
 		}
 
+		if !userAllowsAddress(user, wallet.Address) && !userAllowsAddress(user, f4Addr.String()) {
+			continue
+		}
+
 		if isBalance {
-			amount, err = w.node.Api.WalletBalance(timeoutCtx, f4Addr)
+			amount, err = w.node().Api.WalletBalance(timeoutCtx, f4Addr)
 			if err != nil {
 				log.Warnw("Balance: WalletBalance", "err", err.Error())
 				ReturnError(c, NewError(500, err.Error()))
@@ -102,7 +106,7 @@ func (w *Wallet) EthWalletList(c *gin.Context) {
 		}
 
 		walletId := ""
-		id, err := w.node.Api.StateLookupID(timeoutCtx, f4Addr, types.EmptyTSK)
+		id, err := w.node().Api.StateLookupID(timeoutCtx, f4Addr, types.EmptyTSK)
 		if err != nil {
 			log.Infow("StateLookupID", "err", err.Error())
 			walletId = "NotFound"
@@ -147,8 +151,8 @@ func (w *Wallet) EthBalance(c *gin.Context) {
 		return
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second*10)
-	amount, err := w.node.Api.WalletBalance(timeoutCtx, f4Addr)
+	timeoutCtx, cancel := context.WithTimeout(ctx, w.requestTimeout())
+	amount, err := w.node().Api.WalletBalance(timeoutCtx, f4Addr)
 	cancel()
 	if err != nil {
 		log.Warnw("Balance: WalletBalance", "err", err.Error())
@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// eventBuffer is how many events a slow /events client may fall behind before missing some
+	eventBuffer = 64
+	// eventKeepAlive keeps proxies from closing an idle stream
+	eventKeepAlive = 15 * time.Second
+)
+
+// Events Get
+// Events streams the events as Server-Sent Events, the types query keeps only the listed types,
+// e.g. types=tx.success,tx.failed, a reconnecting client gets the events after Last-Event-ID replayed.
+// A scoped user only gets the events of the addresses, miners and msigs of its scope
+func (w *Wallet) Events(c *gin.Context) {
+	user := w.requestUser(c)
+	filter := make(map[events.Type]struct{})
+	for _, typ := range strings.Split(c.Query("types"), ",") {
+		if typ = strings.TrimSpace(typ); typ != "" {
+			filter[events.Type(typ)] = struct{}{}
+		}
+	}
+
+	var lastID uint64
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		var err error
+		lastID, err = strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			log.Warnw("Events: ParseUint", "lastEventId", id, "err", err)
+			ReturnError(c, ParamErr)
+			return
+		}
+	}
+
+	ch, replay, cancel := w.events.Subscribe(eventBuffer, lastID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	write := func(evt events.Event) error {
+		if _, ok := filter[evt.Type]; len(filter) != 0 && !ok {
+			return nil
+		}
+		if !eventAllowed(user, evt) {
+			return nil
+		}
+
+		data, err := json.Marshal(evt)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", evt.ID, evt.Type, data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	for _, evt := range replay {
+		if err := write(evt); err != nil {
+			log.Debugw("Events: write", "err", err)
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case evt := <-ch:
+			if err := write(evt); err != nil {
+				log.Debugw("Events: write", "err", err)
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// eventAllowed tells whether evt is about an address in the scope of user, the events
+// that name no address are only streamed to unscoped users
+func eventAllowed(user *datastore.User, evt events.Event) bool {
+	if user == nil || (len(user.Addresses) == 0 && len(user.Msigs) == 0) {
+		return true
+	}
+
+	switch data := evt.Data.(type) {
+	case client.HistoryResponse:
+		return userAllowsAddress(user, data.Address)
+	case client.MsigProposalEvent:
+		return userAllowsMsig(user, data.MsigAddress)
+	case client.MinerRoleEvent:
+		return userAllowsAddress(user, data.MinerId)
+	case client.BalanceEvent:
+		return userAllowsAddress(user, data.Address)
+	case client.Workflow:
+		return userAllowsAddress(user, data.MinerId)
+	}
+
+	return false
+}
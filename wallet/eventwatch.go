@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/api"
+	lotusbuiltin "github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"sync"
+	"time"
+)
+
+// eventWatcher polls the chain state the messages do not tell about: new msig proposals,
+// the roles of watched miners and the balances of owned wallets. The first poll of an
+// address only records its state, later polls publish the changes
+type eventWatcher struct {
+	db     datastore.WalletDB
+	events *events.Bus
+
+	lk sync.Mutex
+	// pending keeps the pending txn ids of each msig, true for the txns changing its signers or threshold
+	pending map[string]map[int64]bool
+	// roles keeps the owner, worker and beneficiary of each miner
+	roles map[string]minerRoles
+	// low keeps the wallets already reported below the threshold, they are reported again after recovering
+	low map[string]struct{}
+}
+
+type minerRoles struct {
+	owner       string
+	worker      string
+	beneficiary string
+}
+
+func newEventWatcher(db datastore.WalletDB, bus *events.Bus) *eventWatcher {
+	return &eventWatcher{
+		db:      db,
+		events:  bus,
+		pending: make(map[string]map[int64]bool),
+		roles:   make(map[string]minerRoles),
+		low:     make(map[string]struct{}),
+	}
+}
+
+func (w *Wallet) eventWatchLoop(close <-chan struct{}) {
+	interval := func() time.Duration {
+		return w.eventsConfig().WatchInterval.Duration()
+	}
+
+	runEvery(interval, func() {
+		n := w.node()
+		if w.offline || n == nil {
+			return
+		}
+
+		w.eventWatcher.watch(n.Api, w.eventsConfig())
+	}, close)
+}
+
+// watch runs every check, a failing address is logged and skipped
+func (ew *eventWatcher) watch(node api.FullNode, cfg config.Events) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ew.lk.Lock()
+	defer ew.lk.Unlock()
+
+	ew.watchMsigs(ctx, node)
+	ew.watchMiners(ctx, node)
+
+	if cfg.LowBalance != "" {
+		threshold, err := types.ParseFIL(cfg.LowBalance)
+		if err != nil {
+			log.Warnw("eventWatcher: ParseFIL", "lowBalance", cfg.LowBalance, "err", err)
+			return
+		}
+		ew.watchBalances(ctx, node, big.Int(threshold))
+	}
+}
+
+func (ew *eventWatcher) watchMsigs(ctx context.Context, node api.FullNode) {
+	msigs, err := ew.db.MsigWalletList()
+	if err != nil {
+		log.Warnw("eventWatcher: MsigWalletList", "err", err)
+		return
+	}
+
+	for _, msig := range msigs {
+		msigStr := msig.MsigAddr
+		msigAddr, err := address.NewFromString(msigStr)
+		if err != nil {
+			continue
+		}
+
+		txns, err := node.MsigGetPending(ctx, msigAddr, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("eventWatcher: MsigGetPending", "msig", msigStr, "err", err)
+			continue
+		}
+
+		known, seeded := ew.pending[msigStr]
+		current := make(map[int64]bool, len(txns))
+		for _, txn := range txns {
+			current[txn.ID] = changesSigners(ctx, node, msigAddr, txn)
+			if _, ok := known[txn.ID]; !seeded || ok {
+				continue
+			}
+
+			methodName, err := buildmessage.MethodName(ctx, node, txn.To, txn.Method, types.EmptyTSK)
+			if err != nil {
+				log.Debugw("eventWatcher: MethodName", "msig", msigStr, "txid", txn.ID, "err", err)
+			}
+
+			ew.events.Publish(events.MsigProposal, client.MsigProposalEvent{
+				MsigAddress: msigStr,
+				Txid:        txn.ID,
+				To:          txn.To.String(),
+				Value:       types.FIL(txn.Value).String(),
+				Method:      uint64(txn.Method),
+				MethodName:  methodName,
+				Approved:    addr2Str(txn.Approved),
+			})
+		}
+		ew.pending[msigStr] = current
+
+		// a signer change that left the pending txns was applied or cancelled, by this wallet or by another one
+		for id, signerChange := range known {
+			if _, ok := current[id]; ok || !signerChange {
+				continue
+			}
+
+			msigWallet, err := refreshMsigWallet(ctx, node, ew.db, &msig)
+			if err != nil {
+				log.Warnw("eventWatcher: refreshMsigWallet", "msig", msigStr, "txid", id, "err", err)
+				break
+			}
+
+			log.Infow("eventWatcher: msig refreshed", "msig", msigStr, "txid", id, "signers", msigWallet.Signers, "threshold", msigWallet.NumApprovalsThreshold)
+			break
+		}
+	}
+}
+
+// changesSigners reports whether txn, pending on the msig at msigAddr, adds, removes or swaps
+// a signer of the msig or changes its threshold
+func changesSigners(ctx context.Context, node api.FullNode, msigAddr address.Address, txn *api.MsigTransaction) bool {
+	switch txn.Method {
+	case builtin.MethodsMultisig.AddSigner, builtin.MethodsMultisig.RemoveSigner,
+		builtin.MethodsMultisig.SwapSigner, builtin.MethodsMultisig.ChangeNumApprovalsThreshold:
+	default:
+		return false
+	}
+
+	self, err := sameActor(ctx, node, txn.To, msigAddr)
+	if err != nil {
+		log.Debugw("eventWatcher: sameActor", "msig", msigAddr, "txid", txn.ID, "err", err)
+	}
+
+	return self
+}
+
+func (ew *eventWatcher) watchMiners(ctx context.Context, node api.FullNode) {
+	watches, err := ew.db.WatchList()
+	if err != nil {
+		log.Warnw("eventWatcher: WatchList", "err", err)
+		return
+	}
+
+	for _, watch := range watches {
+		addrStr := watch.Address
+		addr, err := address.NewFromString(addrStr)
+		if err != nil {
+			continue
+		}
+
+		act, err := node.StateGetActor(ctx, addr, types.EmptyTSK)
+		if err != nil || !lotusbuiltin.IsStorageMinerActor(act.Code) {
+			continue
+		}
+
+		mi, err := node.StateMinerInfo(ctx, addr, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("eventWatcher: StateMinerInfo", "miner", addrStr, "err", err)
+			continue
+		}
+
+		current := minerRoles{
+			owner:       mi.Owner.String(),
+			worker:      mi.Worker.String(),
+			beneficiary: mi.Beneficiary.String(),
+		}
+
+		if old, ok := ew.roles[addrStr]; ok {
+			publish := func(typ events.Type, role, old, new string) {
+				if old == new {
+					return
+				}
+				ew.events.Publish(typ, client.MinerRoleEvent{
+					MinerId: addrStr,
+					Role:    role,
+					Old:     old,
+					New:     new,
+				})
+			}
+			publish(events.MinerOwnerChanged, "owner", old.owner, current.owner)
+			publish(events.MinerWorkerChanged, "worker", old.worker, current.worker)
+			publish(events.MinerBeneficiaryChanged, "beneficiary", old.beneficiary, current.beneficiary)
+		}
+		ew.roles[addrStr] = current
+	}
+}
+
+func (ew *eventWatcher) watchBalances(ctx context.Context, node api.FullNode, threshold big.Int) {
+	wallets, err := ew.db.WalletList()
+	if err != nil {
+		log.Warnw("eventWatcher: WalletList", "err", err)
+		return
+	}
+
+	for _, wallet := range wallets {
+		addrStr := wallet.Address
+		addr, err := address.NewFromString(addrStr)
+		if err != nil {
+			continue
+		}
+
+		balance, err := node.WalletBalance(ctx, addr)
+		if err != nil {
+			log.Warnw("eventWatcher: WalletBalance", "address", addrStr, "err", err)
+			continue
+		}
+
+		if balance.GreaterThanEqual(threshold) {
+			delete(ew.low, addrStr)
+			continue
+		}
+
+		if _, ok := ew.low[addrStr]; ok {
+			continue
+		}
+		ew.low[addrStr] = struct{}{}
+
+		ew.events.Publish(events.BalanceLow, client.BalanceEvent{
+			Address:   addrStr,
+			Balance:   types.FIL(balance).String(),
+			Threshold: types.FIL(threshold).String(),
+		})
+	}
+}
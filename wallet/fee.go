@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+)
+
+// FeeEstimate Post
+func (w *Wallet) FeeEstimate(c *gin.Context) {
+	param := client.FeeEstimateRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("FeeEstimate: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	msg, err := feeEstimateMessage(param)
+	if err != nil {
+		log.Warnw("FeeEstimate: feeEstimateMessage", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	baseFees, err := buildmessage.RecentBaseFees(ctx, w.node().Api)
+	if err != nil {
+		log.Warnw("FeeEstimate: RecentBaseFees", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	info := client.FeeEstimateInfo{
+		BaseFee:       baseFees.Current.String(),
+		BaseFeeMin:    baseFees.Min.String(),
+		BaseFeeMedian: baseFees.Median.String(),
+		BaseFeeMax:    baseFees.Max.String(),
+	}
+
+	for _, preset := range buildmessage.FeePresets {
+		fee, err := buildmessage.EstimateFee(ctx, w.node().Api, msg, preset, baseFees)
+		if err != nil {
+			log.Warnw("FeeEstimate: EstimateFee", "preset", preset, "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+
+		info.Presets = append(info.Presets, client.FeePresetEstimate{
+			Preset:       string(fee.Preset),
+			GasLimit:     fee.GasLimit,
+			GasFeeCap:    fee.GasFeeCap.String(),
+			GasPremium:   fee.GasPremium.String(),
+			WorstCaseFee: types.FIL(fee.WorstCaseFee).String(),
+			ExpectedBurn: types.FIL(fee.ExpectedBurn).String(),
+			ExpectedFee:  types.FIL(fee.ExpectedFee).String(),
+		})
+	}
+
+	ReturnOk(c, info)
+}
+
+// feeEstimateMessage builds the message the fees are estimated for,
+// only From is needed when the gas limit is given
+func feeEstimateMessage(param client.FeeEstimateRequest) (*types.Message, error) {
+	from, err := address.NewFromString(param.From)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &types.Message{
+		From:     from,
+		To:       from,
+		Value:    types.NewInt(0),
+		Method:   abi.MethodNum(param.Method),
+		GasLimit: param.GasLimit,
+	}
+
+	if param.To != "" {
+		msg.To, err = address.NewFromString(param.To)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if param.Value != "" {
+		value, err := types.ParseFIL(param.Value)
+		if err != nil {
+			return nil, err
+		}
+		msg.Value = abi.TokenAmount(value)
+	}
+
+	if param.Params != "" {
+		msg.Params, err = hex.DecodeString(param.Params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
@@ -1,45 +1,498 @@
 package wallet
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/gin-gonic/gin"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// historyRecord is a history record together with the address it was recorded for
+type historyRecord struct {
+	address string
+	datastore.History
+}
+
+// historyQuery filters, sorts and pages the history, zero values do not filter
+type historyQuery struct {
+	addresses    []string
+	methods      []string
+	states       []datastore.MsgState
+	directions   []datastore.Direction
+	counterparty string
+	minEpoch     int64
+	maxEpoch     int64
+	since        int64
+	until        int64
+	sortBy       string
+	desc         bool
+	limit        int
+	cursor       *historyCursor
+}
+
+// historyCursor is the sort key and id of the last record of a page, the next page starts after it
+type historyCursor struct {
+	key historyKey
+	id  string
+}
+
+// historyKey is the sort key of a record, the values are attoFIL amounts when sorting by value
+type historyKey struct {
+	value big.Int
+	// missing is set for the records without a key, like the epoch of a pending message
+	missing bool
+}
+
+const (
+	sortByEpoch = "epoch"
+	sortByTime  = "time"
+	sortByValue = "value"
+	sortByNonce = "nonce"
+)
+
+func parseHistoryQuery(c *gin.Context) (*historyQuery, error) {
+	q := &historyQuery{
+		sortBy: sortByEpoch,
+	}
+
+	for _, addr := range splitQuery(c.QueryArray("address")) {
+		a, err := address.NewFromString(addr)
+		if err != nil {
+			return nil, fmt.Errorf("address %s: %w", addr, err)
+		}
+		q.addresses = append(q.addresses, a.String())
+	}
+
+	q.methods = splitQuery(c.QueryArray("method"))
+
+	for _, state := range splitQuery(c.QueryArray("status")) {
+		switch s := datastore.MsgState(state); s {
+		case datastore.Pending, datastore.Success, datastore.Failed:
+			q.states = append(q.states, s)
+		default:
+			return nil, fmt.Errorf("unknown status %s", state)
+		}
+	}
+
+	for _, direction := range splitQuery(c.QueryArray("direction")) {
+		switch d := datastore.Direction(direction); d {
+		case datastore.Outgoing, datastore.Incoming, datastore.Self:
+			q.directions = append(q.directions, d)
+		default:
+			return nil, fmt.Errorf("unknown direction %s", direction)
+		}
+	}
+
+	if counterparty := c.Query("counterparty"); counterparty != "" {
+		a, err := address.NewFromString(counterparty)
+		if err != nil {
+			return nil, fmt.Errorf("counterparty: %w", err)
+		}
+		q.counterparty = a.String()
+	}
+
+	var err error
+	if q.minEpoch, err = queryInt(c, "min_epoch"); err != nil {
+		return nil, err
+	}
+	if q.maxEpoch, err = queryInt(c, "max_epoch"); err != nil {
+		return nil, err
+	}
+	if q.since, err = queryTime(c, "since"); err != nil {
+		return nil, err
+	}
+	if q.until, err = queryTime(c, "until"); err != nil {
+		return nil, err
+	}
+
+	limit, err := queryInt(c, "limit")
+	if err != nil || limit < 0 {
+		return nil, fmt.Errorf("limit must be a positive number")
+	}
+	q.limit = int(limit)
+
+	if sortBy := c.Query("sort"); sortBy != "" {
+		switch sortBy {
+		case sortByEpoch, sortByTime, sortByValue, sortByNonce:
+			q.sortBy = sortBy
+		default:
+			return nil, fmt.Errorf("unknown sort %s, must be one of: epoch, time, value, nonce", sortBy)
+		}
+	}
+
+	switch order := c.Query("order"); order {
+	case "", "asc":
+	case "desc":
+		q.desc = true
+	default:
+		return nil, fmt.Errorf("unknown order %s, must be asc or desc", order)
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		q.cursor, err = decodeHistoryCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return q, nil
+}
+
+// splitQuery accepts both repeated and comma separated query values
+func splitQuery(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
+
+func queryInt(c *gin.Context, key string) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+
+	return i, nil
+}
+
+// queryTime accepts unix seconds and RFC3339
+func queryTime(c *gin.Context, key string) (int64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be unix seconds or RFC3339: %w", key, err)
+	}
+
+	return t.Unix(), nil
+}
+
+func encodeHistoryCursor(cursor historyCursor) string {
+	var key string
+	if !cursor.key.missing {
+		key = cursor.key.value.String()
+	}
+
+	return base64.RawURLEncoding.EncodeToString([]byte(key + ":" + cursor.id))
+}
+
+func decodeHistoryCursor(s string) (*historyCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("invalid cursor")
+	}
+
+	if parts[0] == "" {
+		return &historyCursor{key: historyKey{missing: true}, id: parts[1]}, nil
+	}
+
+	value, err := big.FromString(parts[0])
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &historyCursor{key: historyKey{value: value}, id: parts[1]}, nil
+}
+
+// historyTime is when the message was included, or pushed while it is pending
+func historyTime(h *datastore.History) int64 {
+	if h.Timestamp != 0 {
+		return h.Timestamp
+	}
+
+	return h.SubmitTime
+}
+
+// id tells apart the records of a message sent between two queried addresses
+func (r *historyRecord) id() string {
+	return r.TxCid + "@" + r.address
+}
+
+// counterparty is the other side of the message for the address it was recorded for
+func (r *historyRecord) counterparty() string {
+	if r.Direction == datastore.Incoming {
+		return r.From
+	}
+
+	return r.To
+}
+
+// sortKey of the record, the records without a key come last in both orders
+func (q *historyQuery) sortKey(r *historyRecord) historyKey {
+	var key int64
+	switch q.sortBy {
+	case sortByTime:
+		key = historyTime(&r.History)
+	case sortByValue:
+		return historyKey{value: historyValue(&r.History)}
+	case sortByNonce:
+		return historyKey{value: big.NewIntUnsigned(r.Nonce)}
+	default:
+		key = r.Epoch
+	}
+
+	if key == 0 {
+		return historyKey{missing: true}
+	}
+
+	return historyKey{value: big.NewInt(key)}
+}
+
+// before reports whether a key comes before b key in the order of the query
+func (q *historyQuery) before(aKey historyKey, aID string, bKey historyKey, bID string) bool {
+	if aKey.missing != bKey.missing {
+		return bKey.missing
+	}
+
+	if aKey.missing || aKey.value.Equals(bKey.value) {
+		if q.desc {
+			return aID > bID
+		}
+		return aID < bID
+	}
+
+	if q.desc {
+		return aKey.value.GreaterThan(bKey.value)
+	}
+	return aKey.value.LessThan(bKey.value)
+}
+
+func (q *historyQuery) match(r *historyRecord) bool {
+	if len(q.methods) != 0 {
+		matched := false
+		for _, method := range q.methods {
+			if strings.EqualFold(method, r.MethodName) || method == strconv.FormatUint(r.Method, 10) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(q.states) != 0 && !containsState(q.states, r.TxState) {
+		return false
+	}
+
+	if len(q.directions) != 0 && !containsDirection(q.directions, r.Direction) {
+		return false
+	}
+
+	if q.counterparty != "" && r.counterparty() != q.counterparty {
+		return false
+	}
+
+	if (q.minEpoch != 0 || q.maxEpoch != 0) && r.Epoch == 0 {
+		return false
+	}
+	if q.minEpoch != 0 && r.Epoch < q.minEpoch {
+		return false
+	}
+	if q.maxEpoch != 0 && r.Epoch > q.maxEpoch {
+		return false
+	}
+
+	t := historyTime(&r.History)
+	if (q.since != 0 || q.until != 0) && t == 0 {
+		return false
+	}
+	if q.since != 0 && t < q.since {
+		return false
+	}
+	if q.until != 0 && t > q.until {
+		return false
+	}
+
+	return true
+}
+
+// apply filters and sorts the records and returns the page after the cursor with the cursor of the next page
+func (q *historyQuery) apply(records []historyRecord) ([]historyRecord, string) {
+	var matched []historyRecord
+	for i := range records {
+		if q.match(&records[i]) {
+			matched = append(matched, records[i])
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return q.before(q.sortKey(&matched[i]), matched[i].id(), q.sortKey(&matched[j]), matched[j].id())
+	})
+
+	if q.cursor != nil {
+		start := sort.Search(len(matched), func(i int) bool {
+			return q.before(q.cursor.key, q.cursor.id, q.sortKey(&matched[i]), matched[i].id())
+		})
+		matched = matched[start:]
+	}
+
+	if q.limit == 0 || len(matched) <= q.limit {
+		return matched, ""
+	}
+
+	page := matched[:q.limit]
+	last := &page[len(page)-1]
+	return page, encodeHistoryCursor(historyCursor{key: q.sortKey(last), id: last.id()})
+}
+
+func containsState(states []datastore.MsgState, state datastore.MsgState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDirection(directions []datastore.Direction, direction datastore.Direction) bool {
+	for _, d := range directions {
+		if d == direction {
+			return true
+		}
+	}
+	return false
+}
+
+// historyRecords reads the outgoing and incoming records of addrs
+func (w *Wallet) historyRecords(addrs []string) ([]historyRecord, error) {
+	var records []historyRecord
+	for _, addr := range addrs {
+		historys, err := w.db.HistoryList(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range historys {
+			// records written before the indexer are all pushed by this wallet
+			if h.Direction == "" {
+				h.Direction = datastore.Outgoing
+			}
+			records = append(records, historyRecord{address: addr, History: h})
+		}
+
+		incoming, err := w.db.IncomingList(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range incoming {
+			records = append(records, historyRecord{address: addr, History: h})
+		}
+	}
+
+	return records, nil
+}
+
 // TxHistory Get
 func (w *Wallet) TxHistory(c *gin.Context) {
-	addr := c.Query("address")
-	_, err := address.NewFromString(addr)
+	q, err := parseHistoryQuery(c)
 	if err != nil {
-		log.Warnw("TxHistory: NewFromString", "addr", addr, "err", err.Error())
-		ReturnError(c, ParamErr)
+		log.Warnw("TxHistory: parseHistoryQuery", "err", err.Error())
+		ReturnError(c, NewError(ParamErr.Code, err.Error()))
 		return
 	}
 
-	historys, err := w.db.HistoryList(addr)
+	// without addresses the history of every owned and watched address in the scope of the user is queried
+	addrs := q.addresses
+	if len(addrs) == 0 {
+		all, err := w.indexer.addresses()
+		if err != nil {
+			log.Warnw("TxHistory: addresses", "err", err.Error())
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+
+		user := w.requestUser(c)
+		for _, addr := range all {
+			if userAllowsAddress(user, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+
+	records, err := w.historyRecords(addrs)
 	if err != nil {
-		log.Warnw("TxHistory: HistoryList", "err", err.Error())
+		log.Warnw("TxHistory: historyRecords", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	var hs []client.HistoryResponse
-	for _, h := range historys {
-		hs = append(hs, client.HistoryResponse{
-			Version:    h.Version,
-			To:         h.To,
-			From:       h.From,
-			Nonce:      h.Nonce,
-			Value:      h.Value,
-			GasLimit:   h.GasLimit,
-			GasFeeCap:  h.GasFeeCap,
-			GasPremium: h.GasPremium,
-			Method:     h.Method,
-			Params:     h.Params,
-			TxCid:      h.TxCid,
-			TxState:    string(h.TxState),
-		})
+	page, next := q.apply(records)
+
+	hs := make([]client.HistoryResponse, 0, len(page))
+	for _, h := range page {
+		hs = append(hs, historyResponse(h.address, h.History))
 	}
 
-	ReturnOk(c, hs)
+	ReturnOk(c, client.HistoryPage{
+		Records:    hs,
+		NextCursor: next,
+	})
+}
+
+// historyResponse is the api form of the record h of addr
+func historyResponse(addr string, h datastore.History) client.HistoryResponse {
+	return client.HistoryResponse{
+		Address:            addr,
+		Version:            h.Version,
+		To:                 h.To,
+		From:               h.From,
+		Nonce:              h.Nonce,
+		Value:              h.Value,
+		GasLimit:           h.GasLimit,
+		GasFeeCap:          h.GasFeeCap,
+		GasPremium:         h.GasPremium,
+		AttoValue:          historyValue(&h).String(),
+		AttoGasFeeCap:      historyGasFeeCap(&h).String(),
+		AttoGasPremium:     historyGasPremium(&h).String(),
+		Method:             h.Method,
+		Params:             h.Params,
+		TxCid:              h.TxCid,
+		TxState:            string(h.TxState),
+		Direction:          string(h.Direction),
+		SubmitTime:         h.SubmitTime,
+		Epoch:              h.Epoch,
+		InclusionEpoch:     h.InclusionEpoch,
+		InclusionTipSet:    h.InclusionTipSet,
+		Timestamp:          h.Timestamp,
+		ExitCode:           h.ExitCode,
+		GasUsed:            h.GasUsed,
+		MethodName:         h.MethodName,
+		BaseFeeBurn:        h.BaseFeeBurn,
+		OverEstimationBurn: h.OverEstimationBurn,
+		MinerTip:           h.MinerTip,
+		Return:             h.Return,
+	}
 }
@@ -0,0 +1,70 @@
+package wallet
+
+import (
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHistoryQuery(t *testing.T) {
+	records := []historyRecord{
+		{address: "f01000", History: datastore.History{TxCid: "a", To: "f01", Epoch: 30, Direction: datastore.Outgoing, TxState: datastore.Success, MethodName: "Send"}},
+		{address: "f01000", History: datastore.History{TxCid: "b", From: "f02", Epoch: 10, Direction: datastore.Incoming, TxState: datastore.Success, Method: 2, MethodName: "Propose"}},
+		{address: "f01000", History: datastore.History{TxCid: "c", To: "f02", Direction: datastore.Outgoing, TxState: datastore.Pending}},
+		{address: "f01001", History: datastore.History{TxCid: "d", To: "f02", Epoch: 20, Direction: datastore.Outgoing, TxState: datastore.Failed}},
+	}
+
+	ids := func(page []historyRecord) []string {
+		var ids []string
+		for _, r := range page {
+			ids = append(ids, r.TxCid)
+		}
+		return ids
+	}
+
+	// the pending message comes last in both orders
+	page, next := (&historyQuery{sortBy: sortByEpoch}).apply(records)
+	require.Equal(t, []string{"b", "d", "a", "c"}, ids(page))
+	require.Empty(t, next)
+
+	page, _ = (&historyQuery{sortBy: sortByEpoch, desc: true}).apply(records)
+	require.Equal(t, []string{"a", "d", "b", "c"}, ids(page))
+
+	page, _ = (&historyQuery{sortBy: sortByEpoch, counterparty: "f02"}).apply(records)
+	require.Equal(t, []string{"b", "d", "c"}, ids(page))
+
+	page, _ = (&historyQuery{sortBy: sortByEpoch, minEpoch: 15}).apply(records)
+	require.Equal(t, []string{"d", "a"}, ids(page))
+
+	page, _ = (&historyQuery{sortBy: sortByEpoch, methods: []string{"propose"}}).apply(records)
+	require.Equal(t, []string{"b"}, ids(page))
+
+	page, _ = (&historyQuery{sortBy: sortByEpoch, states: []datastore.MsgState{datastore.Failed, datastore.Pending}}).apply(records)
+	require.Equal(t, []string{"d", "c"}, ids(page))
+
+	// the values above ~9.22 FIL do not fit an int64
+	records[0].AttoValue = "20000000000000000000"
+	records[1].AttoValue = "1"
+	records[3].Value = 5
+	page, _ = (&historyQuery{sortBy: sortByValue, desc: true}).apply(records)
+	require.Equal(t, []string{"a", "d", "b", "c"}, ids(page))
+
+	// paging with the cursor walks every record once
+	q := &historyQuery{sortBy: sortByEpoch, limit: 3}
+	page, next = q.apply(records)
+	require.Equal(t, []string{"b", "d", "a"}, ids(page))
+	require.NotEmpty(t, next)
+
+	q.cursor, _ = decodeHistoryCursor(next)
+	page, next = q.apply(records)
+	require.Equal(t, []string{"c"}, ids(page))
+	require.Empty(t, next)
+
+	q = &historyQuery{sortBy: sortByValue, desc: true, limit: 1}
+	page, next = q.apply(records)
+	require.Equal(t, []string{"a"}, ids(page))
+
+	q.cursor, _ = decodeHistoryCursor(next)
+	page, _ = q.apply(records)
+	require.Equal(t, []string{"d"}, ids(page))
+}
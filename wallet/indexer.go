@@ -0,0 +1,386 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/types/ethtypes"
+	"sync"
+	"time"
+)
+
+// indexBatch bounds how many epochs are walked per round, a long backfill is spread over several rounds
+const indexBatch = 200
+
+// noRewind means no rewind is pending
+const noRewind = -1
+
+// chainIndexer walks the executed tipsets and records the messages sent and received by the owned
+// and watched addresses, outgoing messages go to the history of the sender, incoming ones to the
+// incoming records of the receiver
+type chainIndexer struct {
+	db     datastore.WalletDB
+	events *events.Bus
+
+	lk       sync.Mutex
+	rewindTo int64
+	// ids caches the ID addresses of the indexed addresses, keyed by the address the records are stored under
+	ids map[string]address.Address
+}
+
+func newChainIndexer(db datastore.WalletDB, bus *events.Bus) *chainIndexer {
+	return &chainIndexer{
+		db:       db,
+		events:   bus,
+		rewindTo: noRewind,
+		ids:      make(map[string]address.Address),
+	}
+}
+
+// rewind makes the next round walk the chain again from epoch, messages already recorded are replaced
+func (ix *chainIndexer) rewind(epoch int64) {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	if ix.rewindTo == noRewind || epoch < ix.rewindTo {
+		ix.rewindTo = epoch
+	}
+}
+
+func (ix *chainIndexer) takeRewind() int64 {
+	ix.lk.Lock()
+	defer ix.lk.Unlock()
+
+	rewindTo := ix.rewindTo
+	ix.rewindTo = noRewind
+	return rewindTo
+}
+
+func (w *Wallet) indexLoop(close <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(w.indexerConfig().Interval.Duration()):
+			cfg := w.indexerConfig()
+			n := w.node()
+			if !cfg.Enable || w.offline || n == nil {
+				continue
+			}
+
+			if err := w.indexer.index(n.Api, cfg); err != nil {
+				log.Warnw("indexLoop: index", "err", err)
+			}
+		case <-close:
+			return
+		}
+	}
+}
+
+// index walks at most indexBatch epochs after the last indexed one, staying cfg.Confidence epochs behind the head
+func (ix *chainIndexer) index(node api.FullNode, cfg config.Indexer) error {
+	ctx := context.Background()
+
+	state, err := ix.db.GetIndexerState()
+	if err != nil {
+		return err
+	}
+
+	start := int64(cfg.StartEpoch)
+	if state.UpdatedAt == 0 || state.StartEpoch != start {
+		log.Infow("chainIndexer: index from start epoch", "startEpoch", start)
+		state = &datastore.IndexerState{
+			StartEpoch: start,
+			Height:     start - 1,
+		}
+	}
+
+	if rewindTo := ix.takeRewind(); rewindTo != noRewind && rewindTo <= state.Height {
+		log.Infow("chainIndexer: rewind", "from", state.Height, "to", rewindTo)
+		state.Height = rewindTo - 1
+	}
+
+	// the genesis tipset has no parent messages
+	if state.Height < 0 {
+		state.Height = 0
+	}
+
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := int64(head.Height()) - int64(cfg.Confidence)
+	// events are only published once the indexer follows the head, a backfill would replay old messages
+	live := true
+	if target > state.Height+indexBatch {
+		target = state.Height + indexBatch
+		live = false
+	}
+	if target <= state.Height {
+		return nil
+	}
+
+	tracked, err := ix.tracked(ctx, node, head.Key())
+	if err != nil {
+		return err
+	}
+
+	for height := state.Height + 1; height <= target; height++ {
+		if err := ix.indexEpoch(ctx, node, abi.ChainEpoch(height), head.Key(), tracked, live); err != nil {
+			return fmt.Errorf("indexing epoch %d: %w", height, err)
+		}
+
+		state.Height = height
+		state.UpdatedAt = time.Now().Unix()
+		if err := ix.db.SetIndexerState(state); err != nil {
+			return err
+		}
+	}
+
+	log.Debugw("chainIndexer: indexed", "height", state.Height, "head", head.Height())
+	return nil
+}
+
+// indexEpoch records the tracked messages executed in the tipset at height, a null round has none,
+// the events of the messages not recorded before are published when live
+func (ix *chainIndexer) indexEpoch(ctx context.Context, node api.FullNode, height abi.ChainEpoch, headKey types.TipSetKey, tracked map[address.Address]string, live bool) error {
+	ts, err := node.ChainGetTipSetByHeight(ctx, height, headKey)
+	if err != nil {
+		return err
+	}
+	if ts.Height() != height {
+		return nil
+	}
+
+	msgs, err := node.ChainGetParentMessages(ctx, ts.Cids()[0])
+	if err != nil {
+		return err
+	}
+
+	rcts, err := node.ChainGetParentReceipts(ctx, ts.Cids()[0])
+	if err != nil {
+		return err
+	}
+
+	if len(msgs) != len(rcts) {
+		return fmt.Errorf("got %d messages but %d receipts", len(msgs), len(rcts))
+	}
+
+	// the parent includes the messages, it is only read when one is tracked
+	var incl *types.TipSet
+	for i, msg := range msgs {
+		from, fromOk := tracked[msg.Message.From]
+		to, toOk := tracked[msg.Message.To]
+		if !fromOk && !toOk {
+			continue
+		}
+
+		if incl == nil {
+			incl, err = node.ChainGetTipSet(ctx, ts.Parents())
+			if err != nil {
+				return err
+			}
+		}
+
+		record := ix.newRecord(ctx, node, msg, rcts[i], ts, incl)
+		if fromOk {
+			record.From = from
+		}
+		if toOk {
+			record.To = to
+		}
+
+		if fromOk {
+			recorded, err := ix.recordOutgoing(record, toOk && to == from)
+			if err != nil {
+				return err
+			}
+			if live && recorded != nil {
+				ix.events.Publish(txEventType(recorded.TxState), historyResponse(from, *recorded))
+			}
+		}
+
+		if toOk && to != from {
+			recorded, err := ix.recordIncoming(record)
+			if err != nil {
+				return err
+			}
+			if live && recorded != nil {
+				ix.events.Publish(txEventType(recorded.TxState), historyResponse(to, *recorded))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ix *chainIndexer) newRecord(ctx context.Context, node api.FullNode, msg api.Message, rct *types.MessageReceipt, ts, incl *types.TipSet) datastore.History {
+	record := datastore.History{
+		Version:        msg.Message.Version,
+		To:             msg.Message.To.String(),
+		From:           msg.Message.From.String(),
+		Nonce:          msg.Message.Nonce,
+		Value:          msg.Message.Value.Int64(),
+		GasLimit:       msg.Message.GasLimit,
+		GasFeeCap:      msg.Message.GasFeeCap.Int64(),
+		GasPremium:     msg.Message.GasPremium.Int64(),
+		AttoValue:      msg.Message.Value.String(),
+		AttoGasFeeCap:  msg.Message.GasFeeCap.String(),
+		AttoGasPremium: msg.Message.GasPremium.String(),
+		Method:         uint64(msg.Message.Method),
+		TxCid:          msg.Cid.String(),
+		TxState:        datastore.Success,
+	}
+	setReceipt(&record, rct, ts.Height(), incl)
+	record.Return = decodeHistoryReturn(ctx, node, &record, rct.Return, ts.Key())
+
+	if rct.ExitCode.IsError() {
+		record.TxState = datastore.Failed
+		record.Detail = fmt.Sprintf("ExitCode: %d", rct.ExitCode)
+	}
+
+	methodName, err := buildmessage.MethodName(ctx, node, msg.Message.To, msg.Message.Method, ts.Key())
+	if err != nil {
+		log.Debugw("chainIndexer: MethodName", "cid", msg.Cid, "err", err)
+	}
+	record.MethodName = methodName
+
+	return record
+}
+
+// recordOutgoing replaces the history of the nonce, the params and submit time of a message pushed by this wallet are kept,
+// it returns the record when the message was not found on chain before
+func (ix *chainIndexer) recordOutgoing(record datastore.History, self bool) (*datastore.History, error) {
+	record.Direction = datastore.Outgoing
+	if self {
+		record.Direction = datastore.Self
+	}
+
+	known := false
+	pushed, err := ix.db.GetHistory(record.From, record.Nonce)
+	if err == nil && pushed.TxCid == record.TxCid {
+		record.Params = pushed.Params
+		record.ParamName = pushed.ParamName
+		record.SubmitTime = pushed.SubmitTime
+		known = pushed.Epoch != 0
+	}
+
+	if err := ix.db.UpdateHistory(&record); err != nil {
+		return nil, err
+	}
+
+	if known {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// recordIncoming records the message received, it returns the record when the message was not recorded before
+func (ix *chainIndexer) recordIncoming(record datastore.History) (*datastore.History, error) {
+	record.Direction = datastore.Incoming
+
+	known, err := ix.db.HasIncoming(record.To, record.TxCid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ix.db.SetIncoming(record.To, &record); err != nil {
+		return nil, err
+	}
+
+	if known {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// tracked maps the robust and ID addresses of the owned and watched addresses to the address their records are stored under,
+// addresses not on chain yet are only matched by their robust address
+func (ix *chainIndexer) tracked(ctx context.Context, node api.FullNode, tsk types.TipSetKey) (map[address.Address]string, error) {
+	addrs, err := ix.addresses()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := make(map[address.Address]string, 2*len(addrs))
+	for _, addrStr := range addrs {
+		addr, err := address.NewFromString(addrStr)
+		if err != nil {
+			log.Warnw("chainIndexer: NewFromString", "address", addrStr, "err", err)
+			continue
+		}
+
+		tracked[addr] = addrStr
+		if addr.Protocol() == address.ID {
+			continue
+		}
+
+		id, ok := ix.ids[addrStr]
+		if !ok {
+			id, err = node.StateLookupID(ctx, addr, tsk)
+			if err != nil {
+				continue
+			}
+			ix.ids[addrStr] = id
+		}
+		tracked[id] = addrStr
+	}
+
+	return tracked, nil
+}
+
+// addresses returns the owned wallets, eth wallets as f4 addresses, msigs and watched addresses
+func (ix *chainIndexer) addresses() ([]string, error) {
+	var addrs []string
+
+	wallets, err := ix.db.WalletList()
+	if err != nil {
+		return nil, err
+	}
+	for _, wallet := range wallets {
+		addrs = append(addrs, wallet.Address)
+	}
+
+	ethWallets, err := ix.db.EthWalletList()
+	if err != nil {
+		return nil, err
+	}
+	for _, wallet := range ethWallets {
+		ethAddr, err := ethtypes.ParseEthAddress(wallet.Address)
+		if err != nil {
+			log.Warnw("chainIndexer: ParseEthAddress", "address", wallet.Address, "err", err)
+			continue
+		}
+
+		f4Addr, err := ethAddr.ToFilecoinAddress()
+		if err != nil {
+			log.Warnw("chainIndexer: ToFilecoinAddress", "address", wallet.Address, "err", err)
+			continue
+		}
+		addrs = append(addrs, f4Addr.String())
+	}
+
+	msigs, err := ix.db.MsigWalletList()
+	if err != nil {
+		return nil, err
+	}
+	for _, msig := range msigs {
+		addrs = append(addrs, msig.MsigAddr)
+	}
+
+	watches, err := ix.db.WatchList()
+	if err != nil {
+		return nil, err
+	}
+	for _, watch := range watches {
+		addrs = append(addrs, watch.Address)
+	}
+
+	return addrs, nil
+}
@@ -0,0 +1,186 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/ipfs/go-cid"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// chainNode serves the messages executed at height msgHeight, nullHeight is a null round
+type chainNode struct {
+	api.FullNode
+	head       abi.ChainEpoch
+	nullHeight abi.ChainEpoch
+	msgHeight  abi.ChainEpoch
+	tipsets    map[cid.Cid]abi.ChainEpoch
+	msgs       []api.Message
+	rcts       []*types.MessageReceipt
+}
+
+func (n *chainNode) tipset(height abi.ChainEpoch) *types.TipSet {
+	miner, _ := address.NewIDAddress(uint64(height))
+	root, _ := cid.Decode("bafy2bzacecnamqgqmifpluoeldx7zzglxcljo6oja4vrmtj7432rphldpdmm2")
+	ts, err := types.NewTipSet([]*types.BlockHeader{{
+		Miner:                 miner,
+		Height:                height,
+		ParentWeight:          types.NewInt(0),
+		ParentStateRoot:       root,
+		ParentMessageReceipts: root,
+		Messages:              root,
+		ParentBaseFee:         types.NewInt(100),
+	}})
+	if err != nil {
+		panic(err)
+	}
+	n.tipsets[ts.Cids()[0]] = height
+	return ts
+}
+
+func (n *chainNode) ChainHead(ctx context.Context) (*types.TipSet, error) {
+	return n.tipset(n.head), nil
+}
+
+func (n *chainNode) ChainGetTipSetByHeight(ctx context.Context, height abi.ChainEpoch, tsk types.TipSetKey) (*types.TipSet, error) {
+	if height == n.nullHeight {
+		height--
+	}
+	return n.tipset(height), nil
+}
+
+func (n *chainNode) ChainGetTipSet(ctx context.Context, tsk types.TipSetKey) (*types.TipSet, error) {
+	return n.tipset(n.msgHeight - 1), nil
+}
+
+func (n *chainNode) ChainGetParentMessages(ctx context.Context, blockCid cid.Cid) ([]api.Message, error) {
+	if n.tipsets[blockCid] != n.msgHeight {
+		return nil, nil
+	}
+	return n.msgs, nil
+}
+
+func (n *chainNode) ChainGetParentReceipts(ctx context.Context, blockCid cid.Cid) ([]*types.MessageReceipt, error) {
+	if n.tipsets[blockCid] != n.msgHeight {
+		return nil, nil
+	}
+	return n.rcts, nil
+}
+
+func (n *chainNode) StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error) {
+	return nil, errors.New("actor not found")
+}
+
+func TestChainIndexer(t *testing.T) {
+	db := datastore.NewWalletDB(dssync.MutexWrap(ds.NewMapDatastore()))
+	watched, _ := address.NewIDAddress(1000)
+	other, _ := address.NewIDAddress(1001)
+	stranger, _ := address.NewIDAddress(999)
+	for _, addr := range []address.Address{watched, other} {
+		require.NoError(t, db.SetWatch(&datastore.WatchedAddress{Address: addr.String()}))
+	}
+
+	out := &types.Message{From: watched, To: stranger, Nonce: 1, Value: abi.NewTokenAmount(1)}
+	in := &types.Message{From: stranger, To: other, Nonce: 7, Value: abi.NewTokenAmount(2)}
+	// the value of between overflows an int64
+	between := &types.Message{From: watched, To: other, Nonce: 2, Value: abi.TokenAmount(types.MustParseFIL("20"))}
+	unrelated := &types.Message{From: stranger, To: stranger, Nonce: 8, Value: abi.NewTokenAmount(0)}
+
+	node := &chainNode{
+		head:       20,
+		nullHeight: 12,
+		msgHeight:  11,
+		tipsets:    make(map[cid.Cid]abi.ChainEpoch),
+	}
+	for _, msg := range []*types.Message{out, in, between, unrelated} {
+		msg.GasLimit = 125
+		msg.GasFeeCap = abi.NewTokenAmount(200)
+		msg.GasPremium = abi.NewTokenAmount(10)
+		node.msgs = append(node.msgs, api.Message{Cid: msg.Cid(), Message: msg})
+		node.rcts = append(node.rcts, &types.MessageReceipt{GasUsed: 100})
+	}
+	node.rcts[1].ExitCode = exitcode.ErrInsufficientFunds
+
+	// a message pushed by the wallet keeps its params
+	require.NoError(t, db.SetHistory(&datastore.History{From: watched.String(), Nonce: 1, TxCid: out.Cid().String(), Params: "{}", TxState: datastore.Pending}))
+
+	bus := events.NewBus()
+	published, _, cancel := bus.Subscribe(10, 0)
+	defer cancel()
+
+	ix := newChainIndexer(db, bus)
+	cfg := config.Indexer{Enable: true, StartEpoch: 10, Confidence: 5}
+	require.NoError(t, ix.index(node, cfg))
+
+	// two messages sent and two received, the one that failed is received
+	failed := 0
+	for i := 0; i < 4; i++ {
+		evt := <-published
+		if evt.Type == events.TxFailed {
+			failed++
+		}
+	}
+	require.Equal(t, 1, failed)
+
+	state, err := db.GetIndexerState()
+	require.NoError(t, err)
+	require.Equal(t, int64(15), state.Height)
+
+	check := func() {
+		sent, err := db.HistoryList(watched.String())
+		require.NoError(t, err)
+		require.Len(t, sent, 2)
+		for _, h := range sent {
+			require.Equal(t, datastore.Outgoing, h.Direction)
+			require.Equal(t, int64(11), h.Epoch)
+			require.Equal(t, int64(100), h.GasUsed)
+			require.Equal(t, int64(10), h.InclusionEpoch)
+			// the base fee of the fake tipsets is 100
+			require.Equal(t, "10000", h.BaseFeeBurn)
+			require.Equal(t, "1250", h.MinerTip)
+			require.Equal(t, datastore.HistorySchema, h.Schema)
+			require.Equal(t, "Send", h.MethodName)
+			require.Equal(t, datastore.Success, h.TxState)
+			if h.Nonce == 1 {
+				require.Equal(t, "{}", h.Params)
+			}
+			if h.Nonce == 2 {
+				require.Equal(t, "20000000000000000000", h.AttoValue)
+			}
+		}
+
+		received, err := db.IncomingList(other.String())
+		require.NoError(t, err)
+		require.Len(t, received, 2)
+		for _, h := range received {
+			require.Equal(t, datastore.Incoming, h.Direction)
+			if h.TxCid == in.Cid().String() {
+				require.Equal(t, datastore.Failed, h.TxState)
+				require.Equal(t, int64(exitcode.ErrInsufficientFunds), h.ExitCode)
+			}
+		}
+
+		none, err := db.IncomingList(watched.String())
+		require.NoError(t, err)
+		require.Empty(t, none)
+	}
+	check()
+
+	// walking the chain again replaces the records
+	ix.rewind(11)
+	require.NoError(t, ix.index(node, cfg))
+	check()
+
+	// the messages recorded before are not published again
+	require.Empty(t, published)
+}
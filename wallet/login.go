@@ -8,19 +8,21 @@ import (
 	"time"
 )
 
-const lockDuration = 10 * time.Minute
+var errLoginPasswordDisabled = NewError(505, "the login password is disabled once users exist, login with a user")
 
 type login struct {
-	lock       bool
-	lockTicker *time.Ticker
-	close      <-chan struct{}
+	lock         bool
+	lockTicker   *time.Ticker
+	lockDuration func() time.Duration
+	close        <-chan struct{}
 }
 
-func newLogin(close <-chan struct{}) *login {
+func newLogin(lockDuration func() time.Duration, close <-chan struct{}) *login {
 	l := &login{
-		lock:       true,
-		lockTicker: time.NewTicker(lockDuration),
-		close:      close,
+		lock:         true,
+		lockTicker:   time.NewTicker(lockDuration()),
+		lockDuration: lockDuration,
+		close:        close,
 	}
 
 	go l.loop()
@@ -38,27 +40,54 @@ func (w *Wallet) Login(c *gin.Context) {
 		return
 	}
 
-	loginScryptKey, err := w.db.GetLoginPassword()
-	if err != nil {
-		log.Warnw("Login: GetLoginPassword", "err", err.Error())
-		ReturnError(c, NewError(500, err.Error()))
-		return
-	}
+	var token []byte
+	if param.User != "" {
+		token, err = w.userToken(param.User, param.LoginPassword)
+		if err == errUserAuth {
+			log.Warnw("Login: userToken", "user", param.User, "err", err)
+			ReturnError(c, AuthErr)
+			return
+		} else if err != nil {
+			log.Warnw("Login: userToken", "user", param.User, "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+	} else {
+		hasUsers, err := w.hasUsers()
+		if err != nil {
+			log.Warnw("Login: hasUsers", "err", err.Error())
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+		if hasUsers {
+			log.Warnw("Login: login password refused, named users exist")
+			ReturnError(c, errLoginPasswordDisabled)
+			return
+		}
 
-	isOk, err := crypto.VerifyScrypt(param.LoginPassword, loginScryptKey)
-	if err != nil || !isOk {
-		log.Warnw("Login: VerifyScrypt", "isOk", isOk, "err", err)
-		ReturnError(c, AuthErr)
-		return
-	}
+		loginScryptKey, err := w.db.GetLoginPassword()
+		if err != nil {
+			log.Warnw("Login: GetLoginPassword", "err", err.Error())
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
 
-	token, err := app.AuthNew(app.SignPermissions)
-	if err != nil {
-		log.Warnw("Login: AuthNew", "err", err)
-		ReturnError(c, NewError(500, err.Error()))
-		return
+		isOk, err := crypto.VerifyScrypt(param.LoginPassword, loginScryptKey)
+		if err != nil || !isOk {
+			log.Warnw("Login: VerifyScrypt", "isOk", isOk, "err", err)
+			ReturnError(c, AuthErr)
+			return
+		}
+
+		token, err = app.AuthNew(app.SignPermissions)
+		if err != nil {
+			log.Warnw("Login: AuthNew", "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
 	}
 
+	log.Infow("Login", "user", param.User)
 	w.unlock()
 	ReturnOk(c, client.LoginInfo{
 		Code:    200,
@@ -74,7 +103,7 @@ func (w *Wallet) Logout(c *gin.Context) {
 
 func (l *login) unlock() {
 	l.lock = false
-	l.lockTicker.Reset(lockDuration)
+	l.lockTicker.Reset(l.lockDuration())
 }
 
 func (l *login) loop() {
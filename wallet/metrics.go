@@ -0,0 +1,94 @@
+package wallet
+
+import (
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/metrics"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// Metrics Get
+func (w *Wallet) Metrics(c *gin.Context) {
+	metrics.WalletLocked.Set(metrics.Bool(w.lock))
+	metrics.WalletSealed.Set(metrics.Bool(w.isSealed()))
+	metrics.NodeInUse.Reset()
+	if name := w.nodeName(); name != "" {
+		metrics.NodeInUse.WithLabelValues(name).Set(1)
+	}
+
+	metrics.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+func (w *Wallet) metricsLoop(close <-chan struct{}) {
+	interval := func() time.Duration {
+		return w.metricsConfig().BalanceInterval.Duration()
+	}
+
+	runEvery(interval, func() {
+		n := w.node()
+		if w.offline || n == nil {
+			return
+		}
+
+		w.updateBalances(n.Api, w.metricsConfig().Balances)
+	}, close)
+}
+
+// updateBalances refreshes the balance gauges, an address removed from the config loses its gauges
+func (w *Wallet) updateBalances(node api.FullNode, addrs []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	balances := make(map[string]float64, len(addrs))
+	available := make(map[string]float64)
+	for _, addrStr := range addrs {
+		addr, err := address.NewFromString(addrStr)
+		if err != nil {
+			log.Warnw("updateBalances: NewFromString", "address", addrStr, "err", err)
+			continue
+		}
+
+		act, err := node.StateGetActor(ctx, addr, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("updateBalances: StateGetActor", "address", addrStr, "err", err)
+			continue
+		}
+		balances[addrStr] = filFloat(act.Balance)
+
+		if !builtin.IsStorageMinerActor(act.Code) {
+			continue
+		}
+
+		amount, err := node.StateMinerAvailableBalance(ctx, addr, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("updateBalances: StateMinerAvailableBalance", "miner", addrStr, "err", err)
+			continue
+		}
+		available[addrStr] = filFloat(amount)
+	}
+
+	metrics.Balance.Reset()
+	for addr, balance := range balances {
+		metrics.Balance.WithLabelValues(addr).Set(balance)
+	}
+
+	metrics.MinerAvailableBalance.Reset()
+	for miner, amount := range available {
+		metrics.MinerAvailableBalance.WithLabelValues(miner).Set(amount)
+	}
+}
+
+// filFloat converts attoFIL to FIL, the precision lost does not matter to a gauge
+func filFloat(atto big.Int) float64 {
+	f, err := strconv.ParseFloat(types.FIL(atto).Unitless(), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
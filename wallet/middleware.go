@@ -2,11 +2,14 @@ package wallet
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/metrics"
 	"github.com/gin-gonic/gin"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -41,11 +44,49 @@ func Recovery() gin.HandlerFunc {
 	}
 }
 
+const routeMetaKey = "routeMeta"
+
+// RouteMetadata looks up the metadata of the matched route for the middlewares after it
+func RouteMetadata() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		meta, ok := RouteMetaMap[c.FullPath()]
+		if !ok {
+			ReturnError(c, NewError(404, "route not found"))
+			c.Abort()
+			return
+		}
+
+		c.Set(routeMetaKey, meta)
+		c.Next()
+	}
+}
+
+func routeMeta(c *gin.Context) RouteMeta {
+	return c.MustGet(routeMetaKey).(RouteMeta)
+}
+
+// MustUnseal only lets read-only requests through until the wallet is unsealed
+func (w *Wallet) MustUnseal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !w.isSealed() {
+			c.Next()
+			return
+		}
+
+		perm := routeMeta(c).Perm
+		if perm == "" || perm == app.PermRead {
+			c.Next()
+			return
+		}
+
+		ReturnError(c, NewError(503, "wallet is sealed, please unseal"))
+		c.Abort()
+	}
+}
+
 func (w *Wallet) MustUnlock() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if strings.Contains(c.Request.URL.String(), "status") ||
-			strings.Contains(c.Request.URL.String(), "login") ||
-			strings.Contains(c.Request.URL.String(), "logout") {
+		if !routeMeta(c).NeedUnlock {
 			c.Next()
 			return
 		}
@@ -65,15 +106,10 @@ func (w *Wallet) MustUnlock() gin.HandlerFunc {
 
 func (w *Wallet) MustHaveNode() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if strings.Contains(c.Request.URL.String(), "wallet") ||
-			strings.Contains(c.Request.URL.String(), "miner") ||
-			strings.Contains(c.Request.URL.String(), "msig") ||
-			strings.Contains(c.Request.URL.String(), "transfer") {
-			if w.node == nil {
-				ReturnError(c, NewError(504, "no node available"))
-				c.Abort()
-				return
-			}
+		if routeMeta(c).NeedNode && w.node() == nil {
+			ReturnError(c, NewError(504, "no node available"))
+			c.Abort()
+			return
 		}
 
 		c.Next()
@@ -82,7 +118,7 @@ func (w *Wallet) MustHaveNode() gin.HandlerFunc {
 
 func (w *Wallet) IfOfflineWallet() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if w.offline && strings.Contains(c.Request.URL.String(), "send") {
+		if w.offline && !routeMeta(c).AllowOffline {
 			ReturnError(c, NewError(504, "Offline wallet, does not support sending transactions"))
 			c.Abort()
 			return
@@ -92,10 +128,41 @@ func (w *Wallet) IfOfflineWallet() gin.HandlerFunc {
 	}
 }
 
+type localSocketKey struct{}
+
+// LocalSocket marks the requests served on the api socket,
+// the file permissions of the socket authenticate them, no token is required
+func LocalSocket(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), localSocketKey{}, true)))
+	})
+}
+
+// EventStream lifts the server write timeout for the /events stream, the other routes keep it
+func EventStream(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/events" {
+			if err := http.NewResponseController(rw).SetWriteDeadline(time.Time{}); err != nil {
+				log.Warnw("EventStream: SetWriteDeadline", "err", err)
+			}
+		}
+		h.ServeHTTP(rw, r)
+	})
+}
+
+func isLocalSocket(c *gin.Context) bool {
+	local, _ := c.Request.Context().Value(localSocketKey{}).(bool)
+	return local
+}
+
 func (w *Wallet) JWT() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		method := c.Request.URL.String()
-		if method != "/login" && method != "/getRouters" && method != "/logout" {
+		if isLocalSocket(c) {
+			c.Next()
+			return
+		}
+
+		if routeMeta(c).Perm != "" {
 			token := c.GetHeader("Authorization")
 			tokens := strings.Split(token, " ")
 			if len(tokens) != 2 {
@@ -103,14 +170,33 @@ func (w *Wallet) JWT() gin.HandlerFunc {
 				c.Abort()
 				return
 			}
-			allow, err := app.AuthVerify(tokens[1])
+			claims, err := app.AuthVerifyToken(tokens[1])
 			if err != nil {
 				ReturnError(c, NewError(505, err.Error()))
 				c.Abort()
 				return
 			}
 
-			if !VerifyPermission(c.Request.URL.String(), allow) {
+			allow := claims.Allow
+			if claims.User != "" {
+				allow, err = w.userPermissions(claims.User)
+				if err != nil {
+					ReturnError(c, NewError(505, err.Error()))
+					c.Abort()
+					return
+				}
+				c.Set(userKey, claims.User)
+			} else if !claims.Operator {
+				// tokens of the login password issued before the first user was added,
+				// the operator tokens of the repo stay valid
+				if hasUsers, err := w.hasUsers(); err != nil || hasUsers {
+					ReturnError(c, errLoginPasswordDisabled)
+					c.Abort()
+					return
+				}
+			}
+
+			if !VerifyPermission(c.FullPath(), allow) {
 				ReturnError(c, NewError(505, "Insufficient Permission"))
 				c.Abort()
 				return
@@ -135,17 +221,29 @@ func (l LoggerWriter) Write(p []byte) (int, error) {
 
 func (w *Wallet) TraceLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		method := c.Request.URL.String()
+
+		// the event stream never ends and the metrics are scraped all the time, their bodies are not buffered
+		if path := c.Request.URL.Path; path == "/events" || path == "/metrics" {
+			start := time.Now()
+			c.Next()
+			log.Infow("TraceLogger", "method", method, "user", c.GetString(userKey), "cost", time.Since(start).String())
+			metrics.RequestsTotal.WithLabelValues(c.FullPath(), c.Request.Method, strconv.Itoa(responseCode(c))).Inc()
+			return
+		}
+
 		bodyWriter := &LoggerWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
 		c.Writer = bodyWriter
 
-		method := c.Request.URL.String()
 		start := time.Now()
 		c.Next()
-		log.Infow("TraceLogger", "method", method, "cost", time.Since(start).String())
+		cost := time.Since(start)
+		log.Infow("TraceLogger", "method", method, "user", c.GetString(userKey), "cost", cost.String())
+		metrics.ObserveRequest(c.FullPath(), c.Request.Method, responseCode(c), cost)
 
-		// The login response contains token,
-		// which is sensitive information, skip it
-		if method != "/login" {
+		// The login response contains token and the unseal request contains the master password,
+		// which are sensitive information, skip them whatever query string the request carries
+		if !routeMeta(c).Sensitive {
 			request := ""
 			response := bodyWriter.body.String()
 			if c.Request.Method == http.MethodPost {
@@ -159,8 +257,17 @@ func (w *Wallet) TraceLogger() gin.HandlerFunc {
 				request = c.Request.URL.RawQuery
 			}
 
-			log.Debugw("TraceLogger", "method", method, "request", request, "response", response)
+			log.Debugw("TraceLogger", "method", method, "user", c.GetString(userKey), "request", request, "response", response)
 
 		}
 	}
 }
+
+// responseCode is the code of the error response, the http status is always 200
+func responseCode(c *gin.Context) int {
+	if code := c.GetInt(responseCodeKey); code != 0 {
+		return code
+	}
+
+	return c.Writer.Status()
+}
@@ -2,13 +2,13 @@ package wallet
 
 import (
 	"context"
-	"github.com/OpenFilWallet/OpenFilWallet/chain"
 	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/chain/types"
 	"github.com/gin-gonic/gin"
-	"time"
 )
 
 // Withdraw Post
@@ -21,24 +21,79 @@ func (w *Wallet) Withdraw(c *gin.Context) {
 		return
 	}
 
-	msg, msgParams, err := buildmessage.NewWithdrawMessage(w.Api, param.BaseParams, param.MinerId, param.Amount)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: Withdraw: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewWithdrawMessage(fullNode, param.BaseParams, param.MinerId, param.Amount, param.Sender, param.From)
 	if err != nil {
 		log.Warnw("Miner: Withdraw: NewWithdrawMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Miner: Withdraw: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: Withdraw: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 	return
 }
 
+// CreateMiner Post, the tracker watches the miner once the message lands
+func (w *Wallet) CreateMiner(c *gin.Context) {
+	param := client.CreateMinerRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("Miner: CreateMiner: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: CreateMiner: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewCreateMinerMessage(fullNode, param.BaseParams, param.Owner, param.Worker, param.SectorSize, param.PeerId, param.Multiaddrs, param.From)
+	if err != nil {
+		log.Warnw("Miner: CreateMiner: NewCreateMinerMessage", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
+	if err != nil {
+		log.Warnw("Miner: CreateMiner: EncodeMessage", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: CreateMiner: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, myMsg)
+}
+
 // ChangeOwner Post
 func (w *Wallet) ChangeOwner(c *gin.Context) {
 	param := client.ChangeOwnerRequest{}
@@ -49,20 +104,36 @@ func (w *Wallet) ChangeOwner(c *gin.Context) {
 		return
 	}
 
-	msg, msgParams, err := buildmessage.NewChangeOwnerMessage(w.Api, param.BaseParams, param.MinerId, param.NewOwner, param.From)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: ChangeOwner: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewChangeOwnerMessage(fullNode, param.BaseParams, param.MinerId, param.NewOwner, param.From)
 	if err != nil {
 		log.Warnw("Miner: ChangeOwner: NewChangeOwnerMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Miner: ChangeOwner: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: ChangeOwner: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	w.startWorkflow(fullNode, datastore.WorkflowChangeOwner, param.MinerId, param.NewOwner, param.AutoConfirm)
+
 	ReturnOk(c, myMsg)
 }
 
@@ -76,20 +147,38 @@ func (w *Wallet) ChangeWorker(c *gin.Context) {
 		return
 	}
 
-	msg, msgParams, err := buildmessage.NewChangeWorkerMessage(w.Api, param.BaseParams, param.MinerId, param.NewWorker, param.NewControlAddrs...)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: ChangeWorker: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewChangeWorkerMessage(fullNode, param.BaseParams, param.MinerId, param.NewWorker, param.NewControlAddrs...)
 	if err != nil {
 		log.Warnw("Miner: ChangeWorker: NewChangeWorkerMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Miner: ChangeWorker: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: ChangeWorker: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	if param.NewWorker != "" {
+		w.startWorkflow(fullNode, datastore.WorkflowChangeWorker, param.MinerId, param.NewWorker, param.AutoConfirm)
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -102,20 +191,34 @@ func (w *Wallet) ConfirmChangeWorker(c *gin.Context) {
 		ReturnError(c, ParamErr)
 		return
 	}
-	msg, err := buildmessage.NewConfirmUpdateWorkerMessage(w.Api, param.BaseParams, param.MinerId, param.NewWorker)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: ConfirmChangeWorker: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, err := buildmessage.NewConfirmUpdateWorkerMessage(fullNode, param.BaseParams, param.MinerId, param.NewWorker)
 	if err != nil {
 		log.Warnw("Miner: ConfirmChangeWorker: NewConfirmUpdateWorkerMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, nil)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, nil)
 	if err != nil {
 		log.Warnw("Miner: ConfirmChangeWorker: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: ConfirmChangeWorker: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -129,20 +232,34 @@ func (w *Wallet) ChangeControl(c *gin.Context) {
 		return
 	}
 
-	msg, msgParams, err := buildmessage.NewChangeWorkerMessage(w.Api, param.BaseParams, param.MinerId, "", param.NewControlAddrs...)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: ChangeControl: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewChangeWorkerMessage(fullNode, param.BaseParams, param.MinerId, "", param.NewControlAddrs...)
 	if err != nil {
 		log.Warnw("Miner: ChangeControl: NewChangeWorkerMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Miner: ChangeControl: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: ChangeControl: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -162,28 +279,33 @@ func (w *Wallet) ControlList(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
 	defer cancel()
-	mi, err := w.Api.StateMinerInfo(ctx, minerAddr, types.EmptyTSK)
+	mi, err := w.node().Api.StateMinerInfo(ctx, minerAddr, types.EmptyTSK)
 	if err != nil {
 		log.Warnw("Miner: ControlList: StateMinerInfo", "minerId", minerId, "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	ReturnOk(c, w.minerControl(ctx, mi))
+}
+
+// minerControl describes the owner, worker, control and beneficiary addresses of a miner with their balances
+func (w *Wallet) minerControl(ctx context.Context, mi api.MinerInfo) client.MinerControl {
 	printMeta := func(addr address.Address) client.Meta {
 		meta := client.Meta{
 			ID:      addr.String(),
 			Balance: "",
 		}
 
-		k, err := w.Api.StateAccountKey(ctx, addr, types.EmptyTSK)
+		k, err := w.node().Api.StateAccountKey(ctx, addr, types.EmptyTSK)
 		if err == nil {
 			meta.Address = k.String()
 		} else {
 			meta.ID = addr.String() + " (multisig)"
 		}
-		amount, err := w.node.Api.WalletBalance(ctx, addr)
+		amount, err := w.node().Api.WalletBalance(ctx, addr)
 		if err != nil {
 			log.Warnw("Balance: WalletBalance", "err", err.Error())
 		} else {
@@ -211,7 +333,7 @@ func (w *Wallet) ControlList(c *gin.Context) {
 		minerControl.ControlAddresses = controlAddrs
 	}
 
-	ReturnOk(c, minerControl)
+	return minerControl
 }
 
 // ChangeBeneficiary Post
@@ -224,20 +346,36 @@ func (w *Wallet) ChangeBeneficiary(c *gin.Context) {
 		return
 	}
 
-	msg, msgParams, err := buildmessage.NewChangeBeneficiaryProposeMessage(w.Api, param.BaseParams, param.MinerId, param.BeneficiaryAddress, param.Quota, param.Expiration, param.OverwritePendingChange)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: ChangeBeneficiary: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewChangeBeneficiaryProposeMessage(fullNode, param.BaseParams, param.MinerId, param.BeneficiaryAddress, param.Quota, param.Expiration, param.OverwritePendingChange)
 	if err != nil {
 		log.Warnw("Miner: ChangeBeneficiary: NewChangeBeneficiaryProposeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Miner: ChangeBeneficiary: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: ChangeBeneficiary: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	w.startWorkflow(fullNode, datastore.WorkflowChangeBeneficiary, param.MinerId, param.BeneficiaryAddress, param.AutoConfirm)
+
 	ReturnOk(c, myMsg)
 }
 
@@ -250,19 +388,33 @@ func (w *Wallet) ConfirmChangeBeneficiary(c *gin.Context) {
 		ReturnError(c, ParamErr)
 		return
 	}
-	msg, msgParams, err := buildmessage.NewConfirmChangeBeneficiary(w.Api, param.BaseParams, param.MinerId)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Miner: ConfirmChangeBeneficiary: buildNode", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msg, msgParams, err := buildmessage.NewConfirmChangeBeneficiary(fullNode, param.BaseParams, param.MinerId)
 	if err != nil {
 		log.Warnw("Miner: ConfirmChangeBeneficiary: NewConfirmChangeBeneficiary", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Miner: ConfirmChangeBeneficiary: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Miner: ConfirmChangeBeneficiary: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
@@ -14,7 +14,7 @@ func TestBeneficiary(t *testing.T) {
 		Endpoint: "https://api.node.glif.io/rpc/v0",
 		Token:    "",
 	}
-	n, err := newNode(node.Name, node.Endpoint, "")
+	n, err := newNode(context.Background(), node.Name, node.Endpoint, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,158 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+	"github.com/ipfs/go-cid"
+	"strings"
+)
+
+// minerState is the part of the miner actor state read by StateReadState
+type minerState struct {
+	PreCommitDeposits types.BigInt
+	LockedFunds       types.BigInt
+	FeeDebt           types.BigInt
+	InitialPledge     types.BigInt
+	VestingFunds      cid.Cid
+}
+
+// MinerInfo Get
+// MinerInfo takes several miners as repeated or comma separated miner_id queries
+func (w *Wallet) MinerInfo(c *gin.Context) {
+	var minerIds []string
+	for _, v := range c.QueryArray("miner_id") {
+		for _, minerId := range strings.Split(v, ",") {
+			if minerId = strings.TrimSpace(minerId); minerId != "" {
+				minerIds = append(minerIds, minerId)
+			}
+		}
+	}
+	if len(minerIds) == 0 {
+		log.Warnw("Miner: MinerInfo: GetQuery", "err", "key: miner_id does not exist")
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	var minerAddrs []address.Address
+	for _, minerId := range minerIds {
+		minerAddr, err := address.NewFromString(minerId)
+		if err != nil {
+			log.Warnw("Miner: MinerInfo: NewFromString", "minerId", minerId, "err", err)
+			ReturnError(c, ParamErr)
+			return
+		}
+		minerAddrs = append(minerAddrs, minerAddr)
+	}
+
+	infos := make([]client.MinerInfo, 0, len(minerAddrs))
+	for _, minerAddr := range minerAddrs {
+		info, err := w.minerInfo(minerAddr)
+		if err != nil {
+			log.Warnw("Miner: MinerInfo: minerInfo", "minerId", minerAddr.String(), "err", err)
+			info = client.MinerInfo{MinerId: minerAddr.String(), Error: err.Error()}
+		}
+		infos = append(infos, info)
+	}
+
+	ReturnOk(c, infos)
+}
+
+func (w *Wallet) minerInfo(minerAddr address.Address) (client.MinerInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	fullNode := w.node().Api
+	head, err := fullNode.ChainHead(ctx)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+	tsk := head.Key()
+
+	mi, err := fullNode.StateMinerInfo(ctx, minerAddr, tsk)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+
+	actorState, err := fullNode.StateReadState(ctx, minerAddr, tsk)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+
+	st, err := decodeMinerState(actorState.State)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+
+	available, err := fullNode.StateMinerAvailableBalance(ctx, minerAddr, tsk)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+
+	power, err := fullNode.StateMinerPower(ctx, minerAddr, tsk)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+
+	sectors, err := fullNode.StateMinerSectorCount(ctx, minerAddr, tsk)
+	if err != nil {
+		return client.MinerInfo{}, err
+	}
+
+	info := client.MinerInfo{
+		MinerId:           minerAddr.String(),
+		Control:           w.minerControl(ctx, mi),
+		SectorSize:        uint64(mi.SectorSize),
+		Balance:           types.FIL(actorState.Balance).String(),
+		AvailableBalance:  types.FIL(available).String(),
+		InitialPledge:     types.FIL(st.InitialPledge).String(),
+		LockedFunds:       types.FIL(st.LockedFunds).String(),
+		PreCommitDeposits: types.FIL(st.PreCommitDeposits).String(),
+		FeeDebt:           types.FIL(st.FeeDebt).String(),
+		RawPower:          power.MinerPower.RawBytePower.String(),
+		QualityAdjPower:   power.MinerPower.QualityAdjPower.String(),
+		HasMinPower:       power.HasMinPower,
+		LiveSectors:       sectors.Live,
+		ActiveSectors:     sectors.Active,
+		FaultySectors:     sectors.Faulty,
+	}
+
+	if mi.BeneficiaryTerm != nil {
+		info.BeneficiaryTerm = client.BeneficiaryTerm{
+			Quota:      types.FIL(mi.BeneficiaryTerm.Quota).String(),
+			UsedQuota:  types.FIL(mi.BeneficiaryTerm.UsedQuota).String(),
+			Expiration: int64(mi.BeneficiaryTerm.Expiration),
+		}
+	}
+	if remaining := buildmessage.BeneficiaryRemaining(mi, head.Height()); remaining != nil {
+		info.BeneficiaryTerm.Remaining = types.FIL(*remaining).String()
+	}
+
+	if pending := mi.PendingBeneficiaryTerm; pending != nil {
+		info.PendingBeneficiary = &client.PendingBeneficiary{
+			NewBeneficiary:        pending.NewBeneficiary.String(),
+			NewQuota:              types.FIL(pending.NewQuota).String(),
+			NewExpiration:         int64(pending.NewExpiration),
+			ApprovedByBeneficiary: pending.ApprovedByBeneficiary,
+			ApprovedByNominee:     pending.ApprovedByNominee,
+		}
+	}
+
+	return info, nil
+}
+
+// decodeMinerState decodes the state returned by StateReadState, it comes back as generic json
+func decodeMinerState(state interface{}) (minerState, error) {
+	var st minerState
+	stateJson, err := json.Marshal(state)
+	if err != nil {
+		return st, err
+	}
+
+	err = json.Unmarshal(stateJson, &st)
+	return st, err
+}
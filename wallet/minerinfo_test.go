@@ -0,0 +1,28 @@
+package wallet
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDecodeMinerState(t *testing.T) {
+	// StateReadState over json rpc returns the state as a map
+	var state interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"Info": {"/": "bafy2bzacecnamqgqmifpluoeldx7zzglxcljo6oja4vrmtj7432rphldpdmm2"},
+		"PreCommitDeposits": "1000",
+		"LockedFunds": "2000",
+		"FeeDebt": "0",
+		"InitialPledge": "3000",
+		"VestingFunds": {"/": "bafy2bzacecnamqgqmifpluoeldx7zzglxcljo6oja4vrmtj7432rphldpdmm2"}
+	}`), &state))
+
+	st, err := decodeMinerState(state)
+	require.NoError(t, err)
+	require.Equal(t, "1000", st.PreCommitDeposits.String())
+	require.Equal(t, "2000", st.LockedFunds.String())
+	require.Equal(t, "0", st.FeeDebt.String())
+	require.Equal(t, "3000", st.InitialPledge.String())
+	require.Equal(t, "bafy2bzacecnamqgqmifpluoeldx7zzglxcljo6oja4vrmtj7432rphldpdmm2", st.VestingFunds.String())
+}
@@ -5,11 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/OpenFilWallet/OpenFilWallet/chain"
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
 	"github.com/filecoin-project/lotus/blockstore"
 	"github.com/filecoin-project/lotus/chain/actors/adt"
 	"github.com/filecoin-project/lotus/chain/actors/builtin/multisig"
@@ -21,7 +21,6 @@ import (
 	"reflect"
 	"sort"
 	"strings"
-	"time"
 )
 
 const (
@@ -39,23 +38,28 @@ func (w *Wallet) MsigWalletList(c *gin.Context) {
 		return
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
 	defer cancel()
 
+	user := w.requestUser(c)
 	data := []client.MsigWalletListInfo{}
 	for _, ms := range msigList {
+		if !userAllowsMsig(user, ms.MsigAddr) {
+			continue
+		}
+
 		var amount = types.NewInt(0)
 		addr, _ := address.NewFromString(ms.MsigAddr)
 
 		if isBalance {
-			amount, err = w.node.Api.WalletBalance(timeoutCtx, addr)
+			amount, err = w.node().Api.WalletBalance(timeoutCtx, addr)
 			if err != nil {
 				log.Warnw("Balance: WalletBalance", "err", err.Error())
 				ReturnError(c, NewError(500, err.Error()))
 				return
 			}
 		}
-		id, err := w.node.Api.StateLookupID(timeoutCtx, addr, types.EmptyTSK)
+		id, err := w.node().Api.StateLookupID(timeoutCtx, addr, types.EmptyTSK)
 		if err != nil {
 			log.Warnw("StateLookupID", "err", err.Error())
 			ReturnError(c, NewError(500, err.Error()))
@@ -84,7 +88,7 @@ func (w *Wallet) MsigCreate(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	msig := buildmessage.NewMsiger(w.nonceNode())
 	msg, msgParams, err := msig.NewMsigCreateMessage(param.BaseParams, param.Required, param.Duration, param.Value, param.From, param.Signers...)
 	if err != nil {
 		log.Warnw("Msig: MsigCreate: NewMsigCreateMessage", "err", err.Error())
@@ -92,13 +96,20 @@ func (w *Wallet) MsigCreate(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigCreate: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigCreate: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -133,7 +144,7 @@ func (w *Wallet) MsigAdd(c *gin.Context) {
 			log.Warnw("Msig: MsigAdd: NewFromString", "addr", addr, "err", err.Error())
 			continue
 		}
-		signerActor, err := w.Api.StateAccountKey(context.Background(), msigSignerAddr, types.EmptyTSK)
+		signerActor, err := w.node().Api.StateAccountKey(context.Background(), msigSignerAddr, types.EmptyTSK)
 		if err != nil {
 			log.Warnw("Msig: MsigAdd: StateAccountKey", "err", err.Error())
 			continue
@@ -195,46 +206,55 @@ func (w *Wallet) MsigUpdate(c *gin.Context) {
 }
 
 func (w *Wallet) inquireMsigInfo(msigAddress string) (*datastore.MsigWallet, *client.Response) {
-	msigAddr, err := address.NewFromString(msigAddress)
+	msigWallet, err := loadMsigWallet(context.Background(), w.node().Api, msigAddress)
 	if err != nil {
 		return nil, NewError(500, err.Error())
 	}
 
-	ctx := context.Background()
-	head, err := w.Api.ChainHead(ctx)
+	return msigWallet, nil
+}
+
+// loadMsigWallet reads the signers, threshold and vesting of the msig from its state on chain
+func loadMsigWallet(ctx context.Context, node api.FullNode, msigAddress string) (*datastore.MsigWallet, error) {
+	msigAddr, err := address.NewFromString(msigAddress)
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
 	}
 
-	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(w.Api)))
-	act, err := w.Api.StateGetActor(ctx, msigAddr, head.Key())
+	head, err := node.ChainHead(ctx)
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
+	}
+
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(node)))
+	act, err := node.StateGetActor(ctx, msigAddr, head.Key())
+	if err != nil {
+		return nil, err
 	}
 
 	mstate, err := multisig.Load(store, act)
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
 	}
 
 	se, err := mstate.StartEpoch()
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
 	}
 
 	ud, err := mstate.UnlockDuration()
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
 	}
 
 	signers, err := mstate.Signers()
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
 	}
 
 	threshold, err := mstate.Threshold()
 	if err != nil {
-		return nil, NewError(500, err.Error())
+		return nil, err
 	}
 
 	return &datastore.MsigWallet{
@@ -246,6 +266,69 @@ func (w *Wallet) inquireMsigInfo(msigAddress string) (*datastore.MsigWallet, *cl
 	}, nil
 }
 
+// findMsig is the stored msig at addr, which may be the ID or the robust address the msig was stored with
+func findMsig(ctx context.Context, node api.FullNode, db datastore.WalletDB, addr string) (*datastore.MsigWallet, error) {
+	if msig, err := db.GetMsig(addr); err == nil {
+		return msig, nil
+	}
+
+	msigAddr, err := address.NewFromString(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	msigs, err := db.MsigWalletList()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range msigs {
+		stored, err := address.NewFromString(msigs[i].MsigAddr)
+		if err != nil {
+			continue
+		}
+
+		if same, err := sameActor(ctx, node, stored, msigAddr); err == nil && same {
+			return &msigs[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("msig %s is not stored", addr)
+}
+
+// sameActor reports whether a and b are addresses of the same actor
+func sameActor(ctx context.Context, node api.FullNode, a, b address.Address) (bool, error) {
+	if a == b {
+		return true, nil
+	}
+
+	idA, err := node.StateLookupID(ctx, a, types.EmptyTSK)
+	if err != nil {
+		return false, err
+	}
+
+	idB, err := node.StateLookupID(ctx, b, types.EmptyTSK)
+	if err != nil {
+		return false, err
+	}
+
+	return idA == idB, nil
+}
+
+// refreshMsigWallet reloads the signers and the threshold of the stored msig from the chain
+func refreshMsigWallet(ctx context.Context, node api.FullNode, db datastore.WalletDB, msig *datastore.MsigWallet) (*datastore.MsigWallet, error) {
+	msigWallet, err := loadMsigWallet(ctx, node, msig.MsigAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.UpdateMsig(msigWallet); err != nil {
+		return nil, err
+	}
+
+	return msigWallet, nil
+}
+
 // MsigInspect Get
 func (w *Wallet) MsigInspect(c *gin.Context) {
 	msigAddress, ok := c.GetQuery("msig_address")
@@ -263,15 +346,16 @@ func (w *Wallet) MsigInspect(c *gin.Context) {
 	}
 
 	ctx := context.Background()
-	head, err := w.Api.ChainHead(ctx)
+	fullNode := w.node().Api
+	head, err := fullNode.ChainHead(ctx)
 	if err != nil {
 		log.Warnw("Msig: MsigInspect: ChainHead", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(w.Api)))
-	act, err := w.Api.StateGetActor(ctx, msigAddr, head.Key())
+	store := adt.WrapStore(ctx, cbor.NewCborStore(blockstore.NewAPIBlockstore(fullNode)))
+	act, err := fullNode.StateGetActor(ctx, msigAddr, head.Key())
 	if err != nil {
 		log.Warnw("Msig: MsigInspect: StateGetActor", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
@@ -358,27 +442,31 @@ func (w *Wallet) MsigInspect(c *gin.Context) {
 		for _, txid := range txids {
 			tx := pending[txid]
 
-			targAct, err := w.Api.StateGetActor(ctx, tx.To, types.EmptyTSK)
+			targAct, err := fullNode.StateGetActor(ctx, tx.To, types.EmptyTSK)
 			paramStr := fmt.Sprintf("%x", tx.Params)
 
 			if err != nil {
 				if tx.Method == 0 {
 					transactions = append(transactions, client.MsigTransaction{
-						Txid:     txid,
-						To:       tx.To.String(),
-						Value:    tx.Value.String(),
-						Method:   fmt.Sprintf("Send(%d)", tx.Method),
-						Params:   "",
-						Approved: addr2Str(tx.Approved),
+						Txid:      txid,
+						To:        tx.To.String(),
+						Value:     tx.Value.String(),
+						Method:    fmt.Sprintf("Send(%d)", tx.Method),
+						MethodNum: uint64(tx.Method),
+						Params:    "",
+						RawParams: paramStr,
+						Approved:  addr2Str(tx.Approved),
 					})
 				} else {
 					transactions = append(transactions, client.MsigTransaction{
-						Txid:     txid,
-						To:       tx.To.String(),
-						Value:    tx.Value.String(),
-						Method:   fmt.Sprintf("unknown method(%d)", tx.Method),
-						Params:   paramStr,
-						Approved: addr2Str(tx.Approved),
+						Txid:      txid,
+						To:        tx.To.String(),
+						Value:     tx.Value.String(),
+						Method:    fmt.Sprintf("unknown method(%d)", tx.Method),
+						MethodNum: uint64(tx.Method),
+						Params:    paramStr,
+						RawParams: paramStr,
+						Approved:  addr2Str(tx.Approved),
 					})
 				}
 			} else {
@@ -402,12 +490,14 @@ func (w *Wallet) MsigInspect(c *gin.Context) {
 					paramStr = string(b)
 				}
 				transactions = append(transactions, client.MsigTransaction{
-					Txid:     txid,
-					To:       tx.To.String(),
-					Value:    tx.Value.String(),
-					Method:   fmt.Sprintf("%s(%d)", method.Name, tx.Method),
-					Params:   paramStr,
-					Approved: addr2Str(tx.Approved),
+					Txid:      txid,
+					To:        tx.To.String(),
+					Value:     tx.Value.String(),
+					Method:    fmt.Sprintf("%s(%d)", method.Name, tx.Method),
+					MethodNum: uint64(tx.Method),
+					Params:    paramStr,
+					RawParams: fmt.Sprintf("%x", tx.Params),
+					Approved:  addr2Str(tx.Approved),
 				})
 			}
 		}
@@ -427,8 +517,15 @@ func (w *Wallet) MsigApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
-	msg, msgParams, err := msig.NewMsigApproveMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigApproveMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From, param.Expect)
 	if err != nil {
 		log.Warnw("Msig: MsigApprove: NewMsigApproveMessage", "err", err.Error())
 		if strings.Contains(err.Error(), ApproveErr) {
@@ -440,13 +537,20 @@ func (w *Wallet) MsigApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -460,8 +564,15 @@ func (w *Wallet) MsigCancel(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
-	msg, msgParams, err := msig.NewMsigCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigCancel: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From, param.Expect)
 	if err != nil {
 		log.Warnw("Msig: MsigCancel: NewMsigCancelMessage", "err", err.Error())
 		if strings.Contains(err.Error(), CancelErr) {
@@ -472,13 +583,20 @@ func (w *Wallet) MsigCancel(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigCancel: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigCancel: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -492,7 +610,14 @@ func (w *Wallet) MsigTransferPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigTransferPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigTransferProposeMessage(param.BaseParams, param.MsigAddress, param.DestinationAddress, param.Amount, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigTransferPropose: NewMsigTransferProposeMessage", "err", err.Error())
@@ -500,13 +625,20 @@ func (w *Wallet) MsigTransferPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigTransferPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigTransferPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -520,21 +652,35 @@ func (w *Wallet) MsigTransferApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
-	msg, msgParams, err := msig.NewMsigTransferApproveMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigTransferApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigTransferApproveMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From, param.Expect)
 	if err != nil {
 		log.Warnw("Msig: MsigTransferApprove: NewMsigTransferApproveMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigTransferApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigTransferApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -548,21 +694,35 @@ func (w *Wallet) MsigTransferCancel(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
-	msg, msgParams, err := msig.NewMsigTransferCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigTransferCancel: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigTransferCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.From, param.Expect)
 	if err != nil {
 		log.Warnw("Msig: MsigTransferCancel: NewMsigTransferCancelMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigTransferCancel: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigTransferCancel: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -576,7 +736,14 @@ func (w *Wallet) MsigAddPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigAddPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigAddSignerProposeMessage(param.BaseParams, param.MsigAddress, param.SignerAddress, param.IncreaseThreshold, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigAddPropose: NewMsigAddSignerProposeMessage", "err", err.Error())
@@ -584,13 +751,20 @@ func (w *Wallet) MsigAddPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigAddPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigAddPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -604,7 +778,14 @@ func (w *Wallet) MsigAddApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigAddApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigAddSignerApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.SignerAddress, param.IncreaseThreshold, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigAddApprove: NewMsigAddSignerApproveMessage", "err", err.Error())
@@ -612,13 +793,20 @@ func (w *Wallet) MsigAddApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigAddApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigAddApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -632,7 +820,14 @@ func (w *Wallet) MsigAddCancel(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigAddCancel: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigAddSignerCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.SignerAddress, param.IncreaseThreshold, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigAddCancel: NewMsigAddSignerCancelMessage", "err", err.Error())
@@ -640,17 +835,148 @@ func (w *Wallet) MsigAddCancel(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigAddCancel: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigAddCancel: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, myMsg)
+}
+
+// MsigRemovePropose Post
+func (w *Wallet) MsigRemovePropose(c *gin.Context) {
+	param := client.MsigRemoveSignerProposeRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("Msig: MsigRemovePropose: BindJSON", "err", err.Error())
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigRemovePropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigRemoveSignerProposeMessage(param.BaseParams, param.MsigAddress, param.SignerAddress, param.DecreaseThreshold, param.From)
+	if err != nil {
+		log.Warnw("Msig: MsigRemovePropose: NewMsigRemoveSignerProposeMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
+	if err != nil {
+		log.Warnw("Msig: MsigRemovePropose: EncodeMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigRemovePropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, myMsg)
+}
+
+// MsigRemoveApprove Post
+func (w *Wallet) MsigRemoveApprove(c *gin.Context) {
+	param := client.MsigRemoveSignerApproveRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveApprove: BindJSON", "err", err.Error())
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigRemoveSignerApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.SignerAddress, param.DecreaseThreshold, param.From)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveApprove: NewMsigRemoveSignerApproveMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveApprove: EncodeMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
-// todo remove signer
+// MsigRemoveCancel Post
+func (w *Wallet) MsigRemoveCancel(c *gin.Context) {
+	param := client.MsigRemoveSignerCancelRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveCancel: BindJSON", "err", err.Error())
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveCancel: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
+	msg, msgParams, err := msig.NewMsigRemoveSignerCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.SignerAddress, param.DecreaseThreshold, param.From)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveCancel: NewMsigRemoveSignerCancelMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveCancel: EncodeMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigRemoveCancel: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, myMsg)
+}
 
 // MsigSwapPropose Post
 func (w *Wallet) MsigSwapPropose(c *gin.Context) {
@@ -662,7 +988,14 @@ func (w *Wallet) MsigSwapPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigSwapPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigSwapProposeMessage(param.BaseParams, param.MsigAddress, param.OldAddress, param.NewAddress, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigSwapPropose: NewMsigSwapProposeMessage", "err", err.Error())
@@ -670,13 +1003,20 @@ func (w *Wallet) MsigSwapPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigSwapPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigSwapPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -690,7 +1030,14 @@ func (w *Wallet) MsigSwapApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigSwapApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigSwapApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.OldAddress, param.NewAddress, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigSwapApprove: NewMsigSwapApproveMessage", "err", err.Error())
@@ -698,13 +1045,20 @@ func (w *Wallet) MsigSwapApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigSwapApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigSwapApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -718,7 +1072,14 @@ func (w *Wallet) MsigSwapCancel(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigSwapCancel: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigSwapCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.OldAddress, param.NewAddress, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigSwapCancel: NewMsigSwapCancelMessage", "err", err.Error())
@@ -726,13 +1087,20 @@ func (w *Wallet) MsigSwapCancel(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigSwapCancel: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigSwapCancel: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -746,7 +1114,14 @@ func (w *Wallet) MsigLockPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigLockPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigLockProposeMessage(param.BaseParams, param.MsigAddress, param.StartEpoch, param.UnlockDuration, param.Amount, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigLockPropose: NewMsigLockProposeMessage", "err", err.Error())
@@ -754,13 +1129,20 @@ func (w *Wallet) MsigLockPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigLockPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigLockPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -774,7 +1156,14 @@ func (w *Wallet) MsigLockApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigLockApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigLockApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.StartEpoch, param.UnlockDuration, param.Amount, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigLockApprove: NewMsigLockApproveMessage", "err", err.Error())
@@ -782,13 +1171,20 @@ func (w *Wallet) MsigLockApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigLockApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigLockApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -802,7 +1198,14 @@ func (w *Wallet) MsigLockCancel(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigLockCancel: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigLockCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.StartEpoch, param.UnlockDuration, param.Amount, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigLockCancel: NewMsigLockCancelMessage", "err", err.Error())
@@ -810,13 +1213,20 @@ func (w *Wallet) MsigLockCancel(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigLockCancel: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigLockCancel: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -830,7 +1240,14 @@ func (w *Wallet) MsigThresholdPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigThresholdPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigThresholdProposeMessage(param.BaseParams, param.MsigAddress, param.NewThreshold, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigThresholdPropose: NewMsigThresholdProposeMessage", "err", err.Error())
@@ -838,13 +1255,20 @@ func (w *Wallet) MsigThresholdPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigThresholdPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigThresholdPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -858,7 +1282,14 @@ func (w *Wallet) MsigThresholdApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigThresholdApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigThresholdApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.NewThreshold, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigThresholdApprove: NewMsigThresholdApproveMessage", "err", err.Error())
@@ -866,13 +1297,20 @@ func (w *Wallet) MsigThresholdApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigThresholdApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigThresholdApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -886,7 +1324,14 @@ func (w *Wallet) MsigThresholdCancel(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigThresholdCancelRequest: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigThresholdCancelMessage(param.BaseParams, param.MsigAddress, param.TxId, param.NewThreshold, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigThresholdCancelRequest: NewMsigThresholdCancelMessage", "err", err.Error())
@@ -894,13 +1339,20 @@ func (w *Wallet) MsigThresholdCancel(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigThresholdCancelRequest: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigThresholdCancelRequest: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -914,7 +1366,14 @@ func (w *Wallet) MsigChangeOwnerPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigChangeOwnerPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigChangeOwnerProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.NewOwner, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeOwnerPropose: NewMsigChangeOwnerProposeMessage", "err", err.Error())
@@ -922,13 +1381,22 @@ func (w *Wallet) MsigChangeOwnerPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeOwnerPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigChangeOwnerPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	w.startWorkflow(fullNode, datastore.WorkflowChangeOwner, param.MinerId, param.NewOwner, false)
+
 	ReturnOk(c, myMsg)
 }
 
@@ -942,7 +1410,14 @@ func (w *Wallet) MsigChangeOwnerApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigChangeOwnerApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigChangeOwnerApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.NewOwner, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeOwnerApprove: NewMsigChangeOwnerApproveMessage", "err", err.Error())
@@ -950,13 +1425,20 @@ func (w *Wallet) MsigChangeOwnerApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeOwnerApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigChangeOwnerApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -970,7 +1452,14 @@ func (w *Wallet) MsigWithdrawPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigWithdrawPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigWithdrawProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.Amount, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigWithdrawPropose: NewMsigWithdrawProposeMessage", "err", err.Error())
@@ -978,13 +1467,20 @@ func (w *Wallet) MsigWithdrawPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigWithdrawPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigWithdrawPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -998,7 +1494,14 @@ func (w *Wallet) MsigWithdrawApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigWithdrawApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigWithdrawApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.Amount, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigWithdrawApprove: NewMsigWithdrawApproveMessage", "err", err.Error())
@@ -1006,13 +1509,20 @@ func (w *Wallet) MsigWithdrawApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigWithdrawApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigWithdrawApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1026,7 +1536,14 @@ func (w *Wallet) MsigChangeWorkerPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigChangeWorkerPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigChangeWorkerProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.NewWorker, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeWorkerPropose: NewMsigChangeWorkerProposeMessage", "err", err.Error())
@@ -1034,13 +1551,22 @@ func (w *Wallet) MsigChangeWorkerPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeWorkerPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigChangeWorkerPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	w.startWorkflow(fullNode, datastore.WorkflowChangeWorker, param.MinerId, param.NewWorker, false)
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1054,7 +1580,14 @@ func (w *Wallet) MsigChangeWorkerApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigChangeWorkerApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigChangeWorkerApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.NewWorker, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeWorkerApprove: NewMsigChangeWorkerApproveMessage", "err", err.Error())
@@ -1062,13 +1595,20 @@ func (w *Wallet) MsigChangeWorkerApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeWorkerApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigChangeWorkerApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1082,7 +1622,14 @@ func (w *Wallet) MsigConfirmChangeWorkerPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeWorkerPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigConfirmChangeWorkerProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.NewWorker, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeWorkerPropose: NewMsigConfirmChangeWorkerProposeMessage", "err", err.Error())
@@ -1090,13 +1637,20 @@ func (w *Wallet) MsigConfirmChangeWorkerPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeWorkerPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeWorkerPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1110,7 +1664,14 @@ func (w *Wallet) MsigConfirmChangeWorkerApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeWorkerApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigConfirmChangeWorkerApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.NewWorker, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeWorkerApprove: NewMsigConfirmChangeWorkerApproveMessage", "err", err.Error())
@@ -1118,13 +1679,20 @@ func (w *Wallet) MsigConfirmChangeWorkerApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeWorkerApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeWorkerApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1138,7 +1706,14 @@ func (w *Wallet) MsigSetControlPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigSetControlPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigSetControlProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.From, param.ControlAddrs...)
 	if err != nil {
 		log.Warnw("Msig: MsigSetControlPropose: BindJSON", "err", err.Error())
@@ -1146,13 +1721,20 @@ func (w *Wallet) MsigSetControlPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigSetControlPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigSetControlPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1166,7 +1748,14 @@ func (w *Wallet) MsigSetControlApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigSetControlApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigSetControlApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.From, param.ControlAddrs...)
 	if err != nil {
 		log.Warnw("Msig: MsigSetControlApprove: NewMsigSetControlApproveMessage", "err", err.Error())
@@ -1174,13 +1763,20 @@ func (w *Wallet) MsigSetControlApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigSetControlApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigSetControlApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1194,7 +1790,14 @@ func (w *Wallet) MsigChangeBeneficiaryPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigChangeBeneficiaryPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigChangeBeneficiaryProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.From, param.BeneficiaryAddress, param.Quota, param.Expiration, param.OverwritePendingChange)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeBeneficiaryPropose: NewMsigChangeBeneficiaryProposeMessage", "err", err.Error())
@@ -1202,13 +1805,22 @@ func (w *Wallet) MsigChangeBeneficiaryPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeBeneficiaryPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigChangeBeneficiaryPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	w.startWorkflow(fullNode, datastore.WorkflowChangeBeneficiary, param.MinerId, param.BeneficiaryAddress, false)
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1222,7 +1834,14 @@ func (w *Wallet) MsigChangeBeneficiaryApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigChangeBeneficiaryApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigChangeBeneficiaryApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.From, param.BeneficiaryAddress, param.Quota, param.Expiration)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeBeneficiaryApprove: NewMsigChangeBeneficiaryApproveMessage", "err", err.Error())
@@ -1230,13 +1849,20 @@ func (w *Wallet) MsigChangeBeneficiaryApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigChangeBeneficiaryApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigChangeBeneficiaryApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1250,7 +1876,14 @@ func (w *Wallet) MsigConfirmChangeBeneficiaryPropose(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeBeneficiaryPropose: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigConfirmChangeBeneficiaryProposeMessage(param.BaseParams, param.MsigAddress, param.MinerId, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeBeneficiaryPropose: NewMsigConfirmChangeBeneficiaryProposeMessage", "err", err.Error())
@@ -1258,13 +1891,20 @@ func (w *Wallet) MsigConfirmChangeBeneficiaryPropose(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeBeneficiaryPropose: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeBeneficiaryPropose: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
 
@@ -1278,7 +1918,14 @@ func (w *Wallet) MsigConfirmChangeBeneficiaryApprove(c *gin.Context) {
 		return
 	}
 
-	msig := buildmessage.NewMsiger(w.Api)
+	fullNode, err := w.buildNode()
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeBeneficiaryApprove: buildNode", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	msig := buildmessage.NewMsiger(fullNode)
 	msg, msgParams, err := msig.NewMsigConfirmChangeBeneficiaryApproveMessage(param.BaseParams, param.MsigAddress, param.ProposerAddress, param.TxId, param.MinerId, param.From)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeBeneficiaryApprove: NewMsigConfirmChangeBeneficiaryApproveMessage", "err", err.Error())
@@ -1286,12 +1933,19 @@ func (w *Wallet) MsigConfirmChangeBeneficiaryApprove(c *gin.Context) {
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, msgParams)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, msgParams)
 	if err != nil {
 		log.Warnw("Msig: MsigConfirmChangeBeneficiaryApprove: EncodeMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Msig: MsigConfirmChangeBeneficiaryApprove: simulateBuilt", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 }
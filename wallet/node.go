@@ -7,31 +7,27 @@ import (
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/gin-gonic/gin"
-	"time"
+	"strconv"
+	"sync/atomic"
 )
 
-var glifNodeInfo = &datastore.NodeInfo{
-	Name:     "glif",
-	Endpoint: "https://api.node.glif.io/rpc/v0",
-	Token:    "",
-}
-
 type node struct {
 	name         string
 	nodeEndpoint string
 	token        string
-	height       string
 	*client.LotusClient
 }
 
-func newNode(name, nodeEndpoint, nodeToken string) (*node, error) {
+// newNode connects to the node and checks it with ChainHead under ctx, the client is closed on failure
+func newNode(ctx context.Context, name, nodeEndpoint, nodeToken string) (*node, error) {
 	lotusClient, err := client.NewLotusClient(nodeEndpoint, nodeToken)
 	if err != nil {
 		return nil, err
 	}
 
-	head, err := lotusClient.Api.ChainHead(context.Background())
+	_, err = lotusClient.Api.ChainHead(ctx)
 	if err != nil {
+		lotusClient.Closer()
 		log.Warnw("newNode: ChainHead", "err", err)
 		return nil, fmt.Errorf("nodeEndpoint: %s is bad", nodeEndpoint)
 	}
@@ -40,11 +36,43 @@ func newNode(name, nodeEndpoint, nodeToken string) (*node, error) {
 		name,
 		nodeEndpoint,
 		nodeToken,
-		head.Height().String(),
 		lotusClient,
 	}, nil
 }
 
+func (n *node) close() {
+	if n.Closer != nil {
+		n.Closer()
+	}
+}
+
+// currentNode holds the node in use, the health checker swaps it while handlers and loops read it
+type currentNode struct {
+	p atomic.Pointer[node]
+}
+
+func newCurrentNode(n *node) *currentNode {
+	cn := &currentNode{}
+	if n != nil {
+		cn.p.Store(n)
+	}
+	return cn
+}
+
+// get returns the node in use, nil when no node was ever reachable
+func (cn *currentNode) get() *node {
+	return cn.p.Load()
+}
+
+func (cn *currentNode) set(n *node) {
+	cn.p.Store(n)
+}
+
+// replace makes n the node in use if old is, it tells whether old was in use
+func (cn *currentNode) replace(old, n *node) bool {
+	return cn.p.CompareAndSwap(old, n)
+}
+
 // NodeAdd Post
 func (w *Wallet) NodeAdd(c *gin.Context) {
 	param := client.NodeRequest{}
@@ -55,12 +83,16 @@ func (w *Wallet) NodeAdd(c *gin.Context) {
 		return
 	}
 
-	_, err = newNode(param.Name, param.Endpoint, param.Token)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	n, err := newNode(timeoutCtx, param.Name, param.Endpoint, param.Token)
 	if err != nil {
 		log.Warnw("NodeAdd: newNode", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
+	n.close()
 
 	err = w.db.SetNode(&datastore.NodeInfo{
 		Name:     param.Name,
@@ -93,17 +125,30 @@ func (w *Wallet) NodeUpdate(c *gin.Context) {
 		return
 	}
 
-	err = w.db.UpdateNode(&datastore.NodeInfo{
+	nodeInfo := datastore.NodeInfo{
 		Name:     param.Name,
 		Endpoint: param.Endpoint,
 		Token:    param.Token,
-	})
+	}
+	err = w.db.UpdateNode(&nodeInfo)
 	if err != nil {
 		log.Warnw("NodeUpdate: UpdateNode", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	// the node in use reconnects right away, the others when they are probed next
+	if w.nodeName() == param.Name {
+		err = w.switchNode(nodeInfo)
+		if err != nil {
+			log.Warnw("NodeUpdate: switchNode", "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+	} else {
+		w.nodes.drop(param.Name)
+	}
+
 	ReturnOk(c, nil)
 }
 
@@ -116,7 +161,7 @@ func (w *Wallet) NodeDelete(c *gin.Context) {
 		return
 	}
 
-	if w.node.name == param.Name {
+	if w.nodeName() == param.Name {
 		ReturnError(c, NewError(500, "unable to delete node in use"))
 		return
 	}
@@ -127,6 +172,8 @@ func (w *Wallet) NodeDelete(c *gin.Context) {
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
+	w.health.forget(param.Name)
+	w.nodes.drop(param.Name)
 
 	ReturnOk(c, nil)
 }
@@ -140,13 +187,15 @@ func (w *Wallet) UseNode(c *gin.Context) {
 		return
 	}
 
-	if w.node.name == param.Name {
-		ReturnError(c, NewError(500, "already in use"))
+	// the node chosen by hand is pinned, the health checker only leaves it while it is unhealthy
+	w.health.pin(param.Name)
+	if w.nodeName() == param.Name {
+		ReturnOk(c, nil)
 		return
 	}
 
-	nodeInfo := glifNodeInfo
-	if param.Name != glifNodeInfo.Name {
+	nodeInfo := w.defaultNodeInfo()
+	if param.Name != nodeInfo.Name {
 		nodeInfo, err = w.db.GetNode(param.Name)
 		if err != nil {
 			log.Warnw("UseNode: GetNode", "err", err)
@@ -155,119 +204,107 @@ func (w *Wallet) UseNode(c *gin.Context) {
 		}
 	}
 
-	n, err := newNode(nodeInfo.Name, nodeInfo.Endpoint, nodeInfo.Token)
+	err = w.switchNode(*nodeInfo)
 	if err != nil {
-		log.Warnw("UseNode: newNode", "err", err)
+		log.Warnw("UseNode: switchNode", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
-	w.node = n
-	w.txTracker.node = n
 
 	ReturnOk(c, nil)
 }
 
 // NodeList Get
 func (w *Wallet) NodeList(c *gin.Context) {
-	nodeInfos, err := w.db.NodeList()
+	nodeInfos, err := w.nodeInfos()
 	if err != nil {
 		log.Warnw("NodeList: NodeList", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	// Add a default node
-	nodeInfos = append(nodeInfos, *glifNodeInfo)
+	healths := w.nodes.probeAll(nodeInfos)
 
 	var nis = []client.NodeInfo{}
+	for i, ni := range nodeInfos {
+		h := healths[i]
+		w.health.record(h)
 
-	for _, ni := range nodeInfos {
-		isUsing := false
-		if ni.Name == w.node.name {
-			isUsing = true
-		}
-		n, err := newNode(ni.Name, ni.Endpoint, ni.Token)
-		if err != nil {
-			n = &node{
-				height: "0",
-			}
-		}
 		nis = append(nis, client.NodeInfo{
 			Name:        ni.Name,
 			Endpoint:    ni.Endpoint,
 			Token:       ni.Token,
-			IsUsing:     isUsing,
-			BlockHeight: n.height,
+			IsUsing:     ni.Name == w.nodeName(),
+			BlockHeight: strconv.FormatInt(h.Height, 10),
+			Version:     h.Version,
+			Network:     h.Network,
+			Latency:     h.Latency,
+			Error:       h.Error,
 		})
 	}
 
 	ReturnOk(c, nis)
-	return
 }
 
 // NodeBest Get
 func (w *Wallet) NodeBest(c *gin.Context) {
-	nodeInfo, err := w.getBestNode()
+	nodeInfo, h, err := w.getBestNode()
 	if err != nil {
 		log.Warnw("NodeBest: getBestNode", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	n, err := newNode(nodeInfo.Name, nodeInfo.Endpoint, nodeInfo.Token)
+	// asking for the best node drops the pinned one
+	w.health.pin("")
+	err = w.switchNode(*nodeInfo)
 	if err != nil {
-		log.Warnw("NodeBest: newNode", "err", err)
+		log.Warnw("NodeBest: switchNode", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
-	w.node = n
 
 	ReturnOk(c, client.NodeInfo{
 		Name:        nodeInfo.Name,
 		Endpoint:    nodeInfo.Endpoint,
 		Token:       nodeInfo.Token,
-		BlockHeight: n.height,
+		IsUsing:     true,
+		BlockHeight: strconv.FormatInt(h.Height, 10),
+		Version:     h.Version,
+		Network:     h.Network,
+		Latency:     h.Latency,
 	})
 }
 
-func (w *Wallet) getBestNode() (*datastore.NodeInfo, error) {
-	nodeInfos, err := w.db.NodeList()
+// getBestNode probes all nodes and returns the fastest one in sync,
+// or the fastest reachable one when none is in sync
+func (w *Wallet) getBestNode() (*datastore.NodeInfo, *client.NodeHealth, error) {
+	nodeInfos, err := w.nodeInfos()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// Add a default node
-	nodeInfos = append(nodeInfos, datastore.NodeInfo{
-		Name:     "glif",
-		Endpoint: "https://api.node.glif.io/rpc/v0",
-		Token:    "",
-	})
-
-	bestIndex := -1
-	bestElapsed := time.Duration(0)
-	for i, nodeInfo := range nodeInfos {
-		start := time.Now()
-		_, err = newNode(nodeInfo.Name, nodeInfo.Endpoint, nodeInfo.Token)
-		if err != nil {
-			continue
-		}
+	healths := w.nodes.probeAll(nodeInfos)
+	for _, h := range healths {
+		w.health.record(h)
+	}
 
-		elapsed := time.Since(start)
-		if bestIndex == -1 {
-			bestIndex = i
-			bestElapsed = elapsed
+	best := -1
+	for i, h := range healths {
+		if h.Error != "" {
 			continue
 		}
 
-		if elapsed < bestElapsed {
-			bestIndex = i
-			bestElapsed = elapsed
+		if best == -1 ||
+			(h.Healthy && !healths[best].Healthy) ||
+			(h.Healthy == healths[best].Healthy && h.Latency < healths[best].Latency) {
+			best = i
 		}
 	}
 
-	if bestIndex == -1 {
-		return nil, errors.New("no node available")
+	if best == -1 {
+		return nil, nil, errors.New("no node available")
 	}
 
-	return &nodeInfos[bestIndex], nil
+	return &nodeInfos[best], &healths[best], nil
 }
@@ -0,0 +1,195 @@
+package wallet
+
+import (
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/metrics"
+	"github.com/gin-gonic/gin"
+	"sync"
+	"time"
+)
+
+// nodeHealthHistory is how many probes are kept per node
+const nodeHealthHistory = 20
+
+type nodeHealth struct {
+	lk      sync.RWMutex
+	history map[string][]client.NodeHealth
+	pinned  string
+}
+
+func newNodeHealth() *nodeHealth {
+	return &nodeHealth{
+		history: make(map[string][]client.NodeHealth),
+	}
+}
+
+func (nh *nodeHealth) record(h client.NodeHealth) {
+	nh.lk.Lock()
+	defer nh.lk.Unlock()
+
+	history := append(nh.history[h.Name], h)
+	if len(history) > nodeHealthHistory {
+		history = history[len(history)-nodeHealthHistory:]
+	}
+	nh.history[h.Name] = history
+
+	metrics.NodeHealthy.WithLabelValues(h.Name).Set(metrics.Bool(h.Healthy))
+	metrics.NodeLatency.WithLabelValues(h.Name).Set(float64(h.Latency) / 1000)
+	metrics.NodeHeadLag.WithLabelValues(h.Name).Set(float64(h.HeadLag))
+}
+
+func (nh *nodeHealth) forget(name string) {
+	nh.lk.Lock()
+	defer nh.lk.Unlock()
+
+	delete(nh.history, name)
+	metrics.NodeHealthy.DeleteLabelValues(name)
+	metrics.NodeLatency.DeleteLabelValues(name)
+	metrics.NodeHeadLag.DeleteLabelValues(name)
+	if nh.pinned == name {
+		nh.pinned = ""
+	}
+}
+
+func (nh *nodeHealth) historyOf(name string) []client.NodeHealth {
+	nh.lk.RLock()
+	defer nh.lk.RUnlock()
+
+	return append([]client.NodeHealth{}, nh.history[name]...)
+}
+
+// pin makes name the preferred node, failover still happens while it is unhealthy
+func (nh *nodeHealth) pin(name string) {
+	nh.lk.Lock()
+	defer nh.lk.Unlock()
+	nh.pinned = name
+}
+
+func (nh *nodeHealth) pinnedNode() string {
+	nh.lk.RLock()
+	defer nh.lk.RUnlock()
+	return nh.pinned
+}
+
+func (w *Wallet) nodeName() string {
+	n := w.node()
+	if n == nil {
+		return ""
+	}
+	return n.name
+}
+
+// nodeInfos returns the configured nodes and the default node
+func (w *Wallet) nodeInfos() ([]datastore.NodeInfo, error) {
+	nodeInfos, err := w.db.NodeList()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(nodeInfos, *w.defaultNodeInfo()), nil
+}
+
+func (w *Wallet) healthLoop(close <-chan struct{}) {
+	for {
+		select {
+		case <-time.After(w.healthInterval()):
+			w.checkNodes()
+		case <-close:
+			w.nodes.closeAll()
+			return
+		}
+	}
+}
+
+// checkNodes probes all nodes and fails over when the node in use is unhealthy,
+// the pinned node is preferred whenever it is healthy
+func (w *Wallet) checkNodes() {
+	infos, err := w.nodeInfos()
+	if err != nil {
+		log.Warnw("checkNodes: nodeInfos", "err", err)
+		return
+	}
+
+	healths := make(map[string]client.NodeHealth, len(infos))
+	for _, h := range w.nodes.probeAll(infos) {
+		w.health.record(h)
+		healths[h.Name] = h
+	}
+
+	current := w.nodeName()
+	target := current
+	if pinned := w.health.pinnedNode(); pinned != "" && healths[pinned].Healthy {
+		target = pinned
+	} else if !healths[current].Healthy {
+		target = ""
+		for _, info := range infos {
+			h := healths[info.Name]
+			if h.Healthy && (target == "" || h.Latency < healths[target].Latency) {
+				target = info.Name
+			}
+		}
+	}
+
+	if target == "" {
+		log.Warnw("checkNodes: no healthy node available", "using", current)
+		return
+	}
+
+	if target == current {
+		return
+	}
+
+	for _, info := range infos {
+		if info.Name == target {
+			log.Infow("checkNodes: fail over", "from", current, "to", target)
+			if err := w.switchNode(info); err != nil {
+				log.Warnw("checkNodes: switchNode", "node", target, "err", err)
+			}
+			return
+		}
+	}
+}
+
+// switchNode makes the cached client of info the node of the wallet and the tracker,
+// the previous client stays cached for the health checks
+func (w *Wallet) switchNode(info datastore.NodeInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	n, err := w.nodes.get(ctx, info)
+	if err != nil {
+		return err
+	}
+
+	w.current.set(n)
+
+	return nil
+}
+
+// NodeHealth Get
+func (w *Wallet) NodeHealth(c *gin.Context) {
+	infos, err := w.nodeInfos()
+	if err != nil {
+		log.Warnw("NodeHealth: nodeInfos", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	current := w.nodeName()
+	pinned := w.health.pinnedNode()
+
+	var nhs = []client.NodeHealthInfo{}
+	for _, info := range infos {
+		nhs = append(nhs, client.NodeHealthInfo{
+			Name:     info.Name,
+			Endpoint: info.Endpoint,
+			IsUsing:  info.Name == current,
+			IsPinned: info.Name == pinned,
+			History:  w.health.historyOf(info.Name),
+		})
+	}
+
+	ReturnOk(c, nhs)
+}
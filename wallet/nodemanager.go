@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"sync"
+	"time"
+)
+
+// nodeManager caches one lotus client per node and probes the nodes in parallel
+type nodeManager struct {
+	lk      sync.Mutex
+	clients map[string]*node
+	// current is the node in use, a replaced client that is in use is swapped out before it is closed
+	current *currentNode
+
+	requestTimeout func() time.Duration
+	maxHeadLag     func() int64
+}
+
+func newNodeManager(current *currentNode, requestTimeout func() time.Duration, maxHeadLag func() int64) *nodeManager {
+	return &nodeManager{
+		clients:        make(map[string]*node),
+		current:        current,
+		requestTimeout: requestTimeout,
+		maxHeadLag:     maxHeadLag,
+	}
+}
+
+// get returns the cached client of info, a client cached with another endpoint or token is replaced and closed,
+// when it is the node in use the new client takes its place first and it is closed once the requests
+// already sent to it had their request timeout
+func (nm *nodeManager) get(ctx context.Context, info datastore.NodeInfo) (*node, error) {
+	nm.lk.Lock()
+	cached, ok := nm.clients[info.Name]
+	nm.lk.Unlock()
+
+	if ok && cached.nodeEndpoint == info.Endpoint && cached.token == info.Token {
+		return cached, nil
+	}
+
+	n, err := newNode(ctx, info.Name, info.Endpoint, info.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	if cur, ok := nm.clients[info.Name]; ok {
+		// another caller connected first, keep its client
+		if cur.nodeEndpoint == info.Endpoint && cur.token == info.Token {
+			n.close()
+			return cur, nil
+		}
+		if nm.current.replace(cur, n) {
+			time.AfterFunc(nm.requestTimeout(), cur.close)
+		} else {
+			cur.close()
+		}
+	}
+	nm.clients[info.Name] = n
+
+	return n, nil
+}
+
+// drop closes and forgets the cached client of name
+func (nm *nodeManager) drop(name string) {
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	if n, ok := nm.clients[name]; ok {
+		n.close()
+		delete(nm.clients, name)
+	}
+}
+
+func (nm *nodeManager) closeAll() {
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	for name, n := range nm.clients {
+		n.close()
+		delete(nm.clients, name)
+	}
+}
+
+// probe measures the latency, head and version of a node within the request timeout
+func (nm *nodeManager) probe(info datastore.NodeInfo) client.NodeHealth {
+	h := client.NodeHealth{
+		Name:      info.Name,
+		CheckedAt: time.Now().Unix(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), nm.requestTimeout())
+	defer cancel()
+
+	n, err := nm.get(ctx, info)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+
+	start := time.Now()
+	head, err := n.Api.ChainHead(ctx)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.Latency = time.Since(start).Milliseconds()
+	h.Height = int64(head.Height())
+	h.HeadLag = int64(time.Since(time.Unix(int64(head.MinTimestamp()), 0)) / (builtin.EpochDurationSeconds * time.Second))
+
+	version, err := n.Api.Version(ctx)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.Version = version.Version
+
+	network, err := n.Api.StateNetworkName(ctx)
+	if err != nil {
+		h.Error = err.Error()
+		return h
+	}
+	h.Network = string(network)
+
+	h.Healthy = h.HeadLag <= nm.maxHeadLag()
+	return h
+}
+
+// probeAll probes all nodes in parallel, the results are in the order of infos
+func (nm *nodeManager) probeAll(infos []datastore.NodeInfo) []client.NodeHealth {
+	healths := make([]client.NodeHealth, len(infos))
+
+	var wg sync.WaitGroup
+	for i, info := range infos {
+		wg.Add(1)
+		go func(i int, info datastore.NodeInfo) {
+			defer wg.Done()
+			healths[i] = nm.probe(info)
+		}(i, info)
+	}
+	wg.Wait()
+
+	return healths
+}
@@ -0,0 +1,303 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+	"sync"
+	"time"
+)
+
+// signedNonceHistory is how many signed nonces are kept per address
+const signedNonceHistory = 100
+
+// nonceManager hands out nonces to built messages, a nonce stays reserved until the mpool
+// moves past it or the reservation expires, so messages built before any is pushed get distinct nonces
+type nonceManager struct {
+	lk  sync.Mutex
+	db  datastore.WalletDB
+	ttl func() time.Duration
+}
+
+func newNonceManager(db datastore.WalletDB, ttl func() time.Duration) *nonceManager {
+	return &nonceManager{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// reserve reconciles the reservations of addr with the mpool and reserves the next nonce
+func (nm *nonceManager) reserve(ctx context.Context, node api.FullNode, addr address.Address) (uint64, error) {
+	mpoolNonce, err := node.MpoolGetNonce(ctx, addr)
+	if err != nil {
+		return 0, err
+	}
+
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	state, err := nm.db.GetNonceState(addr.String())
+	if err != nil {
+		return 0, err
+	}
+
+	nm.reconcile(state, mpoolNonce)
+	next := nextNonce(state, mpoolNonce)
+	state.Reserved = append(state.Reserved, datastore.NonceReservation{
+		Nonce:      next,
+		ReservedAt: time.Now().Unix(),
+	})
+
+	if err := nm.db.SetNonceState(state); err != nil {
+		return 0, err
+	}
+
+	log.Debugw("nonceManager: reserve", "address", addr, "mpoolNonce", mpoolNonce, "nonce", next)
+	return next, nil
+}
+
+// reconcile drops the reservations the mpool has moved past and the expired ones
+func (nm *nonceManager) reconcile(state *datastore.NonceState, mpoolNonce uint64) {
+	expired := time.Now().Add(-nm.ttl()).Unix()
+
+	var reserved []datastore.NonceReservation
+	for _, r := range state.Reserved {
+		if r.Nonce < mpoolNonce || r.ReservedAt < expired {
+			continue
+		}
+		reserved = append(reserved, r)
+	}
+	state.Reserved = reserved
+}
+
+func nextNonce(state *datastore.NonceState, mpoolNonce uint64) uint64 {
+	next := mpoolNonce
+	for _, r := range state.Reserved {
+		if r.Nonce >= next {
+			next = r.Nonce + 1
+		}
+	}
+
+	return next
+}
+
+// recordSigned remembers the nonce of a signed message and returns a warning
+// when another message with the same nonce was signed before
+func (nm *nonceManager) recordSigned(msg *types.Message) string {
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	state, err := nm.db.GetNonceState(msg.From.String())
+	if err != nil {
+		log.Warnw("nonceManager: GetNonceState", "address", msg.From, "err", err)
+		return ""
+	}
+
+	msgCid := msg.Cid().String()
+	var warning string
+	for _, s := range state.Signed {
+		if s.Nonce != msg.Nonce {
+			continue
+		}
+		if s.MsgCid == msgCid {
+			return ""
+		}
+		warning = fmt.Sprintf("nonce %d of %s was already signed for message %s, only one of them can land on chain", msg.Nonce, msg.From, s.MsgCid)
+	}
+
+	if warning != "" {
+		log.Warnw("nonceManager: nonce signed again", "address", msg.From, "nonce", msg.Nonce, "msgCid", msgCid)
+	}
+
+	state.Signed = append(state.Signed, datastore.SignedNonce{
+		Nonce:    msg.Nonce,
+		MsgCid:   msgCid,
+		SignedAt: time.Now().Unix(),
+	})
+	if len(state.Signed) > signedNonceHistory {
+		state.Signed = state.Signed[len(state.Signed)-signedNonceHistory:]
+	}
+
+	if err := nm.db.SetNonceState(state); err != nil {
+		log.Warnw("nonceManager: SetNonceState", "address", msg.From, "err", err)
+	}
+
+	return warning
+}
+
+// release drops the reservation of nonce, for built messages that are never pushed
+func (nm *nonceManager) release(addr string, nonce uint64) error {
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	state, err := nm.db.GetNonceState(addr)
+	if err != nil {
+		return err
+	}
+
+	var reserved []datastore.NonceReservation
+	for _, r := range state.Reserved {
+		if r.Nonce == nonce {
+			continue
+		}
+		reserved = append(reserved, r)
+	}
+	if len(reserved) == len(state.Reserved) {
+		return nil
+	}
+
+	state.Reserved = reserved
+	return nm.db.SetNonceState(state)
+}
+
+func (nm *nonceManager) reset(addr string) error {
+	nm.lk.Lock()
+	defer nm.lk.Unlock()
+
+	state, err := nm.db.GetNonceState(addr)
+	if err != nil {
+		return err
+	}
+
+	state.Reserved = nil
+	return nm.db.SetNonceState(state)
+}
+
+// nonceNode hands out the nonces of the messages it builds from the nonce manager
+type nonceNode struct {
+	api.FullNode
+	nonces *nonceManager
+}
+
+// MpoolGetNonce is only called by buildmessage for messages without an explicit nonce
+func (n *nonceNode) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
+	return n.nonces.reserve(ctx, n.FullNode, addr)
+}
+
+// releaseNonce gives back the nonce reserved for msg when the message is not going to be pushed,
+// a nonce set in the base params was never reserved
+func (w *Wallet) releaseNonce(baseParams buildmessage.BaseParams, msg *types.Message) {
+	if msg == nil || baseParams.Nonce != 0 || baseParams.ExplicitNonce {
+		return
+	}
+
+	if err := w.nonces.release(msg.From.String(), msg.Nonce); err != nil {
+		log.Warnw("releaseNonce: release", "address", msg.From, "nonce", msg.Nonce, "err", err)
+	}
+}
+
+// encodeBuilt encodes a built message, the nonce of the message is released when it cannot be encoded
+func (w *Wallet) encodeBuilt(baseParams buildmessage.BaseParams, msg *types.Message, params interface{}) (*chain.Message, error) {
+	myMsg, err := chain.EncodeMessage(msg, params)
+	if err != nil {
+		w.releaseNonce(baseParams, msg)
+		return nil, err
+	}
+
+	return myMsg, nil
+}
+
+func (w *Wallet) nonceNode() api.FullNode {
+	return &nonceNode{
+		FullNode: w.node().Api,
+		nonces:   w.nonces,
+	}
+}
+
+// Nonce Get
+func (w *Wallet) Nonce(c *gin.Context) {
+	addrStr, ok := c.GetQuery("address")
+	if !ok {
+		log.Warnw("Nonce: GetQuery", "err", "key: address does not exist")
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	addr, err := address.NewFromString(addrStr)
+	if err != nil {
+		log.Warnw("Nonce: NewFromString", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	info := client.NonceInfo{
+		Address:  addr.String(),
+		Reserved: []client.ReservedNonce{},
+		Signed:   []client.SignedNonce{},
+	}
+
+	n := w.node()
+	if n != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+		defer cancel()
+
+		act, err := n.Api.StateGetActor(ctx, addr, types.EmptyTSK)
+		if err == nil {
+			info.ChainNonce = act.Nonce
+		}
+
+		info.MpoolNonce, err = n.Api.MpoolGetNonce(ctx, addr)
+		if err != nil {
+			log.Warnw("Nonce: MpoolGetNonce", "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+	}
+
+	w.nonces.lk.Lock()
+	state, err := w.db.GetNonceState(addr.String())
+	if err == nil && n != nil {
+		w.nonces.reconcile(state, info.MpoolNonce)
+		err = w.db.SetNonceState(state)
+	}
+	w.nonces.lk.Unlock()
+	if err != nil {
+		log.Warnw("Nonce: GetNonceState", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	info.Next = nextNonce(state, info.MpoolNonce)
+	for _, r := range state.Reserved {
+		info.Reserved = append(info.Reserved, client.ReservedNonce{Nonce: r.Nonce, ReservedAt: r.ReservedAt})
+	}
+	for _, s := range state.Signed {
+		info.Signed = append(info.Signed, client.SignedNonce{Nonce: s.Nonce, MsgCid: s.MsgCid, SignedAt: s.SignedAt})
+	}
+
+	ReturnOk(c, info)
+}
+
+// NonceReset Post
+func (w *Wallet) NonceReset(c *gin.Context) {
+	param := client.NonceRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("NonceReset: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	addr, err := address.NewFromString(param.Address)
+	if err != nil {
+		log.Warnw("NonceReset: NewFromString", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	err = w.nonces.reset(addr.String())
+	if err != nil {
+		log.Warnw("NonceReset: reset", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, nil)
+}
@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type mpoolNode struct {
+	api.FullNode
+	nonce uint64
+}
+
+func (m *mpoolNode) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
+	return m.nonce, nil
+}
+
+func TestNonceManager(t *testing.T) {
+	db := datastore.NewWalletDB(dssync.MutexWrap(ds.NewMapDatastore()))
+	nm := newNonceManager(db, func() time.Duration { return time.Hour })
+	node := &mpoolNode{nonce: 5}
+	from, _ := address.NewIDAddress(1000)
+
+	// messages built before any is pushed get distinct nonces
+	for _, expected := range []uint64{5, 6, 7} {
+		nonce, err := nm.reserve(context.Background(), node, from)
+		require.NoError(t, err)
+		require.Equal(t, expected, nonce)
+	}
+
+	// the mpool moved past the pushed messages
+	node.nonce = 7
+	nonce, err := nm.reserve(context.Background(), node, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), nonce)
+
+	state, err := db.GetNonceState(from.String())
+	require.NoError(t, err)
+	require.Len(t, state.Reserved, 2)
+
+	require.NoError(t, nm.reset(from.String()))
+	nonce, err = nm.reserve(context.Background(), node, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), nonce)
+
+	// a message that is never pushed gives its nonce back
+	nonce, err = nm.reserve(context.Background(), node, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), nonce)
+	require.NoError(t, nm.release(from.String(), nonce))
+	nonce, err = nm.reserve(context.Background(), node, from)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), nonce)
+	require.NoError(t, nm.release(from.String(), 100))
+
+	msg := &types.Message{From: from, To: from, Nonce: 7, Value: types.NewInt(1)}
+	require.Empty(t, nm.recordSigned(msg))
+	require.Empty(t, nm.recordSigned(msg))
+
+	other := &types.Message{From: from, To: from, Nonce: 7, Value: types.NewInt(2)}
+	require.Contains(t, nm.recordSigned(other), msg.Cid().String())
+}
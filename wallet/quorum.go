@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/filecoin-project/lotus/api"
+)
+
+// buildNode returns the node miner and msig messages are built with,
+// nonces come from the nonce manager, in quorum mode the state is also read from other nodes and compared
+func (w *Wallet) buildNode() (api.FullNode, error) {
+	quorum, names := w.quorum()
+	if quorum <= 1 {
+		return w.nonceNode(), nil
+	}
+
+	infos, err := w.nodeInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	current := w.nodeName()
+	var peers []buildmessage.QuorumPeer
+	for _, info := range infos {
+		if len(peers) == quorum-1 {
+			break
+		}
+
+		if info.Name == current || (len(names) != 0 && !containsName(names, info.Name)) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+		n, err := w.nodes.get(ctx, info)
+		cancel()
+		if err != nil {
+			log.Warnw("buildNode: get node", "node", info.Name, "err", err)
+			continue
+		}
+
+		peers = append(peers, buildmessage.QuorumPeer{Name: info.Name, Node: n.Api})
+	}
+
+	if len(peers) < quorum-1 {
+		return nil, fmt.Errorf("quorum of %d nodes required, only %d available", quorum, len(peers)+1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	return buildmessage.NewQuorumNode(ctx, buildmessage.QuorumPeer{Name: current, Node: w.nonceNode()}, peers...)
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/stmgr"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/filecoin-project/lotus/chain/vm"
+	"github.com/ipfs/go-cid"
+)
+
+// searchMsg looks the message up on chain, a nil lookup means it is not on chain yet
+func searchMsg(n *node, msgCid string) (*api.MsgLookup, error) {
+	c, err := cid.Parse(msgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	searchRes, err := n.Api.StateSearchMsg(context.Background(), types.EmptyTSK, c, stmgr.LookbackNoLimit, true)
+	if err == nil {
+		return searchRes, nil
+	}
+
+	// For some public node services, StateSearchMsg request parameters are optimized: only one msg cid parameter is required
+	r, err := client.LotusStateSearchMsg(n.nodeEndpoint, n.token, msgCid)
+	if err != nil {
+		return nil, err
+	}
+
+	if r == nil {
+		return nil, nil
+	}
+
+	return &api.MsgLookup{
+		Message:   r.Message,
+		Receipt:   r.Receipt,
+		ReturnDec: r.ReturnDec,
+		TipSet:    r.TipSet,
+		Height:    r.Height,
+	}, nil
+}
+
+// applyLookup fills the receipt fields of h from the lookup of its message
+func applyLookup(ctx context.Context, node api.FullNode, h *datastore.History, lookup *api.MsgLookup) error {
+	execTs, err := node.ChainGetTipSet(ctx, lookup.TipSet)
+	if err != nil {
+		return err
+	}
+
+	incl, err := node.ChainGetTipSet(ctx, execTs.Parents())
+	if err != nil {
+		return err
+	}
+
+	setReceipt(h, &lookup.Receipt, lookup.Height, incl)
+
+	if lookup.ReturnDec != nil {
+		if b, err := json.Marshal(lookup.ReturnDec); err == nil {
+			h.Return = string(b)
+			return nil
+		}
+	}
+
+	h.Return = decodeHistoryReturn(ctx, node, h, lookup.Receipt.Return, lookup.TipSet)
+	return nil
+}
+
+// setReceipt fills the receipt fields of h for a message included in incl and executed at height,
+// the fee split is computed like the vm does with the base fee the message was executed with
+func setReceipt(h *datastore.History, rct *types.MessageReceipt, height abi.ChainEpoch, incl *types.TipSet) {
+	h.Epoch = int64(height)
+	h.InclusionEpoch = int64(incl.Height())
+	h.InclusionTipSet = incl.Key().String()
+	h.Timestamp = int64(incl.MinTimestamp())
+	h.ExitCode = int64(rct.ExitCode)
+	h.GasUsed = rct.GasUsed
+
+	out := vm.ComputeGasOutputs(rct.GasUsed, h.GasLimit, incl.Blocks()[0].ParentBaseFee, historyGasFeeCap(h), historyGasPremium(h), true)
+	h.BaseFeeBurn = out.BaseFeeBurn.String()
+	h.OverEstimationBurn = out.OverEstimationBurn.String()
+	h.MinerTip = out.MinerTip.String()
+
+	h.Schema = datastore.HistorySchema
+}
+
+// historyValue is the value of h in attoFIL, the records written before schema 2 only have the int64 value
+func historyValue(h *datastore.History) big.Int {
+	return historyAmount(h.AttoValue, h.Value)
+}
+
+func historyGasFeeCap(h *datastore.History) big.Int {
+	return historyAmount(h.AttoGasFeeCap, h.GasFeeCap)
+}
+
+func historyGasPremium(h *datastore.History) big.Int {
+	return historyAmount(h.AttoGasPremium, h.GasPremium)
+}
+
+func historyAmount(atto string, legacy int64) big.Int {
+	if atto != "" {
+		if amount, err := big.FromString(atto); err == nil {
+			return amount
+		}
+	}
+
+	return big.NewInt(legacy)
+}
+
+// setHistoryAmounts fills the attoFIL amounts of a record written before schema 2 from its message
+func setHistoryAmounts(ctx context.Context, node api.FullNode, h *datastore.History) error {
+	c, err := cid.Parse(h.TxCid)
+	if err != nil {
+		return err
+	}
+
+	msg, err := node.ChainGetMessage(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	h.AttoValue = msg.Value.String()
+	h.AttoGasFeeCap = msg.GasFeeCap.String()
+	h.AttoGasPremium = msg.GasPremium.String()
+	return nil
+}
+
+func decodeHistoryReturn(ctx context.Context, node api.FullNode, h *datastore.History, ret []byte, tsk types.TipSetKey) string {
+	to, err := address.NewFromString(h.To)
+	if err != nil {
+		return ""
+	}
+
+	return buildmessage.DecodeReturn(ctx, node, to, abi.MethodNum(h.Method), ret, tsk)
+}
+
+// migrateHistory fills the receipt fields and the attoFIL amounts of the records written before HistorySchema,
+// records whose message can not be found yet are migrated on a later start
+func (w *Wallet) migrateHistory() {
+	n := w.node()
+	if w.offline || n == nil {
+		return
+	}
+
+	historys, err := w.db.HistoryListAll()
+	if err != nil {
+		log.Warnw("migrateHistory: HistoryListAll", "err", err)
+		return
+	}
+
+	var migrated int
+	for i := range historys {
+		h := &historys[i]
+		if h.Schema >= datastore.HistorySchema || h.TxCid == "" {
+			continue
+		}
+
+		if h.AttoValue == "" {
+			ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+			err = setHistoryAmounts(ctx, n.Api, h)
+			cancel()
+			if err != nil {
+				log.Debugw("migrateHistory: setHistoryAmounts", "cid", h.TxCid, "err", err)
+				continue
+			}
+		}
+
+		if h.Schema < datastore.HistoryReceiptSchema {
+			lookup, err := searchMsg(n, h.TxCid)
+			if err != nil || lookup == nil {
+				log.Debugw("migrateHistory: searchMsg", "cid", h.TxCid, "err", err)
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+			err = applyLookup(ctx, n.Api, h, lookup)
+			cancel()
+			if err != nil {
+				log.Warnw("migrateHistory: applyLookup", "cid", h.TxCid, "err", err)
+				continue
+			}
+
+			if h.Direction == "" {
+				h.Direction = datastore.Outgoing
+			}
+			if h.TxState == datastore.Pending {
+				h.TxState = datastore.Success
+				if lookup.Receipt.ExitCode.IsError() {
+					h.TxState = datastore.Failed
+					h.Detail = fmt.Sprintf("ExitCode: %d", lookup.Receipt.ExitCode)
+				}
+			}
+		}
+		h.Schema = datastore.HistorySchema
+
+		if err := w.db.UpdateHistory(h); err != nil {
+			log.Warnw("migrateHistory: UpdateHistory", "cid", h.TxCid, "err", err)
+			continue
+		}
+		migrated++
+	}
+
+	if migrated != 0 {
+		log.Infow("migrateHistory: migrated history records", "count", migrated, "schema", datastore.HistorySchema)
+	}
+}
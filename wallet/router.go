@@ -1,20 +1,23 @@
 package wallet
 
 import (
+	"fmt"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
 	"github.com/gin-gonic/gin"
-	"strings"
 )
 
-func (w *Wallet) NewRouter() *gin.Engine {
+func (w *Wallet) NewRouter() (*gin.Engine, error) {
 	r := gin.New()
 	r.Use(Cors())
 	r.Use(Recovery())
+	r.Use(RouteMetadata())
+	r.Use(w.TraceLogger())
+	r.Use(w.MustUnseal())
 	r.Use(w.MustUnlock())
 	r.Use(w.MustHaveNode())
 	r.Use(w.IfOfflineWallet())
 	r.Use(w.JWT())
-	r.Use(w.TraceLogger())
+	r.Use(w.UserScope())
 
 	r.GET("/getRouters", w.GetRouters)
 
@@ -22,6 +25,7 @@ func (w *Wallet) NewRouter() *gin.Engine {
 
 	r.POST("/login", w.Login)
 	r.POST("/logout", w.Logout)
+	r.POST("/unseal", w.Unseal)
 
 	r.POST("/chain/decode", w.Decode)
 	r.POST("/chain/encode", w.Encode)
@@ -32,6 +36,7 @@ func (w *Wallet) NewRouter() *gin.Engine {
 	r.POST("/node/use_node", w.UseNode)
 	r.GET("/node/list", w.NodeList)
 	r.GET("/node/best", w.NodeBest)
+	r.GET("/node/health", w.NodeHealth)
 
 	r.POST("/wallet/create", w.WalletCreate)
 	r.GET("/wallet/list", w.WalletList)
@@ -47,18 +52,42 @@ func (w *Wallet) NewRouter() *gin.Engine {
 
 	r.POST("/send", w.Send)
 
+	r.POST("/fee/estimate", w.FeeEstimate)
+
+	r.GET("/nonce", w.Nonce)
+	r.POST("/nonce/reset", w.NonceReset)
+
 	r.GET("/tx_history", w.TxHistory)
 
+	r.GET("/watch/list", w.WatchList)
+	r.POST("/watch/add", w.WatchAdd)
+	r.POST("/watch/remove", w.WatchRemove)
+	r.GET("/indexer/status", w.IndexerStatus)
+
+	r.GET("/events", w.Events)
+
+	r.GET("/metrics", w.Metrics)
+
 	r.POST("/sign_msg", w.SignMsg)
 	r.POST("/sign", w.Sign)
 	r.POST("/sign_send", w.SignAndSend)
+	r.POST("/simulate", w.Simulate)
 
 	r.POST("/miner/withdraw", w.Withdraw)
+	r.POST("/miner/create", w.CreateMiner)
 	r.POST("/miner/change_owner", w.ChangeOwner)
 	r.POST("/miner/change_worker", w.ChangeWorker)
 	r.POST("/miner/confirm_change_worker", w.ConfirmChangeWorker)
 	r.POST("/miner/change_control", w.ChangeControl)
 	r.GET("/miner/control_list", w.ControlList)
+	r.GET("/miner/info", w.MinerInfo)
+	r.GET("/miner/vesting", w.MinerVesting)
+	r.GET("/automation/rules", w.AutomationRules)
+	r.POST("/automation/rules/set", w.AutomationRuleSet)
+	r.POST("/automation/rules/remove", w.AutomationRuleRemove)
+	r.GET("/automation/runs", w.AutomationRuns)
+	r.GET("/workflow/list", w.WorkflowList)
+	r.POST("/workflow/cancel", w.WorkflowCancel)
 	r.POST("/miner/change_beneficiary", w.ChangeBeneficiary)
 	r.POST("/miner/confirm_change_beneficiary", w.ConfirmChangeBeneficiary)
 
@@ -75,6 +104,9 @@ func (w *Wallet) NewRouter() *gin.Engine {
 	r.POST("/msig/add_signer_propose", w.MsigAddPropose)
 	r.POST("/msig/add_signer_approve", w.MsigAddApprove)
 	r.POST("/msig/add_signer_cancel", w.MsigAddCancel)
+	r.POST("/msig/remove_signer_propose", w.MsigRemovePropose)
+	r.POST("/msig/remove_signer_approve", w.MsigRemoveApprove)
+	r.POST("/msig/remove_signer_cancel", w.MsigRemoveCancel)
 	r.POST("/msig/swap_propose", w.MsigSwapPropose)
 	r.POST("/msig/swap_approve", w.MsigSwapApprove)
 	r.POST("/msig/swap_cancel", w.MsigSwapCancel)
@@ -99,85 +131,181 @@ func (w *Wallet) NewRouter() *gin.Engine {
 	r.POST("/msig/confirm_change_beneficiary_propose", w.MsigConfirmChangeBeneficiaryPropose)
 	r.POST("/msig/confirm_change_beneficiary_approve", w.MsigConfirmChangeBeneficiaryApprove)
 
-	return r
+	if err := checkRouteMeta(r.Routes()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// RouteMeta describes what a route requires, the middlewares look it up by gin's FullPath
+type RouteMeta struct {
+	// Perm is the permission the token must carry, routes without Perm need no token
+	Perm app.Permission
+	// NeedUnlock requires the wallet to be unlocked by login
+	NeedUnlock bool
+	// NeedNode requires a lotus node to be available
+	NeedNode bool
+	// AllowOffline allows the route on an offline wallet
+	AllowOffline bool
+	// Sensitive keeps the request and response bodies out of the trace log
+	Sensitive bool
+}
+
+var (
+	publicRoute = RouteMeta{AllowOffline: true}
+	readRoute   = RouteMeta{Perm: app.PermRead, NeedUnlock: true, AllowOffline: true}
+	writeRoute  = RouteMeta{Perm: app.PermWrite, NeedUnlock: true, AllowOffline: true}
+	signRoute   = RouteMeta{Perm: app.PermSign, NeedUnlock: true, AllowOffline: true}
+	// metricsRoute is scraped with a read token and keeps working while the wallet is locked
+	metricsRoute = RouteMeta{Perm: app.PermRead, AllowOffline: true}
+)
+
+func (m RouteMeta) withNode() RouteMeta {
+	m.NeedNode = true
+	return m
+}
+
+func (m RouteMeta) onlineOnly() RouteMeta {
+	m.AllowOffline = false
+	return m
+}
+
+func (m RouteMeta) sensitive() RouteMeta {
+	m.Sensitive = true
+	return m
+}
+
+// RouteMetaMap is keyed by the route path as registered in NewRouter
+var RouteMetaMap = map[string]RouteMeta{
+	"/getRouters":                              RouteMeta{NeedUnlock: true, AllowOffline: true},
+	"/status":                                  RouteMeta{Perm: app.PermRead, AllowOffline: true},
+	"/login":                                   publicRoute.sensitive(),
+	"/logout":                                  publicRoute,
+	"/unseal":                                  publicRoute.sensitive(),
+	"/chain/decode":                            readRoute,
+	"/chain/encode":                            readRoute,
+	"/node/add":                                writeRoute,
+	"/node/update":                             writeRoute,
+	"/node/delete":                             writeRoute,
+	"/node/use_node":                           writeRoute,
+	"/node/list":                               readRoute,
+	"/node/best":                               readRoute,
+	"/node/health":                             readRoute,
+	"/wallet/create":                           writeRoute,
+	"/wallet/list":                             readRoute.withNode(),
+	"/balance":                                 readRoute.withNode(),
+	"/eth/wallet/create":                       writeRoute,
+	"/eth/wallet/list":                         readRoute.withNode(),
+	"/eth/balance":                             readRoute.withNode(),
+	"/transfer":                                writeRoute.withNode(),
+	"/send":                                    writeRoute.withNode().onlineOnly(),
+	"/fee/estimate":                            readRoute.withNode(),
+	"/nonce":                                   readRoute,
+	"/nonce/reset":                             writeRoute,
+	"/tx_history":                              readRoute,
+	"/sign_msg":                                signRoute,
+	"/sign":                                    signRoute,
+	"/sign_send":                               signRoute.withNode().onlineOnly(),
+	"/simulate":                                readRoute.withNode(),
+	"/miner/withdraw":                          writeRoute.withNode(),
+	"/miner/change_owner":                      writeRoute.withNode(),
+	"/miner/change_worker":                     writeRoute.withNode(),
+	"/miner/confirm_change_worker":             writeRoute.withNode(),
+	"/miner/change_control":                    writeRoute.withNode(),
+	"/miner/control_list":                      readRoute.withNode(),
+	"/miner/change_beneficiary":                writeRoute.withNode(),
+	"/miner/confirm_change_beneficiary":        writeRoute.withNode(),
+	"/msig/list":                               readRoute.withNode(),
+	"/msig/inspect":                            readRoute.withNode(),
+	"/msig/create":                             writeRoute.withNode(),
+	"/msig/add":                                writeRoute.withNode(),
+	"/msig/update":                             writeRoute.withNode(),
+	"/msig/approve":                            writeRoute.withNode(),
+	"/msig/cancel":                             writeRoute.withNode(),
+	"/msig/transfer_propose":                   writeRoute.withNode(),
+	"/msig/transfer_approve":                   writeRoute.withNode(),
+	"/msig/transfer_cancel":                    writeRoute.withNode(),
+	"/msig/add_signer_propose":                 writeRoute.withNode(),
+	"/msig/add_signer_approve":                 writeRoute.withNode(),
+	"/msig/add_signer_cancel":                  writeRoute.withNode(),
+	"/msig/remove_signer_propose":              writeRoute.withNode(),
+	"/msig/remove_signer_approve":              writeRoute.withNode(),
+	"/msig/remove_signer_cancel":               writeRoute.withNode(),
+	"/msig/swap_propose":                       writeRoute.withNode(),
+	"/msig/swap_approve":                       writeRoute.withNode(),
+	"/msig/swap_cancel":                        writeRoute.withNode(),
+	"/msig/lock_propose":                       writeRoute.withNode(),
+	"/msig/lock_approve":                       writeRoute.withNode(),
+	"/msig/lock_cancel":                        writeRoute.withNode(),
+	"/msig/threshold_propose":                  writeRoute.withNode(),
+	"/msig/threshold_approve":                  writeRoute.withNode(),
+	"/msig/threshold_cancel":                   writeRoute.withNode(),
+	"/msig/change_owner_propose":               writeRoute.withNode(),
+	"/msig/change_owner_approve":               writeRoute.withNode(),
+	"/msig/withdraw_propose":                   writeRoute.withNode(),
+	"/msig/withdraw_approve":                   writeRoute.withNode(),
+	"/msig/change_worker_propose":              writeRoute.withNode(),
+	"/msig/change_worker_approve":              writeRoute.withNode(),
+	"/msig/confirm_change_worker_propose":      writeRoute.withNode(),
+	"/msig/confirm_change_worker_approve":      writeRoute.withNode(),
+	"/msig/set_control_propose":                writeRoute.withNode(),
+	"/msig/set_control_approve":                writeRoute.withNode(),
+	"/msig/change_beneficiary_propose":         writeRoute.withNode(),
+	"/msig/change_beneficiary_approve":         writeRoute.withNode(),
+	"/msig/confirm_change_beneficiary_propose": writeRoute.withNode(),
+	"/msig/confirm_change_beneficiary_approve": writeRoute.withNode(),
+	"/watch/list":                              readRoute,
+	"/watch/add":                               writeRoute,
+	"/watch/remove":                            writeRoute,
+	"/indexer/status":                          readRoute,
+	"/events":                                  readRoute,
+	"/metrics":                                 metricsRoute,
+	"/miner/info":                              readRoute.withNode(),
+	"/miner/vesting":                           readRoute.withNode(),
+	"/automation/rules":                        readRoute,
+	"/automation/rules/set":                    signRoute,
+	"/automation/rules/remove":                 writeRoute,
+	"/automation/runs":                         readRoute,
+	"/workflow/list":                           readRoute,
+	"/workflow/cancel":                         writeRoute,
+	"/miner/create":                            writeRoute.withNode(),
 }
 
-var HandlePermMap = map[string]app.Permission{
-	"/status":                                  app.PermRead,
-	"/login":                                   app.PermWrite,
-	"/logout":                                  app.PermWrite,
-	"/chain/decode":                            app.PermRead,
-	"/chain/encode":                            app.PermRead,
-	"/node/add":                                app.PermWrite,
-	"/node/update":                             app.PermWrite,
-	"/node/delete":                             app.PermWrite,
-	"/node/use_node":                           app.PermWrite,
-	"/node/list":                               app.PermRead,
-	"/node/best":                               app.PermRead,
-	"/wallet/create":                           app.PermWrite,
-	"/wallet/list":                             app.PermRead,
-	"/balance":                                 app.PermRead,
-	"/transfer":                                app.PermWrite,
-	"/tx_history":                              app.PermRead,
-	"/send":                                    app.PermWrite,
-	"/sign_msg":                                app.PermSign,
-	"/sign":                                    app.PermSign,
-	"/sign_send":                               app.PermSign,
-	"/miner/withdraw":                          app.PermWrite,
-	"/miner/change_owner":                      app.PermWrite,
-	"/miner/change_worker":                     app.PermWrite,
-	"/miner/confirm_change_worker":             app.PermWrite,
-	"/miner/change_control":                    app.PermWrite,
-	"/miner/control_list":                      app.PermRead,
-	"/miner/change_beneficiary":                app.PermWrite,
-	"/miner/confirm_change_beneficiary":        app.PermWrite,
-	"/msig/list":                               app.PermRead,
-	"/msig/add":                                app.PermWrite,
-	"/msig/update":                             app.PermWrite,
-	"/msig/inspect":                            app.PermRead,
-	"/msig/create":                             app.PermWrite,
-	"/msig/approve":                            app.PermWrite,
-	"/msig/cancel":                             app.PermWrite,
-	"/msig/transfer_propose":                   app.PermWrite,
-	"/msig/transfer_approve":                   app.PermWrite,
-	"/msig/transfer_cancel":                    app.PermWrite,
-	"/msig/add_signer_propose":                 app.PermWrite,
-	"/msig/add_signer_approve":                 app.PermWrite,
-	"/msig/add_signer_cancel":                  app.PermWrite,
-	"/msig/swap_propose":                       app.PermWrite,
-	"/msig/swap_approve":                       app.PermWrite,
-	"/msig/swap_cancel":                        app.PermWrite,
-	"/msig/lock_propose":                       app.PermWrite,
-	"/msig/lock_approve":                       app.PermWrite,
-	"/msig/lock_cancel":                        app.PermWrite,
-	"/msig/threshold_propose":                  app.PermWrite,
-	"/msig/threshold_approve":                  app.PermWrite,
-	"/msig/threshold_cancel":                   app.PermWrite,
-	"/msig/change_owner_propose":               app.PermWrite,
-	"/msig/change_owner_approve":               app.PermWrite,
-	"/msig/withdraw_propose":                   app.PermWrite,
-	"/msig/withdraw_approve":                   app.PermWrite,
-	"/msig/change_worker_propose":              app.PermWrite,
-	"/msig/change_worker_approve":              app.PermWrite,
-	"/msig/confirm_change_worker_propose":      app.PermWrite,
-	"/msig/confirm_change_worker_approve":      app.PermWrite,
-	"/msig/set_control_propose":                app.PermWrite,
-	"/msig/set_control_approve":                app.PermWrite,
-	"/msig/change_beneficiary_propose":         app.PermWrite,
-	"/msig/change_beneficiary_approve":         app.PermWrite,
-	"/msig/confirm_change_beneficiary_propose": app.PermWrite,
-	"/msig/confirm_change_beneficiary_approve": app.PermWrite,
+// checkRouteMeta makes sure every registered route has metadata and every metadata has a route
+func checkRouteMeta(routes gin.RoutesInfo) error {
+	registered := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		registered[route.Path] = struct{}{}
+		if _, ok := RouteMetaMap[route.Path]; !ok {
+			return fmt.Errorf("route %s %s has no metadata", route.Method, route.Path)
+		}
+	}
+
+	for path := range RouteMetaMap {
+		if _, ok := registered[path]; !ok {
+			return fmt.Errorf("metadata of %s has no route", path)
+		}
+	}
+
+	return nil
 }
 
-func VerifyPermission(requestUrl string, allows []app.Permission) bool {
-	for key, perm := range HandlePermMap {
-		if strings.Contains(requestUrl, key) {
-			for _, allow := range allows {
-				if allow == perm {
-					return true
-				}
-			}
+func VerifyPermission(fullPath string, allows []app.Permission) bool {
+	meta, ok := RouteMetaMap[fullPath]
+	if !ok {
+		return false
+	}
+
+	if meta.Perm == "" {
+		return true
+	}
+
+	for _, allow := range allows {
+		if allow == meta.Perm {
+			return true
 		}
 	}
+
 	return false
 }
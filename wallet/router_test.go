@@ -0,0 +1,97 @@
+package wallet
+
+import (
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
+	"github.com/gin-gonic/gin"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	logging "github.com/ipfs/go-log/v2"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewRouter(t *testing.T) {
+	w := &Wallet{}
+	_, err := w.NewRouter()
+	require.NoError(t, err)
+
+	require.Error(t, checkRouteMeta(gin.RoutesInfo{{Method: "GET", Path: "/unknown"}}))
+}
+
+func TestVerifyPermission(t *testing.T) {
+	require.True(t, VerifyPermission("/send", []app.Permission{app.PermWrite}))
+	require.False(t, VerifyPermission("/sign_send", []app.Permission{app.PermWrite}))
+	require.True(t, VerifyPermission("/sign_send", app.SignPermissions))
+	require.False(t, VerifyPermission("/unknown", app.AllPermissions))
+}
+
+func TestJWTOperatorToken(t *testing.T) {
+	app.SetSecret([]byte("secret"))
+	w := &Wallet{db: datastore.NewWalletDB(dssync.MutexWrap(ds.NewMapDatastore()))}
+
+	r := gin.New()
+	r.Use(RouteMetadata(), w.JWT())
+	var reached bool
+	r.GET("/watch/list", func(c *gin.Context) {
+		reached = true
+	})
+
+	allowed := func(token []byte) bool {
+		reached = false
+		req := httptest.NewRequest(http.MethodGet, "/watch/list", nil)
+		req.Header.Set("Authorization", "Bearer "+string(token))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		return reached
+	}
+
+	operator, err := app.AuthNewOperator(app.AllPermissions)
+	require.NoError(t, err)
+	password, err := app.AuthNew(app.SignPermissions)
+	require.NoError(t, err)
+	require.True(t, allowed(operator))
+	require.True(t, allowed(password))
+
+	require.NoError(t, w.db.SetUser(&datastore.User{Name: "alice", Role: string(app.RoleViewer)}))
+	user, err := app.AuthNewUser("alice", []app.Permission{app.PermRead})
+	require.NoError(t, err)
+
+	// the repo token keeps working once users exist, the login password tokens do not
+	require.True(t, allowed(operator))
+	require.True(t, allowed(user))
+	require.False(t, allowed(password))
+}
+
+func TestTraceLoggerSensitive(t *testing.T) {
+	require.NoError(t, logging.SetLogLevel("wallet-server", "debug"))
+	defer logging.SetLogLevel("wallet-server", "info") // nolint:errcheck
+
+	pr := logging.NewPipeReader()
+	logs := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(pr)
+		logs <- string(b)
+	}()
+
+	w := &Wallet{}
+	r := gin.New()
+	r.Use(RouteMetadata(), w.TraceLogger())
+	r.POST("/unseal", func(c *gin.Context) {})
+	r.POST("/watch/add", func(c *gin.Context) {})
+
+	// a query string must not let the master password into the log
+	for target, secret := range map[string]string{"/unseal": "unseal-password", "/unseal?x": "query-password", "/watch/add": "watch-body"} {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"master_password":"`+secret+`"}`))
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	require.NoError(t, pr.Close())
+	out := <-logs
+	require.NotContains(t, out, "unseal-password")
+	require.NotContains(t, out, "query-password")
+	require.Contains(t, out, "watch-body")
+}
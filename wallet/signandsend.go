@@ -7,7 +7,6 @@ import (
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/gin-gonic/gin"
-	"time"
 )
 
 // SignMsg Post
@@ -69,12 +68,15 @@ func (w *Wallet) Sign(c *gin.Context) {
 		return
 	}
 
+	warning := w.nonces.recordSigned(msg)
+
 	mySignedMsg, err := chain.BuildSignedMessage(&param, signedMsg.Signature)
 	if err != nil {
 		log.Warnw("Sign: BuildSignedMessage", "err", err.Error())
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
+	mySignedMsg.Warning = warning
 
 	ReturnOk(c, mySignedMsg)
 }
@@ -103,8 +105,10 @@ func (w *Wallet) SignAndSend(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	cid, err := w.Api.MpoolPush(ctx, signedMsg)
+	w.nonces.recordSigned(msg)
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	cid, err := w.node().Api.MpoolPush(ctx, signedMsg)
 	cancel()
 	if err != nil {
 		log.Warnw("SignAndSend: MpoolPush", "err", err.Error())
@@ -114,19 +118,22 @@ func (w *Wallet) SignAndSend(c *gin.Context) {
 
 	if ok := w.signer.HasSigner(signedMsg.Message.From.String()); ok {
 		w.txTracker.trackTx(&datastore.History{
-			Version:    signedMsg.Message.Version,
-			To:         signedMsg.Message.To.String(),
-			From:       signedMsg.Message.From.String(),
-			Nonce:      signedMsg.Message.Nonce,
-			Value:      signedMsg.Message.Value.Int64(),
-			GasLimit:   signedMsg.Message.GasLimit,
-			GasFeeCap:  signedMsg.Message.GasFeeCap.Int64(),
-			GasPremium: signedMsg.Message.GasPremium.Int64(),
-			Method:     uint64(signedMsg.Message.Method),
-			Params:     param.Params.Params,
-			ParamName:  param.Params.Name,
-			TxCid:      cid.String(),
-			TxState:    datastore.Pending,
+			Version:        signedMsg.Message.Version,
+			To:             signedMsg.Message.To.String(),
+			From:           signedMsg.Message.From.String(),
+			Nonce:          signedMsg.Message.Nonce,
+			Value:          signedMsg.Message.Value.Int64(),
+			GasLimit:       signedMsg.Message.GasLimit,
+			GasFeeCap:      signedMsg.Message.GasFeeCap.Int64(),
+			GasPremium:     signedMsg.Message.GasPremium.Int64(),
+			AttoValue:      signedMsg.Message.Value.String(),
+			AttoGasFeeCap:  signedMsg.Message.GasFeeCap.String(),
+			AttoGasPremium: signedMsg.Message.GasPremium.String(),
+			Method:         uint64(signedMsg.Message.Method),
+			Params:         param.Params.Params,
+			ParamName:      param.Params.Name,
+			TxCid:          cid.String(),
+			TxState:        datastore.Pending,
 		})
 	}
 
@@ -153,8 +160,8 @@ func (w *Wallet) Send(c *gin.Context) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	cid, err := w.Api.MpoolPush(ctx, signedMsg)
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	cid, err := w.node().Api.MpoolPush(ctx, signedMsg)
 	cancel()
 	if err != nil {
 		log.Warnw("Send: MpoolPush", "err", err.Error())
@@ -163,19 +170,22 @@ func (w *Wallet) Send(c *gin.Context) {
 	}
 
 	w.txTracker.trackTx(&datastore.History{
-		Version:    signedMsg.Message.Version,
-		To:         signedMsg.Message.To.String(),
-		From:       signedMsg.Message.From.String(),
-		Nonce:      signedMsg.Message.Nonce,
-		Value:      signedMsg.Message.Value.Int64(),
-		GasLimit:   signedMsg.Message.GasLimit,
-		GasFeeCap:  signedMsg.Message.GasFeeCap.Int64(),
-		GasPremium: signedMsg.Message.GasPremium.Int64(),
-		Method:     uint64(signedMsg.Message.Method),
-		Params:     param.Message.Params.Params,
-		ParamName:  param.Message.Params.Name,
-		TxCid:      cid.String(),
-		TxState:    datastore.Pending,
+		Version:        signedMsg.Message.Version,
+		To:             signedMsg.Message.To.String(),
+		From:           signedMsg.Message.From.String(),
+		Nonce:          signedMsg.Message.Nonce,
+		Value:          signedMsg.Message.Value.Int64(),
+		GasLimit:       signedMsg.Message.GasLimit,
+		GasFeeCap:      signedMsg.Message.GasFeeCap.Int64(),
+		GasPremium:     signedMsg.Message.GasPremium.Int64(),
+		AttoValue:      signedMsg.Message.Value.String(),
+		AttoGasFeeCap:  signedMsg.Message.GasFeeCap.String(),
+		AttoGasPremium: signedMsg.Message.GasPremium.String(),
+		Method:         uint64(signedMsg.Message.Method),
+		Params:         param.Message.Params.Params,
+		ParamName:      param.Message.Params.Name,
+		TxCid:          cid.String(),
+		TxState:        datastore.Pending,
 	})
 
 	ReturnOk(c, client.Response{
@@ -0,0 +1,65 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+)
+
+// Simulate Post
+func (w *Wallet) Simulate(c *gin.Context) {
+	param := chain.Message{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("Simulate: BindJSON", "err", err.Error())
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	msg, err := chain.DecodeMessage(&param)
+	if err != nil {
+		log.Warnw("Simulate: DecodeMessage", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	sim, err := buildmessage.Simulate(ctx, w.node().Api, msg)
+	if err != nil {
+		log.Warnw("Simulate: Simulate", "err", err.Error())
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, sim)
+}
+
+// simulateBuilt runs a built message through StateCall when the base params ask for it,
+// a failed simulation fails the build unless it is forced and releases the nonce of the message
+func (w *Wallet) simulateBuilt(baseParams buildmessage.BaseParams, msg *types.Message, myMsg *chain.Message) error {
+	if !baseParams.Simulate {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	sim, err := buildmessage.Simulate(ctx, w.node().Api, msg)
+	if err != nil {
+		w.releaseNonce(baseParams, msg)
+		return err
+	}
+
+	if sim.Failed() && !baseParams.Force {
+		w.releaseNonce(baseParams, msg)
+		return fmt.Errorf("simulation failed with exit code %d: %s, gas used: %d", sim.ExitCode, sim.Error, sim.GasUsed)
+	}
+
+	myMsg.Simulation = sim
+	return nil
+}
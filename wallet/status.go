@@ -10,6 +10,7 @@ import (
 func (w *Wallet) Status(c *gin.Context) {
 	ReturnOk(c, client.StatusInfo{
 		Lock:    w.lock,
+		Sealed:  w.isSealed(),
 		Offline: w.offline,
 		Version: build.Version(),
 	})
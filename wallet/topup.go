@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+)
+
+// runTopUpRule tops the worker and control addresses of the miner of rule that fell below the threshold
+// back up to the target from the funder. An address whose last top-up is still pending is skipped
+func (w *Wallet) runTopUpRule(node api.FullNode, rule datastore.AutomationRule, policy *spendingPolicy, runs []datastore.AutomationRun) []*datastore.AutomationRun {
+	fail := func(to string, err error) []*datastore.AutomationRun {
+		run := newAutomationRun(rule)
+		run.From = rule.Funder
+		run.To = to
+		run.Action = datastore.AutomationFailed
+		run.Detail = err.Error()
+		return []*datastore.AutomationRun{run}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	minerAddr, err := address.NewFromString(rule.MinerId)
+	if err != nil {
+		return fail("", err)
+	}
+
+	threshold, err := types.ParseFIL(rule.Threshold)
+	if err != nil {
+		return fail("", err)
+	}
+
+	target, err := types.ParseFIL(rule.Target)
+	if err != nil {
+		return fail("", err)
+	}
+
+	funder, err := address.NewFromString(rule.Funder)
+	if err != nil {
+		return fail("", err)
+	}
+
+	mi, err := node.StateMinerInfo(ctx, minerAddr, types.EmptyTSK)
+	if err != nil {
+		return fail("", err)
+	}
+
+	var low []address.Address
+	balances := make(map[address.Address]big.Int)
+	for _, addr := range topUpAddresses(mi) {
+		balance, err := node.WalletBalance(ctx, addr)
+		if err != nil {
+			return fail(addr.String(), err)
+		}
+
+		if balance.GreaterThanEqual(big.Int(threshold)) || w.topUpPending(runs, rule.ID, addr.String()) {
+			continue
+		}
+		low = append(low, addr)
+		balances[addr] = balance
+	}
+
+	funderKey, err := node.StateAccountKey(ctx, funder, types.EmptyTSK)
+	if err != nil {
+		return fail("", err)
+	}
+
+	funderBalance, err := node.WalletBalance(ctx, funderKey)
+	if err != nil {
+		return fail("", err)
+	}
+	defer func() {
+		w.checkFunder(rule, funderBalance)
+	}()
+
+	if len(low) == 0 {
+		return nil
+	}
+
+	if !w.signer.HasSigner(funderKey.String()) {
+		return fail("", fmt.Errorf("the wallet does not hold the key of funder %s", funderKey))
+	}
+
+	var out []*datastore.AutomationRun
+	for _, addr := range low {
+		run := newAutomationRun(rule)
+		run.From = funderKey.String()
+		run.To = addr.String()
+		run.Available = balances[addr].String()
+		out = append(out, run)
+
+		amount := policy.cap(big.Sub(big.Int(target), balances[addr]))
+		run.Amount = amount.String()
+		if amount.IsZero() {
+			run.Action = datastore.AutomationSkipped
+			run.Detail = "daily top-up limit of the spending policy is reached"
+			continue
+		}
+
+		if amount.GreaterThan(funderBalance) {
+			run.Action = datastore.AutomationFailed
+			run.Detail = fmt.Sprintf("funder balance %s is not enough for the top-up", types.FIL(funderBalance))
+			continue
+		}
+
+		baseParams := buildmessage.BaseParams{FeePreset: rule.FeePreset}
+		msg, err := buildmessage.NewTransferMessage(node, baseParams, funderKey.String(), addr.String(), types.FIL(amount).String())
+		if err == nil {
+			run.Nonce = msg.Nonce
+
+			var myMsg *chain.Message
+			myMsg, err = w.encodeBuilt(baseParams, msg, nil)
+			if err == nil {
+				err = w.pushAutomated(ctx, node, msg, myMsg, run)
+			}
+		}
+		if err != nil {
+			run.Action = datastore.AutomationFailed
+			run.Detail = err.Error()
+			continue
+		}
+
+		policy.spend(amount)
+		funderBalance = big.Sub(funderBalance, amount)
+	}
+
+	return out
+}
+
+// topUpAddresses is the worker and the control addresses of the miner without duplicates
+func topUpAddresses(mi api.MinerInfo) []address.Address {
+	addrs := []address.Address{mi.Worker}
+	for _, addr := range mi.ControlAddresses {
+		dup := false
+		for _, known := range addrs {
+			if known == addr {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return addrs
+}
+
+// topUpPending reports whether the last top-up of to by the rule is still waiting to land on chain
+func (w *Wallet) topUpPending(runs []datastore.AutomationRun, ruleID, to string) bool {
+	var last *datastore.AutomationRun
+	for i, run := range runs {
+		if run.RuleID != ruleID || run.To != to || run.Action != datastore.AutomationPushed {
+			continue
+		}
+		if last == nil || run.Time > last.Time {
+			last = &runs[i]
+		}
+	}
+	if last == nil {
+		return false
+	}
+
+	h, err := w.db.GetHistory(last.From, last.Nonce)
+	if err != nil {
+		return false
+	}
+
+	return h.TxState == datastore.Pending
+}
+
+// checkFunder publishes a balance.low event when the funder of rule falls below FunderLow,
+// it is published again once the funder recovered and falls below it again
+func (w *Wallet) checkFunder(rule datastore.AutomationRule, balance big.Int) {
+	if rule.FunderLow == "" {
+		return
+	}
+
+	threshold, err := types.ParseFIL(rule.FunderLow)
+	if err != nil {
+		log.Warnw("checkFunder: ParseFIL", "rule", rule.ID, "funderLow", rule.FunderLow, "err", err)
+		return
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if balance.GreaterThanEqual(big.Int(threshold)) {
+		delete(w.lowFunders, rule.Funder)
+		return
+	}
+
+	if _, ok := w.lowFunders[rule.Funder]; ok {
+		return
+	}
+	w.lowFunders[rule.Funder] = struct{}{}
+
+	log.Warnw("checkFunder: funder balance is low", "rule", rule.ID, "funder", rule.Funder, "balance", types.FIL(balance), "threshold", types.FIL(threshold))
+	w.events.Publish(events.BalanceLow, client.BalanceEvent{
+		Address:   rule.Funder,
+		Balance:   types.FIL(balance).String(),
+		Threshold: types.FIL(threshold).String(),
+	})
+}
@@ -1,7 +1,6 @@
 package wallet
 
 import (
-	"github.com/OpenFilWallet/OpenFilWallet/chain"
 	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
 	"github.com/gin-gonic/gin"
@@ -17,20 +16,27 @@ func (w *Wallet) Transfer(c *gin.Context) {
 		return
 	}
 
-	msg, err := buildmessage.NewTransferMessage(w.node.Api, param.BaseParams, param.From, param.To, param.Amount)
+	msg, err := buildmessage.NewTransferMessage(w.nonceNode(), param.BaseParams, param.From, param.To, param.Amount)
 	if err != nil {
 		log.Warnw("Transfer: NewTransferMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
-	myMsg, err := chain.EncodeMessage(msg, nil)
+	myMsg, err := w.encodeBuilt(param.BaseParams, msg, nil)
 	if err != nil {
 		log.Warnw("Transfer: EncodeMessage", "err", err)
 		ReturnError(c, NewError(500, err.Error()))
 		return
 	}
 
+	err = w.simulateBuilt(param.BaseParams, msg, myMsg)
+	if err != nil {
+		log.Warnw("Transfer: simulateBuilt", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
 	ReturnOk(c, myMsg)
 	return
 }
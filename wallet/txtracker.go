@@ -5,30 +5,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/OpenFilWallet/OpenFilWallet/client"
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/metrics"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
 	multisig13 "github.com/filecoin-project/go-state-types/builtin/v13/multisig"
-	"github.com/filecoin-project/lotus/api"
-	"github.com/filecoin-project/lotus/chain/stmgr"
 	"github.com/filecoin-project/lotus/chain/types"
 	specsinit8 "github.com/filecoin-project/specs-actors/v8/actors/builtin/init"
-	"github.com/ipfs/go-cid"
+	specspower8 "github.com/filecoin-project/specs-actors/v8/actors/builtin/power"
 	"time"
 )
 
 type txTracker struct {
-	node       *node
-	db         datastore.WalletDB
-	txReceiver chan *datastore.History
-	close      <-chan struct{}
+	current      *currentNode
+	db           datastore.WalletDB
+	txReceiver   chan *datastore.History
+	pollInterval func() time.Duration
+	events       *events.Bus
+	close        <-chan struct{}
 }
 
-func newTxTracker(node *node, db datastore.WalletDB, close <-chan struct{}) *txTracker {
+func newTxTracker(current *currentNode, db datastore.WalletDB, bus *events.Bus, receiverBuffer int, pollInterval func() time.Duration, close <-chan struct{}) *txTracker {
 	txTracker := &txTracker{
-		node:       node,
-		db:         db,
-		txReceiver: make(chan *datastore.History, 50),
-		close:      close,
+		current:      current,
+		db:           db,
+		events:       bus,
+		txReceiver:   make(chan *datastore.History, receiverBuffer),
+		pollInterval: pollInterval,
+		close:        close,
 	}
 
 	go txTracker.txMonitor()
@@ -36,8 +41,18 @@ func newTxTracker(node *node, db datastore.WalletDB, close <-chan struct{}) *txT
 	return txTracker
 }
 
+// node returns the node in use, shared with the wallet
+func (tt *txTracker) node() *node {
+	return tt.current.get()
+}
+
 func (tt *txTracker) trackTx(msg *datastore.History) {
 	log.Infof("txTracker: trackTx: %s", msg.TxCid)
+	if msg.SubmitTime == 0 {
+		msg.SubmitTime = time.Now().Unix()
+	}
+	msg.Schema = datastore.HistorySchema
+	tt.events.Publish(events.TxPending, historyResponse(msg.From, *msg))
 	tt.txReceiver <- msg
 }
 
@@ -53,11 +68,14 @@ func (tt *txTracker) txMonitor() {
 }
 
 func (tt *txTracker) monitor(msg *datastore.History) {
+	metrics.TrackerPending.Inc()
+	defer metrics.TrackerPending.Dec()
+
 	for {
-		time.Sleep(1 * time.Minute)
+		time.Sleep(tt.pollInterval())
 
-		if tt.node == nil {
-			log.Warn("txTracker: node is nil, try again in 1 minute")
+		if tt.node() == nil {
+			log.Warnw("txTracker: node is nil, try again later", "pollInterval", tt.pollInterval().String())
 			continue
 		}
 
@@ -72,32 +90,32 @@ func (tt *txTracker) monitor(msg *datastore.History) {
 			tt.recordTx(msg)
 		}
 
-		msgCid, _ := cid.Parse(msg.TxCid)
-		searchRes, err := tt.node.Api.StateSearchMsg(context.Background(), types.EmptyTSK, msgCid, stmgr.LookbackNoLimit, true)
+		searchRes, err := searchMsg(tt.node(), msg.TxCid)
 		if err != nil {
-			// For some public node services, StateSearchMsg request parameters are optimized: only one msg cid parameter is required
-			r, err := client.LotusStateSearchMsg(tt.node.nodeEndpoint, tt.node.token, msg.TxCid)
-			if err != nil {
-				log.Warnw("txTracker: LotusStateSearchMsg", "err", err)
-				recordFailedTx(err)
-				return
-			}
-
-			if r == nil {
-				log.Debugw("txTracker: LotusStateSearchMsg: pending transaction", "cid", msg.TxCid)
-				continue
-			}
+			metrics.TrackerPollErrors.Inc()
+			log.Warnw("txTracker: searchMsg", "err", err)
+			recordFailedTx(err)
+			return
+		}
 
-			searchRes = &api.MsgLookup{
-				Message:   r.Message,
-				Receipt:   r.Receipt,
-				ReturnDec: r.ReturnDec,
-				TipSet:    r.TipSet,
-				Height:    r.Height,
-			}
+		if searchRes == nil {
+			log.Debugw("txTracker: searchMsg: pending transaction", "cid", msg.TxCid)
+			continue
 		}
 
 		if searchRes != nil {
+			msg.Direction = datastore.Outgoing
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			err = applyLookup(ctx, tt.node().Api, msg, searchRes)
+			cancel()
+			if err != nil {
+				metrics.TrackerPollErrors.Inc()
+				log.Warnw("txTracker: applyLookup", "cid", msg.TxCid, "err", err)
+				msg.Epoch = int64(searchRes.Height)
+				msg.ExitCode = int64(searchRes.Receipt.ExitCode)
+				msg.GasUsed = searchRes.Receipt.GasUsed
+			}
+
 			if searchRes.Receipt.ExitCode.IsError() {
 				log.Warnw("txTracker: Receipt", "cid", msg.TxCid, "ExitCode", searchRes.Receipt.ExitCode)
 				recordFailedTx(fmt.Errorf("ExitCode: %d", searchRes.Receipt.ExitCode))
@@ -123,7 +141,7 @@ func (tt *txTracker) monitor(msg *datastore.History) {
 
 				signers := make([]string, 0)
 				for _, signer := range p.Signers {
-					actorId, err := tt.node.Api.StateLookupID(context.Background(), signer, types.EmptyTSK)
+					actorId, err := tt.node().Api.StateLookupID(context.Background(), signer, types.EmptyTSK)
 					if err != nil {
 						log.Warnw("txTracker: StateLookupID fail", "err", err.Error())
 						recordFailedTx(err)
@@ -145,6 +163,9 @@ func (tt *txTracker) monitor(msg *datastore.History) {
 				}
 			}
 
+			tt.watchCreatedMiner(msg, searchRes.Receipt.Return)
+			tt.refreshMsig(msg, searchRes.Receipt.Return)
+
 			recordSuccessTx()
 			log.Infow("txTracker: recordSuccessTx", "cid", msg.TxCid)
 			return
@@ -153,10 +174,175 @@ func (tt *txTracker) monitor(msg *datastore.History) {
 }
 
 func (tt *txTracker) recordTx(msg *datastore.History) {
-	err := tt.db.SetHistory(msg)
+	// the indexer may have recorded the nonce already, its record of a message found on chain is kept
+	// unless the tracker found the same message too, the indexer published the event of a record it made
+	published := false
+	if indexed, err := tt.db.GetHistory(msg.From, msg.Nonce); err == nil && indexed.Epoch != 0 {
+		if indexed.TxCid != msg.TxCid || msg.Epoch == 0 {
+			log.Infow("txTracker: keep indexed record", "cid", msg.TxCid, "indexed", indexed.TxCid)
+			return
+		}
+		published = true
+		msg.Direction = indexed.Direction
+		msg.MethodName = indexed.MethodName
+		if msg.Return == "" {
+			msg.Return = indexed.Return
+		}
+	}
+	if msg.Direction == "" {
+		msg.Direction = datastore.Outgoing
+	}
+
+	err := tt.db.UpdateHistory(msg)
 	if err != nil {
 		log.Warnw("RecordTx fail", "msg", fmt.Sprintf("From: %s To: %s Method: %d", msg.From, msg.To, msg.Method), "err", err)
 	}
+
+	if !published {
+		tt.events.Publish(txEventType(msg.TxState), historyResponse(msg.From, *msg))
+	}
+}
+
+func txEventType(state datastore.MsgState) events.Type {
+	if state == datastore.Failed {
+		return events.TxFailed
+	}
+
+	return events.TxSuccess
+}
+
+// watchCreatedMiner watches the miner created by msg, directly or by a multisig proposal applied by msg.
+// The message succeeded whatever happens here so a failure is only logged
+func (tt *txTracker) watchCreatedMiner(msg *datastore.History, ret []byte) {
+	minerId, approved, err := createdMiner(msg, ret)
+	if err != nil {
+		log.Warnw("txTracker: createdMiner", "cid", msg.TxCid, "err", err)
+		return
+	}
+	if minerId == address.Undef {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// an approval does not tell which method it applied, the return is only a created miner when the multisig owns it
+	if approved {
+		msigAddr, err := address.NewFromString(msg.To)
+		if err != nil {
+			return
+		}
+
+		msigId, err := tt.node().Api.StateLookupID(ctx, msigAddr, types.EmptyTSK)
+		if err != nil {
+			log.Warnw("txTracker: watchCreatedMiner: StateLookupID", "cid", msg.TxCid, "err", err)
+			return
+		}
+
+		mi, err := tt.node().Api.StateMinerInfo(ctx, minerId, types.EmptyTSK)
+		if err != nil || mi.Owner != msigId {
+			return
+		}
+	}
+
+	err = tt.db.SetWatch(&datastore.WatchedAddress{
+		Address: minerId.String(),
+		Label:   fmt.Sprintf("created by %s", msg.TxCid),
+		AddedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Warnw("txTracker: watchCreatedMiner: SetWatch", "cid", msg.TxCid, "miner", minerId, "err", err)
+		return
+	}
+
+	log.Infow("txTracker: miner created", "cid", msg.TxCid, "miner", minerId)
+}
+
+// createdMiner is the id of the miner created by msg of return ret, address.Undef when msg created none.
+// approved reports a multisig approval whose applied method is unknown
+func createdMiner(msg *datastore.History, ret []byte) (minerId address.Address, approved bool, err error) {
+	switch msg.ParamName {
+	case "CreateMinerParams":
+	case "ProposeParams":
+		var p multisig13.ProposeParams
+		if err := json.Unmarshal([]byte(msg.Params), &p); err != nil {
+			return address.Undef, false, err
+		}
+		if p.To != builtin.StoragePowerActorAddr || p.Method != builtin.MethodsPower.CreateMiner {
+			return address.Undef, false, nil
+		}
+
+		var pr multisig13.ProposeReturn
+		if err := pr.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+			return address.Undef, false, err
+		}
+		if !pr.Applied || pr.Code.IsError() {
+			return address.Undef, false, nil
+		}
+		ret = pr.Ret
+	case "TxnIDParams":
+		if msg.Method != uint64(builtin.MethodsMultisig.Approve) {
+			return address.Undef, false, nil
+		}
+
+		var ar multisig13.ApproveReturn
+		if err := ar.UnmarshalCBOR(bytes.NewReader(ret)); err != nil || !ar.Applied || ar.Code.IsError() {
+			return address.Undef, false, nil
+		}
+		ret, approved = ar.Ret, true
+	default:
+		return address.Undef, false, nil
+	}
+
+	var cr specspower8.CreateMinerReturn
+	if err := cr.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+		if approved {
+			// the approval applied another method
+			return address.Undef, false, nil
+		}
+		return address.Undef, false, err
+	}
+
+	return cr.IDAddress, approved, nil
+}
+
+// refreshMsig reloads a stored msig once msg executed one of its transactions, which may have added,
+// removed or swapped a signer or changed the threshold
+func (tt *txTracker) refreshMsig(msg *datastore.History, ret []byte) {
+	if !msigApplied(msg, ret) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	node := tt.node().Api
+	msig, err := findMsig(ctx, node, tt.db, msg.To)
+	if err != nil {
+		return
+	}
+
+	msigWallet, err := refreshMsigWallet(ctx, node, tt.db, msig)
+	if err != nil {
+		log.Warnw("txTracker: refreshMsig: refreshMsigWallet", "cid", msg.TxCid, "msig", msg.To, "err", err)
+		return
+	}
+
+	log.Infow("txTracker: msig refreshed", "cid", msg.TxCid, "msig", msg.To, "signers", msigWallet.Signers, "threshold", msigWallet.NumApprovalsThreshold)
+}
+
+// msigApplied reports whether msg, a msig proposal or approval, executed the transaction
+func msigApplied(msg *datastore.History, ret []byte) bool {
+	switch {
+	case msg.ParamName == "ProposeParams":
+		var pr multisig13.ProposeReturn
+		return pr.UnmarshalCBOR(bytes.NewReader(ret)) == nil && pr.Applied && !pr.Code.IsError()
+	case msg.ParamName == "TxnIDParams" && msg.Method == uint64(builtin.MethodsMultisig.Approve):
+		var ar multisig13.ApproveReturn
+		return ar.UnmarshalCBOR(bytes.NewReader(ret)) == nil && ar.Applied && !ar.Code.IsError()
+	}
+
+	return false
 }
 
 func (tt *txTracker) addMsig(msig *datastore.MsigWallet) error {
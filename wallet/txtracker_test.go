@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/builtin"
+	multisig13 "github.com/filecoin-project/go-state-types/builtin/v13/multisig"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	specspower8 "github.com/filecoin-project/specs-actors/v8/actors/builtin/power"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCreatedMiner(t *testing.T) {
+	minerId, _ := address.NewIDAddress(1234)
+	robust, _ := address.NewActorAddress([]byte("miner"))
+
+	var createRet bytes.Buffer
+	require.NoError(t, (&specspower8.CreateMinerReturn{IDAddress: minerId, RobustAddress: robust}).MarshalCBOR(&createRet))
+
+	// sent directly
+	got, approved, err := createdMiner(&datastore.History{ParamName: "CreateMinerParams"}, createRet.Bytes())
+	require.NoError(t, err)
+	require.False(t, approved)
+	require.Equal(t, minerId, got)
+
+	// proposed by a multisig of one signer, applied at once
+	proposeParams, err := json.Marshal(&multisig13.ProposeParams{To: builtin.StoragePowerActorAddr, Method: builtin.MethodsPower.CreateMiner})
+	require.NoError(t, err)
+	var proposeRet bytes.Buffer
+	require.NoError(t, (&multisig13.ProposeReturn{Applied: true, Code: exitcode.Ok, Ret: createRet.Bytes()}).MarshalCBOR(&proposeRet))
+	got, _, err = createdMiner(&datastore.History{ParamName: "ProposeParams", Params: string(proposeParams)}, proposeRet.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, minerId, got)
+
+	// waiting for approvals
+	proposeRet.Reset()
+	require.NoError(t, (&multisig13.ProposeReturn{TxnID: 1}).MarshalCBOR(&proposeRet))
+	got, _, err = createdMiner(&datastore.History{ParamName: "ProposeParams", Params: string(proposeParams)}, proposeRet.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, address.Undef, got)
+
+	// the last approval applies it
+	var approveRet bytes.Buffer
+	require.NoError(t, (&multisig13.ApproveReturn{Applied: true, Code: exitcode.Ok, Ret: createRet.Bytes()}).MarshalCBOR(&approveRet))
+	got, approved, err = createdMiner(&datastore.History{ParamName: "TxnIDParams", Method: uint64(builtin.MethodsMultisig.Approve)}, approveRet.Bytes())
+	require.NoError(t, err)
+	require.True(t, approved)
+	require.Equal(t, minerId, got)
+
+	// any other message
+	got, _, err = createdMiner(&datastore.History{ParamName: "WithdrawBalanceParams"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, address.Undef, got)
+}
+
+func TestMsigApplied(t *testing.T) {
+	var applied, pending, approved bytes.Buffer
+	require.NoError(t, (&multisig13.ProposeReturn{Applied: true, Code: exitcode.Ok}).MarshalCBOR(&applied))
+	require.NoError(t, (&multisig13.ProposeReturn{TxnID: 3}).MarshalCBOR(&pending))
+	require.NoError(t, (&multisig13.ApproveReturn{Applied: true, Code: exitcode.Ok}).MarshalCBOR(&approved))
+
+	require.True(t, msigApplied(&datastore.History{ParamName: "ProposeParams"}, applied.Bytes()))
+	require.False(t, msigApplied(&datastore.History{ParamName: "ProposeParams"}, pending.Bytes()))
+	require.True(t, msigApplied(&datastore.History{ParamName: "TxnIDParams", Method: uint64(builtin.MethodsMultisig.Approve)}, approved.Bytes()))
+	// a cancel never executes the transaction
+	require.False(t, msigApplied(&datastore.History{ParamName: "TxnIDParams", Method: uint64(builtin.MethodsMultisig.Cancel)}, nil))
+}
+
+// lookupNode resolves the robust addresses of ids to their ID addresses
+type lookupNode struct {
+	api.FullNode
+	ids map[address.Address]address.Address
+}
+
+func (n *lookupNode) StateLookupID(ctx context.Context, addr address.Address, tsk types.TipSetKey) (address.Address, error) {
+	if addr.Protocol() == address.ID {
+		return addr, nil
+	}
+	if id, ok := n.ids[addr]; ok {
+		return id, nil
+	}
+	return address.Undef, fmt.Errorf("actor %s not found", addr)
+}
+
+func TestFindMsig(t *testing.T) {
+	db := datastore.NewWalletDB(dssync.MutexWrap(ds.NewMapDatastore()))
+	robust, _ := address.NewFromString("f2kn7xnbr7eddhq4z7h7ltmmg3yyatbwwkjs4oliq")
+	id, _ := address.NewIDAddress(1500)
+	other, _ := address.NewIDAddress(1501)
+	node := &lookupNode{ids: map[address.Address]address.Address{robust: id}}
+	require.NoError(t, db.SetMsig(&datastore.MsigWallet{MsigAddr: robust.String()}))
+
+	// the messages of the msig may be sent to its ID address
+	for _, addr := range []address.Address{robust, id} {
+		msig, err := findMsig(context.Background(), node, db, addr.String())
+		require.NoError(t, err)
+		require.Equal(t, robust.String(), msig.MsigAddr)
+	}
+
+	_, err := findMsig(context.Background(), node, db, other.String())
+	require.Error(t, err)
+
+	require.True(t, changesSigners(context.Background(), node, robust, &api.MsigTransaction{To: id, Method: builtin.MethodsMultisig.AddSigner}))
+	require.False(t, changesSigners(context.Background(), node, robust, &api.MsigTransaction{To: other, Method: builtin.MethodsMultisig.AddSigner}))
+	require.False(t, changesSigners(context.Background(), node, robust, &api.MsigTransaction{To: id, Method: builtin.MethodSend}))
+}
@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/account"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/crypto"
+	"github.com/gin-gonic/gin"
+)
+
+var ErrIncorrectMasterPassword = errors.New("incorrect master password")
+
+// UnsealWith verifies the master password, decrypts the keys and registers them to the signer
+func (w *Wallet) UnsealWith(masterPassword string) error {
+	w.sealLk.Lock()
+	defer w.sealLk.Unlock()
+
+	if !w.sealed {
+		return nil
+	}
+
+	masterKey, err := w.db.GetMasterPassword()
+	if err != nil {
+		return err
+	}
+
+	isOk, err := crypto.VerifyScrypt(masterPassword, masterKey)
+	if err != nil || !isOk {
+		return ErrIncorrectMasterPassword
+	}
+
+	encryptKey := crypto.GenerateEncryptKey([]byte(masterPassword))
+	if _, err := account.LoadMnemonic(w.db, encryptKey); err != nil {
+		return fmt.Errorf("failed to decrypt mnemonic, err: %s", err.Error())
+	}
+
+	keys, err := account.LoadPrivateKeys(w.db, encryptKey)
+	if err != nil {
+		log.Warnw("Unseal: LoadPrivateKeys", "err", err)
+		return err
+	}
+
+	err = w.signer.RegisterSigner(keys...)
+	if err != nil {
+		return err
+	}
+
+	ethKeys, err := account.LoadEthPrivateKeys(w.db, encryptKey)
+	if err != nil {
+		log.Warnw("Unseal: LoadEthPrivateKeys", "err", err)
+		return err
+	}
+
+	err = w.signer.RegisterEthSigner(ethKeys...)
+	if err != nil {
+		return err
+	}
+
+	w.masterPassword = masterPassword
+	w.sealed = false
+	log.Info("wallet unsealed")
+
+	return nil
+}
+
+func (w *Wallet) isSealed() bool {
+	w.sealLk.RLock()
+	defer w.sealLk.RUnlock()
+	return w.sealed
+}
+
+// Unseal Post
+func (w *Wallet) Unseal(c *gin.Context) {
+	param := client.UnsealRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("Unseal: BindJSON", "err", err.Error())
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	err = w.UnsealWith(param.MasterPassword)
+	if err == ErrIncorrectMasterPassword {
+		log.Warn("Unseal: incorrect master password")
+		ReturnError(c, AuthErr)
+		return
+	} else if err != nil {
+		log.Warnw("Unseal: UnsealWith", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, client.Response{
+		Code:    200,
+		Message: "unseal wallet success",
+	})
+}
+
+// NewUnsealRouter serves only /unseal, it is used on the local unseal socket
+func (w *Wallet) NewUnsealRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(Recovery())
+
+	r.POST("/unseal", w.Unseal)
+
+	return r
+}
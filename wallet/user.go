@@ -0,0 +1,204 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/crypto"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/app"
+	"github.com/filecoin-project/go-address"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const userKey = "user"
+
+var errUserAuth = errors.New("user verification failed")
+
+// userToken verifies the password of the named user and issues a token carrying the user
+func (w *Wallet) userToken(name, password string) ([]byte, error) {
+	user, err := w.db.GetUser(name)
+	if err != nil {
+		return nil, errUserAuth
+	}
+
+	isOk, err := crypto.VerifyScrypt(password, user.Password)
+	if err != nil || !isOk {
+		return nil, errUserAuth
+	}
+
+	perms, err := app.RolePermissions(app.Role(user.Role))
+	if err != nil {
+		return nil, err
+	}
+
+	return app.AuthNewUser(user.Name, perms)
+}
+
+// userPermissions returns the permissions of the current role of the user,
+// so that role changes and removals apply to tokens already issued
+func (w *Wallet) userPermissions(name string) ([]app.Permission, error) {
+	user, err := w.db.GetUser(name)
+	if err != nil {
+		return nil, fmt.Errorf("user %s does not exist", name)
+	}
+
+	return app.RolePermissions(app.Role(user.Role))
+}
+
+// requestUser returns the named user of the request, nil for the login password and the api socket
+func (w *Wallet) requestUser(c *gin.Context) *datastore.User {
+	name := c.GetString(userKey)
+	if name == "" {
+		return nil
+	}
+
+	user, err := w.db.GetUser(name)
+	if err != nil {
+		return nil
+	}
+
+	return user
+}
+
+// hasUsers tells whether named users exist, the login password then stops working
+func (w *Wallet) hasUsers() (bool, error) {
+	users, err := w.db.UserList()
+	if err != nil {
+		return false, err
+	}
+
+	return len(users) != 0, nil
+}
+
+// UserScope limits a named user to the addresses, miners and msigs assigned to it
+func (w *Wallet) UserScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := w.requestUser(c)
+		if user == nil || (len(user.Addresses) == 0 && len(user.Msigs) == 0) {
+			c.Next()
+			return
+		}
+
+		addrs, msigs, err := requestAddresses(c)
+		if err != nil {
+			ReturnError(c, ParamErr)
+			c.Abort()
+			return
+		}
+
+		for _, addr := range addrs {
+			if !userAllowsAddress(user, addr) {
+				ReturnError(c, NewError(505, fmt.Sprintf("user %s is not allowed to use %s", user.Name, addr)))
+				c.Abort()
+				return
+			}
+		}
+
+		for _, msig := range msigs {
+			if !userAllowsMsig(user, msig) {
+				ReturnError(c, NewError(505, fmt.Sprintf("user %s is not allowed to use %s", user.Name, msig)))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// requestAddresses collects the wallet, miner and msig addresses a request acts on,
+// the miners are checked against the addresses of the user
+func requestAddresses(c *gin.Context) ([]string, []string, error) {
+	var addrs, msigs []string
+	if c.Request.Method != http.MethodPost {
+		// the addresses may be repeated or comma separated, like the handlers read them
+		for _, key := range []string{"address", "miner_id", "actor"} {
+			addrs = append(addrs, splitQuery(c.QueryArray(key))...)
+		}
+		msigs = append(msigs, splitQuery(c.QueryArray("msig_address"))...)
+		return addrs, msigs, nil
+	}
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+
+	var param struct {
+		From        string `json:"from"`
+		MsigAddress string `json:"msig_address"`
+		MinerId     string `json:"miner_id"`
+		Owner       string `json:"owner"`
+		Funder      string `json:"funder"`
+		Message     struct {
+			From string `json:"from"`
+		} `json:"message"`
+	}
+	if len(body) != 0 {
+		if err := json.Unmarshal(body, &param); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, addr := range []string{param.From, param.Message.From, param.MinerId, param.Owner, param.Funder} {
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if param.MsigAddress != "" {
+		msigs = append(msigs, param.MsigAddress)
+	}
+
+	return addrs, msigs, nil
+}
+
+// userAllowsAddress tells whether the user may act on the wallet or miner addr, a msig of the user is allowed too
+func userAllowsAddress(user *datastore.User, addr string) bool {
+	if user == nil || (len(user.Addresses) == 0 && len(user.Msigs) == 0) {
+		return true
+	}
+
+	return containsAddress(user.Addresses, addr) || containsAddress(user.Msigs, addr)
+}
+
+func userAllowsMsig(user *datastore.User, msig string) bool {
+	if user == nil || (len(user.Addresses) == 0 && len(user.Msigs) == 0) {
+		return true
+	}
+
+	return containsAddress(user.Msigs, msig)
+}
+
+func containsAddress(addrs []string, addr string) bool {
+	for _, a := range addrs {
+		if sameAddress(a, addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameAddress(a, b string) bool {
+	if strings.EqualFold(a, b) {
+		return true
+	}
+
+	addrA, err := address.NewFromString(a)
+	if err != nil {
+		return false
+	}
+
+	addrB, err := address.NewFromString(b)
+	if err != nil {
+		return false
+	}
+
+	return addrA == addrB
+}
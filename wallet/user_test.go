@@ -0,0 +1,101 @@
+package wallet
+
+import (
+	"encoding/json"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/gin-gonic/gin"
+	ds "github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestAddresses(t *testing.T) {
+	newContext := func(method, target, body string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+		return c
+	}
+
+	addrs, msigs, err := requestAddresses(newContext(http.MethodGet, "/miner/info?miner_id=f01000,f01001&miner_id=f01002", ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"f01000", "f01001", "f01002"}, addrs)
+	require.Empty(t, msigs)
+
+	addrs, _, err = requestAddresses(newContext(http.MethodGet, "/tx_history?address=f01000&address=f01001,f01002", ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"f01000", "f01001", "f01002"}, addrs)
+
+	addrs, _, err = requestAddresses(newContext(http.MethodGet, "/miner/vesting?actor=f01003", ""))
+	require.NoError(t, err)
+	require.Equal(t, []string{"f01003"}, addrs)
+
+	addrs, msigs, err = requestAddresses(newContext(http.MethodPost, "/miner/withdraw", `{"miner_id":"f01000","from":"f1abc","msig_address":"f2abc"}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"f1abc", "f01000"}, addrs)
+	require.Equal(t, []string{"f2abc"}, msigs)
+
+	user := &datastore.User{Name: "director", Addresses: []string{"f01000"}}
+	require.True(t, userAllowsAddress(user, "f01000"))
+	require.False(t, userAllowsAddress(user, "f01001"))
+	require.True(t, userAllowsAddress(nil, "f01001"))
+}
+
+func TestEventAllowed(t *testing.T) {
+	user := &datastore.User{Addresses: []string{"f01000", "f1abc"}, Msigs: []string{"f2abc"}}
+
+	require.True(t, eventAllowed(user, events.Event{Data: client.HistoryResponse{Address: "f1abc", From: "f1other"}}))
+	require.False(t, eventAllowed(user, events.Event{Data: client.HistoryResponse{Address: "f1other", To: "f1abc"}}))
+	require.True(t, eventAllowed(user, events.Event{Data: client.MsigProposalEvent{MsigAddress: "f2abc"}}))
+	require.False(t, eventAllowed(user, events.Event{Data: client.MsigProposalEvent{MsigAddress: "f2other"}}))
+	require.True(t, eventAllowed(user, events.Event{Data: client.MinerRoleEvent{MinerId: "f01000"}}))
+	require.False(t, eventAllowed(user, events.Event{Data: client.Workflow{MinerId: "f01001"}}))
+	require.False(t, eventAllowed(user, events.Event{Data: client.BalanceEvent{Address: "f1other"}}))
+	require.False(t, eventAllowed(user, events.Event{Data: "unknown"}))
+
+	require.True(t, eventAllowed(nil, events.Event{Data: client.BalanceEvent{Address: "f1other"}}))
+	require.True(t, eventAllowed(&datastore.User{}, events.Event{Data: "unknown"}))
+}
+
+func TestTxHistoryScope(t *testing.T) {
+	db := datastore.NewWalletDB(dssync.MutexWrap(ds.NewMapDatastore()))
+	w := &Wallet{db: db, indexer: newChainIndexer(db, events.NewBus())}
+	for _, addr := range []string{"f01000", "f01001"} {
+		require.NoError(t, db.SetWatch(&datastore.WatchedAddress{Address: addr}))
+		require.NoError(t, db.SetHistory(&datastore.History{From: addr, To: "f01002", Nonce: 1, TxCid: addr}))
+	}
+	require.NoError(t, db.SetUser(&datastore.User{Name: "director", Addresses: []string{"f01000"}}))
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(userKey, "director")
+		c.Next()
+	}, w.UserScope())
+	r.GET("/tx_history", w.TxHistory)
+
+	query := func(target string) (client.HistoryPage, string) {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		var page client.HistoryPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		return page, rec.Body.String()
+	}
+
+	// without addresses only the addresses of the user are queried
+	page, _ := query("/tx_history")
+	require.Len(t, page.Records, 1)
+	require.Equal(t, "f01000", page.Records[0].TxCid)
+
+	// every repeated or comma separated address is checked
+	for _, target := range []string{"/tx_history?address=f01000&address=f01001", "/tx_history?address=f01000,f01001"} {
+		page, body := query(target)
+		require.Empty(t, page.Records)
+		require.Contains(t, body, "not allowed to use f01001")
+	}
+}
@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/go-state-types/builtin"
+	miner13 "github.com/filecoin-project/go-state-types/builtin/v13/miner"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	vestingDays    = 180
+	vestingMaxDays = 540
+)
+
+// vestingBucket is the amount unlocking in the epochs [start, end)
+type vestingBucket struct {
+	start  abi.ChainEpoch
+	end    abi.ChainEpoch
+	amount big.Int
+}
+
+// MinerVesting Get
+// MinerVesting projects the unlocks of the vesting funds of the miners in actor, repeated or comma separated,
+// per day or week over the next days (180 by default)
+func (w *Wallet) MinerVesting(c *gin.Context) {
+	var minerAddrs []address.Address
+	for _, v := range c.QueryArray("actor") {
+		for _, minerId := range strings.Split(v, ",") {
+			if minerId = strings.TrimSpace(minerId); minerId == "" {
+				continue
+			}
+
+			minerAddr, err := address.NewFromString(minerId)
+			if err != nil {
+				log.Warnw("Miner: MinerVesting: NewFromString", "minerId", minerId, "err", err)
+				ReturnError(c, ParamErr)
+				return
+			}
+			minerAddrs = append(minerAddrs, minerAddr)
+		}
+	}
+
+	interval := c.DefaultQuery("interval", "day")
+	bucketEpochs := abi.ChainEpoch(builtin.EpochsInDay)
+	switch interval {
+	case "day":
+	case "week":
+		bucketEpochs *= 7
+	default:
+		log.Warnw("Miner: MinerVesting: interval", "interval", interval)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", strconv.Itoa(vestingDays)))
+	if err != nil || days <= 0 || days > vestingMaxDays || len(minerAddrs) == 0 {
+		log.Warnw("Miner: MinerVesting: params", "days", c.Query("days"), "miners", len(minerAddrs), "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+	buckets := (abi.ChainEpoch(days)*builtin.EpochsInDay + bucketEpochs - 1) / bucketEpochs
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	fullNode := w.node().Api
+	head, err := fullNode.ChainHead(ctx)
+	if err != nil {
+		log.Warnw("Miner: MinerVesting: ChainHead", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	total := make([]big.Int, buckets)
+	for i := range total {
+		total[i] = big.Zero()
+	}
+
+	schedule := client.VestingSchedule{
+		Interval: interval,
+		Days:     days,
+		Head:     int64(head.Height()),
+	}
+	for _, minerAddr := range minerAddrs {
+		mv := client.MinerVesting{MinerId: minerAddr.String()}
+
+		funds, err := vestingFunds(ctx, fullNode, minerAddr, head.Key())
+		if err != nil {
+			log.Warnw("Miner: MinerVesting: vestingFunds", "minerId", minerAddr.String(), "err", err)
+			mv.Error = err.Error()
+			schedule.Miners = append(schedule.Miners, mv)
+			continue
+		}
+
+		locked, unlocked, projected := projectVesting(funds, head.Height(), bucketEpochs, int(buckets))
+		mv.Locked = locked.String()
+		mv.Unlocked = unlocked.String()
+		for i, b := range projected {
+			total[i] = big.Add(total[i], b.amount)
+			mv.Buckets = append(mv.Buckets, vestingResponse(b, head))
+		}
+		schedule.Miners = append(schedule.Miners, mv)
+	}
+
+	for i, amount := range total {
+		start := head.Height() + 1 + abi.ChainEpoch(i)*bucketEpochs
+		schedule.Total = append(schedule.Total, vestingResponse(vestingBucket{start: start, end: start + bucketEpochs, amount: amount}, head))
+	}
+
+	ReturnOk(c, schedule)
+}
+
+// vestingFunds loads the VestingFunds of the miner state, its encoding has not changed across the actor versions
+func vestingFunds(ctx context.Context, fullNode api.FullNode, minerAddr address.Address, tsk types.TipSetKey) ([]miner13.VestingFund, error) {
+	actorState, err := fullNode.StateReadState(ctx, minerAddr, tsk)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := decodeMinerState(actorState.State)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := fullNode.ChainReadObj(ctx, st.VestingFunds)
+	if err != nil {
+		return nil, err
+	}
+
+	var vf miner13.VestingFunds
+	if err := vf.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+
+	return vf.Funds, nil
+}
+
+// projectVesting sums the funds by the bucket of bucketEpochs epochs they unlock in, starting after head,
+// funds past the buckets only count in locked, funds at or before head are unlocked but not yet moved by the actor
+func projectVesting(funds []miner13.VestingFund, head abi.ChainEpoch, bucketEpochs abi.ChainEpoch, buckets int) (locked, unlocked big.Int, projected []vestingBucket) {
+	locked, unlocked = big.Zero(), big.Zero()
+
+	projected = make([]vestingBucket, buckets)
+	for i := range projected {
+		start := head + 1 + abi.ChainEpoch(i)*bucketEpochs
+		projected[i] = vestingBucket{start: start, end: start + bucketEpochs, amount: big.Zero()}
+	}
+
+	for _, fund := range funds {
+		if fund.Epoch <= head {
+			unlocked = big.Add(unlocked, fund.Amount)
+			continue
+		}
+
+		locked = big.Add(locked, fund.Amount)
+		i := int((fund.Epoch - head - 1) / bucketEpochs)
+		if i < buckets {
+			projected[i].amount = big.Add(projected[i].amount, fund.Amount)
+		}
+	}
+
+	return locked, unlocked, projected
+}
+
+// vestingResponse dates the bucket by the time of its first epoch
+func vestingResponse(b vestingBucket, head *types.TipSet) client.VestingBucket {
+	startTime := int64(head.MinTimestamp()) + int64(b.start-head.Height())*int64(builtin.EpochDurationSeconds)
+	return client.VestingBucket{
+		Date:       time.Unix(startTime, 0).UTC().Format("2006-01-02"),
+		StartEpoch: int64(b.start),
+		EndEpoch:   int64(b.end),
+		Amount:     b.amount.String(),
+	}
+}
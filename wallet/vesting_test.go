@@ -0,0 +1,29 @@
+package wallet
+
+import (
+	"github.com/filecoin-project/go-state-types/abi"
+	miner13 "github.com/filecoin-project/go-state-types/builtin/v13/miner"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestProjectVesting(t *testing.T) {
+	funds := []miner13.VestingFund{
+		{Epoch: 90, Amount: abi.NewTokenAmount(1)},
+		{Epoch: 100, Amount: abi.NewTokenAmount(2)},
+		{Epoch: 101, Amount: abi.NewTokenAmount(4)},
+		{Epoch: 110, Amount: abi.NewTokenAmount(8)},
+		{Epoch: 111, Amount: abi.NewTokenAmount(16)},
+		{Epoch: 500, Amount: abi.NewTokenAmount(32)},
+	}
+
+	locked, unlocked, projected := projectVesting(funds, 100, 10, 3)
+	require.Equal(t, "60", locked.String())
+	require.Equal(t, "3", unlocked.String())
+	require.Len(t, projected, 3)
+	require.Equal(t, abi.ChainEpoch(101), projected[0].start)
+	require.Equal(t, abi.ChainEpoch(111), projected[0].end)
+	require.Equal(t, "12", projected[0].amount.String())
+	require.Equal(t, "16", projected[1].amount.String())
+	require.Equal(t, "0", projected[2].amount.String())
+}
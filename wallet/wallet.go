@@ -1,78 +1,124 @@
 package wallet
 
 import (
-	"github.com/OpenFilWallet/OpenFilWallet/account"
-	"github.com/OpenFilWallet/OpenFilWallet/crypto"
+	"context"
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/config"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
 	"github.com/OpenFilWallet/OpenFilWallet/modules/messagesigner"
 	logging "github.com/ipfs/go-log/v2"
 	"sync"
+	"time"
 )
 
 var log = logging.Logger("wallet-server")
 
 type Wallet struct {
 	*login
-	*node
 	*txTracker
 
+	// current is the node in use, read it with node()
+	current *currentNode
+
 	offline bool
 
 	signer messagesigner.Signer
 
 	masterPassword string
+	sealed         bool
+	sealLk         sync.RWMutex
+
+	nodes   *nodeManager
+	health  *nodeHealth
+	nonces  *nonceManager
+	indexer *chainIndexer
+
+	events       *events.Bus
+	eventWatcher *eventWatcher
+	// lowFunders keeps the top-up funders already reported below their threshold
+	lowFunders map[string]struct{}
+
+	cfg   *config.Config
+	cfgLk sync.RWMutex
 
 	db datastore.WalletDB
 	lk sync.Mutex
 }
 
-func NewWallet(offline bool, masterPassword string, db datastore.WalletDB, close <-chan struct{}) (*Wallet, error) {
-	login := newLogin(close)
+// node returns the node in use, nil when no node was ever reachable
+func (w *Wallet) node() *node {
+	return w.current.get()
+}
 
+// NewWallet returns a sealed wallet, the keys are decrypted by Unseal
+func NewWallet(offline bool, db datastore.WalletDB, cfg *config.Config, close <-chan struct{}) (*Wallet, error) {
 	w := &Wallet{
-		offline:        offline,
-		login:          login,
-		signer:         messagesigner.NewSigner(),
-		masterPassword: masterPassword,
-		db:             db,
+		offline: offline,
+		signer:  messagesigner.NewSigner(),
+		sealed:  true,
+		health:  newNodeHealth(),
+		current: newCurrentNode(nil),
+		cfg:     cfg,
+		db:      db,
 	}
 
-	nodeInfo, err := w.getBestNode()
+	w.login = newLogin(w.lockDuration, close)
+	w.nodes = newNodeManager(w.current, w.requestTimeout, w.maxHeadLag)
+	w.nonces = newNonceManager(db, w.nonceReservation)
+	w.events = events.NewBus()
+	w.indexer = newChainIndexer(db, w.events)
+	w.eventWatcher = newEventWatcher(db, w.events)
+	w.lowFunders = make(map[string]struct{})
+
+	nodeInfo, _, err := w.getBestNode()
 	if err != nil {
 		return nil, err
 	}
 
-	n, err := newNode(nodeInfo.Name, nodeInfo.Endpoint, nodeInfo.Token)
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	n, err := w.nodes.get(ctx, *nodeInfo)
 	if err == nil {
-		w.node = n
+		w.current.set(n)
 	} else {
 		log.Warn("no nodes available")
 	}
 
-	txTracker := newTxTracker(n, db, close)
+	txTracker := newTxTracker(w.current, db, w.events, cfg.Tracker.ReceiverBuffer, w.pollInterval, close)
 	w.txTracker = txTracker
 
-	keys, err := account.LoadPrivateKeys(db, crypto.GenerateEncryptKey([]byte(masterPassword)))
-	if err != nil {
-		log.Warnw("NewWallet: LoadPrivateKeys", "err", err)
-		return nil, err
-	}
+	go w.healthLoop(close)
+	go w.indexLoop(close)
+	go w.migrateHistory()
+	go w.eventWatchLoop(close)
+	go w.metricsLoop(close)
+	go w.automationLoop(close)
+	go w.workflowLoop(close)
+	go events.NewWebhooks(w.events, w.webhookConfig).Run(close)
 
-	err = w.signer.RegisterSigner(keys...)
-	if err != nil {
-		return nil, err
-	}
-
-	ethKeys, err := account.LoadEthPrivateKeys(db, crypto.GenerateEncryptKey([]byte(masterPassword)))
-	if err != nil {
-		log.Warnw("NewWallet: LoadEthPrivateKeys", "err", err)
-		return nil, err
-	}
+	return w, nil
+}
 
-	err = w.signer.RegisterEthSigner(ethKeys...)
-	if err != nil {
-		return nil, err
+// runEvery calls fn every interval until close, an interval of 0 or less disables fn
+// until the config is reloaded with a positive one
+func runEvery(interval func() time.Duration, fn func(), close <-chan struct{}) {
+	for {
+		wait := interval()
+		if wait <= 0 {
+			// disabled, the config may be reloaded
+			wait = time.Minute
+		}
+
+		select {
+		case <-time.After(wait):
+			if interval() <= 0 {
+				continue
+			}
+
+			fn()
+		case <-close:
+			return
+		}
 	}
-
-	return w, nil
 }
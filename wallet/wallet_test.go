@@ -5,6 +5,7 @@ import (
 	"github.com/OpenFilWallet/OpenFilWallet/datastore"
 	"github.com/OpenFilWallet/OpenFilWallet/repo"
 	"github.com/stretchr/testify/require"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -23,10 +24,10 @@ func TestWallet_TxHistory(t *testing.T) {
 
 	db := datastore.NewWalletDB(ds)
 
-	n, err := newNode("glif", "https://api.node.glif.io/rpc/v0", "")
+	n, err := newNode(context.Background(), "glif", "https://api.node.glif.io/rpc/v0", "")
 	require.NoError(t, err)
 
-	txTracker := newTxTracker(n, db, nil)
+	txTracker := newTxTracker(newCurrentNode(n), db, nil, 50, func() time.Duration { return time.Minute }, nil)
 
 	txTracker.trackTx(&datastore.History{
 		Version:    0,
@@ -59,3 +60,44 @@ func TestWallet_TxHistory(t *testing.T) {
 		return
 	}
 }
+
+func TestRunEvery(t *testing.T) {
+	var interval atomic.Int64
+	interval.Store(int64(time.Millisecond))
+
+	calls := make(chan struct{}, 1)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		runEvery(func() time.Duration { return time.Duration(interval.Load()) }, func() {
+			select {
+			case calls <- struct{}{}:
+			default:
+			}
+		}, stop)
+		done <- struct{}{}
+	}()
+
+	select {
+	case <-calls:
+	case <-time.After(time.Second):
+		t.Fatal("fn was not called")
+	}
+
+	// a disabled interval waits for a reload instead of spinning
+	interval.Store(0)
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-calls:
+	default:
+	}
+	time.Sleep(10 * time.Millisecond)
+	require.Empty(t, calls)
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("runEvery did not return on close")
+	}
+}
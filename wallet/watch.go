@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"context"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/filecoin-project/go-address"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// WatchList Get
+func (w *Wallet) WatchList(c *gin.Context) {
+	watches, err := w.db.WatchList()
+	if err != nil {
+		log.Warnw("WatchList: WatchList", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	infos := make([]client.WatchInfo, 0, len(watches))
+	for _, watch := range watches {
+		infos = append(infos, client.WatchInfo{
+			Address: watch.Address,
+			Label:   watch.Label,
+			AddedAt: watch.AddedAt,
+		})
+	}
+
+	ReturnOk(c, infos)
+}
+
+// WatchAdd Post
+func (w *Wallet) WatchAdd(c *gin.Context) {
+	param := client.WatchRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("WatchAdd: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	addr, err := address.NewFromString(param.Address)
+	if err != nil || param.FromEpoch < 0 {
+		log.Warnw("WatchAdd: NewFromString", "address", param.Address, "fromEpoch", param.FromEpoch, "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	err = w.db.SetWatch(&datastore.WatchedAddress{
+		Address: addr.String(),
+		Label:   param.Label,
+		AddedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Warnw("WatchAdd: SetWatch", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	if param.FromEpoch != 0 {
+		w.indexer.rewind(param.FromEpoch)
+	}
+
+	ReturnOk(c, nil)
+}
+
+// WatchRemove Post
+func (w *Wallet) WatchRemove(c *gin.Context) {
+	param := client.WatchRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("WatchRemove: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	addr, err := address.NewFromString(param.Address)
+	if err != nil {
+		log.Warnw("WatchRemove: NewFromString", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	has, err := w.db.HasWatch(addr.String())
+	if err != nil || !has {
+		log.Warnw("WatchRemove: HasWatch", "address", addr, "err", err)
+		ReturnError(c, NewError(500, "address is not watched"))
+		return
+	}
+
+	err = w.db.DeleteWatch(addr.String())
+	if err != nil {
+		log.Warnw("WatchRemove: DeleteWatch", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, nil)
+}
+
+// IndexerStatus Get
+func (w *Wallet) IndexerStatus(c *gin.Context) {
+	state, err := w.db.GetIndexerState()
+	if err != nil {
+		log.Warnw("IndexerStatus: GetIndexerState", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	cfg := w.indexerConfig()
+	status := client.IndexerStatus{
+		Enable:     cfg.Enable,
+		StartEpoch: int64(cfg.StartEpoch),
+		Height:     state.Height,
+		UpdatedAt:  state.UpdatedAt,
+	}
+
+	if n := w.node(); n != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+		defer cancel()
+
+		head, err := n.Api.ChainHead(ctx)
+		if err == nil {
+			status.Head = int64(head.Height())
+		}
+	}
+
+	ReturnOk(c, status)
+}
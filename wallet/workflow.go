@@ -0,0 +1,432 @@
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/OpenFilWallet/OpenFilWallet/chain"
+	"github.com/OpenFilWallet/OpenFilWallet/client"
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/buildmessage"
+	"github.com/OpenFilWallet/OpenFilWallet/modules/events"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/builtin"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/types"
+	"github.com/gin-gonic/gin"
+	"sort"
+	"time"
+)
+
+// the next actions of a workflow, named after the miner commands that send them
+const (
+	confirmChangeWorker      = "confirm_change_worker"
+	confirmChangeOwner       = "confirm_change_owner"
+	confirmChangeBeneficiary = "confirm_change_beneficiary"
+)
+
+// workflowStartTimeout is how long a started workflow waits for its first message to land on chain
+const workflowStartTimeout = 24 * time.Hour
+
+func workflowActive(wf datastore.Workflow) bool {
+	return wf.State != datastore.WorkflowDone && wf.State != datastore.WorkflowCancelled
+}
+
+// startWorkflow records the change of the miner to target built by the user. An active workflow of the same
+// change is kept, one of the same kind to another target is replaced, a failure is only logged as the message
+// is already built
+func (w *Wallet) startWorkflow(node api.FullNode, kind datastore.WorkflowKind, minerId, target string, autoConfirm bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	err := func() error {
+		minerAddr, err := address.NewFromString(minerId)
+		if err != nil {
+			return err
+		}
+
+		targetAddr, err := address.NewFromString(target)
+		if err != nil {
+			return err
+		}
+
+		targetId, err := node.StateLookupID(ctx, targetAddr, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		wfs, err := w.db.WorkflowList()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().Unix()
+		for _, wf := range wfs {
+			if !workflowActive(wf) || wf.Kind != kind || wf.MinerId != minerAddr.String() {
+				continue
+			}
+
+			if wf.Target == targetId.String() {
+				if autoConfirm && !wf.AutoConfirm {
+					wf.AutoConfirm = true
+					wf.UpdatedAt = now
+					return w.saveWorkflow(&wf)
+				}
+				return nil
+			}
+
+			wf.State = datastore.WorkflowCancelled
+			wf.Detail = fmt.Sprintf("replaced by the change to %s", targetId)
+			wf.UpdatedAt = now
+			if err := w.saveWorkflow(&wf); err != nil {
+				return err
+			}
+		}
+
+		return w.saveWorkflow(&datastore.Workflow{
+			ID:          fmt.Sprintf("%s-%s-%d", kind, minerAddr, now),
+			Kind:        kind,
+			MinerId:     minerAddr.String(),
+			Target:      targetId.String(),
+			State:       datastore.WorkflowStarted,
+			NextAction:  string(kind),
+			AutoConfirm: autoConfirm,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+	}()
+	if err != nil {
+		log.Warnw("startWorkflow: workflow not recorded", "kind", kind, "miner", minerId, "target", target, "err", err)
+	}
+}
+
+// saveWorkflow stores wf and publishes it to the event subscribers
+func (w *Wallet) saveWorkflow(wf *datastore.Workflow) error {
+	if err := w.db.SetWorkflow(wf); err != nil {
+		return err
+	}
+
+	info, err := workflowResponse(*wf, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Infow("saveWorkflow: workflow changed", "id", wf.ID, "state", wf.State, "nextAction", wf.NextAction, "readyEpoch", wf.ReadyEpoch, "detail", wf.Detail)
+	w.events.Publish(events.MinerWorkflowChanged, info)
+	return nil
+}
+
+// advanceWorkflow moves wf to the state shown by the miner info at head. A workflow whose change is gone from
+// the miner info without being applied is cancelled, as is one whose first message never landed
+func advanceWorkflow(wf *datastore.Workflow, mi api.MinerInfo, head abi.ChainEpoch, now time.Time) {
+	next := func(action string, sender address.Address, readyEpoch abi.ChainEpoch) {
+		wf.NextAction = action
+		wf.NextSender = sender.String()
+		wf.ReadyEpoch = int64(readyEpoch)
+		wf.State = datastore.WorkflowReady
+		if head < readyEpoch {
+			wf.State = datastore.WorkflowWaiting
+		}
+		wf.Detail = ""
+	}
+	finish := func(state datastore.WorkflowState, detail string) {
+		wf.State = state
+		wf.NextAction = ""
+		wf.NextSender = ""
+		wf.ReadyEpoch = 0
+		wf.Detail = detail
+	}
+	// an action possible at once is ready from the epoch it was first seen
+	seen := abi.ChainEpoch(wf.ReadyEpoch)
+	if wf.State == datastore.WorkflowStarted || seen == 0 {
+		seen = head
+	}
+
+	pending := true
+	switch wf.Kind {
+	case datastore.WorkflowChangeWorker:
+		if mi.Worker.String() == wf.Target {
+			finish(datastore.WorkflowDone, "")
+		} else if mi.NewWorker.String() == wf.Target {
+			next(confirmChangeWorker, mi.Owner, mi.WorkerChangeEpoch)
+		} else {
+			pending = false
+		}
+	case datastore.WorkflowChangeOwner:
+		if mi.Owner.String() == wf.Target {
+			finish(datastore.WorkflowDone, "")
+		} else if mi.PendingOwnerAddress != nil && mi.PendingOwnerAddress.String() == wf.Target {
+			next(confirmChangeOwner, *mi.PendingOwnerAddress, seen)
+		} else {
+			pending = false
+		}
+	case datastore.WorkflowChangeBeneficiary:
+		if term := mi.PendingBeneficiaryTerm; term != nil && term.NewBeneficiary.String() == wf.Target {
+			next(confirmChangeBeneficiary, buildmessage.BeneficiaryApprover(mi), seen)
+		} else if mi.Beneficiary.String() == wf.Target {
+			finish(datastore.WorkflowDone, "")
+		} else {
+			pending = false
+		}
+	default:
+		finish(datastore.WorkflowCancelled, fmt.Sprintf("unknown workflow kind %s", wf.Kind))
+		return
+	}
+
+	if !pending {
+		if wf.State != datastore.WorkflowStarted {
+			finish(datastore.WorkflowCancelled, "the pending change is gone from the miner info")
+		} else if now.Sub(time.Unix(wf.CreatedAt, 0)) > workflowStartTimeout {
+			finish(datastore.WorkflowCancelled, "the first message was not seen on chain")
+		}
+	}
+
+	// a confirm message is only valid for the sender that is expected now
+	if wf.State != datastore.WorkflowReady || wf.ConfirmSender != wf.NextSender {
+		wf.ConfirmMessage = ""
+		wf.ConfirmSender = ""
+	}
+}
+
+func (w *Wallet) workflowLoop(close <-chan struct{}) {
+	interval := func() time.Duration {
+		return w.workflowConfig().Interval.Duration()
+	}
+
+	runEvery(interval, func() {
+		if w.offline || w.node() == nil {
+			return
+		}
+
+		w.followWorkflows()
+	}, close)
+}
+
+// followWorkflows advances every active workflow and builds the confirm messages of the ready ones with auto confirm
+func (w *Wallet) followWorkflows() {
+	wfs, err := w.db.WorkflowList()
+	if err != nil {
+		log.Warnw("followWorkflows: WorkflowList", "err", err)
+		return
+	}
+
+	var active []datastore.Workflow
+	for _, wf := range wfs {
+		if workflowActive(wf) {
+			active = append(active, wf)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	node, err := w.buildNode()
+	if err != nil {
+		log.Warnw("followWorkflows: buildNode", "err", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+	defer cancel()
+
+	head, err := node.ChainHead(ctx)
+	if err != nil {
+		log.Warnw("followWorkflows: ChainHead", "err", err)
+		return
+	}
+
+	for _, wf := range active {
+		old := wf
+
+		minerAddr, err := address.NewFromString(wf.MinerId)
+		if err != nil {
+			log.Warnw("followWorkflows: NewFromString", "id", wf.ID, "err", err)
+			continue
+		}
+
+		mi, err := node.StateMinerInfo(ctx, minerAddr, head.Key())
+		if err != nil {
+			log.Warnw("followWorkflows: StateMinerInfo", "id", wf.ID, "err", err)
+			continue
+		}
+
+		advanceWorkflow(&wf, mi, head.Height(), time.Now())
+		if wf.AutoConfirm && wf.State == datastore.WorkflowReady && wf.ConfirmMessage == "" {
+			w.buildConfirm(node, &wf)
+		}
+
+		if wf == old {
+			continue
+		}
+
+		wf.UpdatedAt = time.Now().Unix()
+		if err := w.saveWorkflow(&wf); err != nil {
+			log.Warnw("followWorkflows: saveWorkflow", "id", wf.ID, "err", err)
+		}
+	}
+}
+
+// buildConfirm builds the unsigned confirm message of the next action of wf, the error is kept in the detail
+func (w *Wallet) buildConfirm(node api.FullNode, wf *datastore.Workflow) {
+	var (
+		msg       *types.Message
+		msgParams interface{}
+		err       error
+	)
+	switch wf.NextAction {
+	case confirmChangeWorker:
+		msg, err = buildmessage.NewConfirmUpdateWorkerMessage(node, buildmessage.BaseParams{}, wf.MinerId, wf.Target)
+	case confirmChangeOwner:
+		msg, msgParams, err = buildmessage.NewChangeOwnerMessage(node, buildmessage.BaseParams{}, wf.MinerId, wf.Target, wf.Target)
+	case confirmChangeBeneficiary:
+		msg, msgParams, err = buildmessage.NewConfirmChangeBeneficiary(node, buildmessage.BaseParams{}, wf.MinerId)
+	default:
+		err = fmt.Errorf("unknown next action %s", wf.NextAction)
+	}
+
+	if err == nil {
+		// the confirm message is signed and pushed out of the wallet, it does not hold the nonce
+		w.releaseNonce(buildmessage.BaseParams{}, msg)
+
+		var myMsg *chain.Message
+		myMsg, err = chain.EncodeMessage(msg, msgParams)
+		if err == nil {
+			var data []byte
+			data, err = json.Marshal(myMsg)
+			if err == nil {
+				wf.ConfirmMessage = string(data)
+				wf.ConfirmSender = wf.NextSender
+				wf.Detail = ""
+				return
+			}
+		}
+	}
+
+	wf.Detail = fmt.Sprintf("building the confirm message: %s", err)
+}
+
+// WorkflowList Get, the done and cancelled workflows are listed with all=true
+func (w *Wallet) WorkflowList(c *gin.Context) {
+	wfs, err := w.db.WorkflowList()
+	if err != nil {
+		log.Warnw("WorkflowList: WorkflowList", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	// newest first
+	sort.Slice(wfs, func(i, j int) bool {
+		return wfs[i].CreatedAt > wfs[j].CreatedAt
+	})
+
+	var head *types.TipSet
+	if n := w.node(); !w.offline && n != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), w.requestTimeout())
+		head, err = n.Api.ChainHead(ctx)
+		cancel()
+		if err != nil {
+			// the ready times are only estimates, the list is still useful without them
+			log.Warnw("WorkflowList: ChainHead", "err", err)
+			head = nil
+		}
+	}
+
+	user := w.requestUser(c)
+	minerId := c.Query("miner_id")
+	all := c.Query("all") == "true"
+	infos := make([]client.Workflow, 0, len(wfs))
+	for _, wf := range wfs {
+		if minerId != "" && !sameAddress(wf.MinerId, minerId) {
+			continue
+		}
+		if !userAllowsAddress(user, wf.MinerId) {
+			continue
+		}
+		if !all && !workflowActive(wf) {
+			continue
+		}
+
+		info, err := workflowResponse(wf, head)
+		if err != nil {
+			log.Warnw("WorkflowList: workflowResponse", "id", wf.ID, "err", err)
+			ReturnError(c, NewError(500, err.Error()))
+			return
+		}
+		infos = append(infos, info)
+	}
+
+	ReturnOk(c, infos)
+}
+
+// WorkflowCancel Post, the workflow is no longer followed, nothing is sent on chain
+func (w *Wallet) WorkflowCancel(c *gin.Context) {
+	param := client.WorkflowCancelRequest{}
+	err := c.BindJSON(&param)
+	if err != nil {
+		log.Warnw("WorkflowCancel: BindJSON", "err", err)
+		ReturnError(c, ParamErr)
+		return
+	}
+
+	wf, err := w.db.GetWorkflow(param.ID)
+	if err != nil {
+		log.Warnw("WorkflowCancel: GetWorkflow", "id", param.ID, "err", err)
+		ReturnError(c, NewError(500, "workflow does not exist"))
+		return
+	}
+
+	if !userAllowsAddress(w.requestUser(c), wf.MinerId) {
+		ReturnError(c, NewError(505, fmt.Sprintf("not allowed to use %s", wf.MinerId)))
+		return
+	}
+
+	if !workflowActive(*wf) {
+		ReturnError(c, NewError(500, fmt.Sprintf("workflow is already %s", wf.State)))
+		return
+	}
+
+	wf.State = datastore.WorkflowCancelled
+	wf.Detail = "cancelled by the user"
+	wf.UpdatedAt = time.Now().Unix()
+	err = w.saveWorkflow(wf)
+	if err != nil {
+		log.Warnw("WorkflowCancel: saveWorkflow", "err", err)
+		ReturnError(c, NewError(500, err.Error()))
+		return
+	}
+
+	ReturnOk(c, nil)
+}
+
+// workflowResponse converts wf, the ready time is estimated from head when it is known
+func workflowResponse(wf datastore.Workflow, head *types.TipSet) (client.Workflow, error) {
+	info := client.Workflow{
+		ID:          wf.ID,
+		Kind:        string(wf.Kind),
+		MinerId:     wf.MinerId,
+		Target:      wf.Target,
+		State:       string(wf.State),
+		NextAction:  wf.NextAction,
+		NextSender:  wf.NextSender,
+		ReadyEpoch:  wf.ReadyEpoch,
+		AutoConfirm: wf.AutoConfirm,
+		Detail:      wf.Detail,
+		CreatedAt:   wf.CreatedAt,
+		UpdatedAt:   wf.UpdatedAt,
+	}
+
+	if head != nil && wf.ReadyEpoch != 0 {
+		info.ReadyTime = int64(head.MinTimestamp()) + (wf.ReadyEpoch-int64(head.Height()))*int64(builtin.EpochDurationSeconds)
+	}
+
+	if wf.ConfirmMessage != "" {
+		var msg chain.Message
+		if err := json.Unmarshal([]byte(wf.ConfirmMessage), &msg); err != nil {
+			return client.Workflow{}, err
+		}
+		info.ConfirmMessage = &msg
+	}
+
+	return info, nil
+}
@@ -0,0 +1,99 @@
+package wallet
+
+import (
+	"github.com/OpenFilWallet/OpenFilWallet/datastore"
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/filecoin-project/lotus/api"
+	"github.com/filecoin-project/lotus/chain/actors/builtin/miner"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAdvanceWorkflow(t *testing.T) {
+	owner, _ := address.NewIDAddress(1001)
+	worker, _ := address.NewIDAddress(1002)
+	target, _ := address.NewIDAddress(1003)
+	now := time.Now()
+
+	newWorkflow := func(kind datastore.WorkflowKind) *datastore.Workflow {
+		return &datastore.Workflow{
+			ID:        "wf",
+			Kind:      kind,
+			MinerId:   "f01000",
+			Target:    target.String(),
+			State:     datastore.WorkflowStarted,
+			CreatedAt: now.Unix(),
+		}
+	}
+	mi := api.MinerInfo{Owner: owner, Worker: worker, Beneficiary: owner, NewWorker: address.Undef}
+
+	// the change worker message has not landed yet
+	wf := newWorkflow(datastore.WorkflowChangeWorker)
+	advanceWorkflow(wf, mi, 100, now)
+	require.Equal(t, datastore.WorkflowStarted, wf.State)
+
+	// it is never seen on chain
+	advanceWorkflow(wf, mi, 100, now.Add(workflowStartTimeout+time.Minute))
+	require.Equal(t, datastore.WorkflowCancelled, wf.State)
+
+	wf = newWorkflow(datastore.WorkflowChangeWorker)
+	pendingWorker := mi
+	pendingWorker.NewWorker = target
+	pendingWorker.WorkerChangeEpoch = 200
+	advanceWorkflow(wf, pendingWorker, 100, now)
+	require.Equal(t, datastore.WorkflowWaiting, wf.State)
+	require.Equal(t, confirmChangeWorker, wf.NextAction)
+	require.Equal(t, owner.String(), wf.NextSender)
+	require.Equal(t, int64(200), wf.ReadyEpoch)
+
+	advanceWorkflow(wf, pendingWorker, 200, now)
+	require.Equal(t, datastore.WorkflowReady, wf.State)
+
+	// a confirm message built for another sender is dropped
+	wf.ConfirmMessage, wf.ConfirmSender = "{}", worker.String()
+	advanceWorkflow(wf, pendingWorker, 201, now)
+	require.Empty(t, wf.ConfirmMessage)
+
+	changed := mi
+	changed.Worker = target
+	advanceWorkflow(wf, changed, 202, now)
+	require.Equal(t, datastore.WorkflowDone, wf.State)
+	require.Empty(t, wf.NextAction)
+
+	// the pending change is replaced outside of the wallet
+	wf = newWorkflow(datastore.WorkflowChangeWorker)
+	advanceWorkflow(wf, pendingWorker, 100, now)
+	advanceWorkflow(wf, mi, 101, now)
+	require.Equal(t, datastore.WorkflowCancelled, wf.State)
+
+	// the new owner confirms from the epoch the change was first seen
+	wf = newWorkflow(datastore.WorkflowChangeOwner)
+	pendingOwner := mi
+	pendingOwner.PendingOwnerAddress = &target
+	advanceWorkflow(wf, pendingOwner, 150, now)
+	require.Equal(t, datastore.WorkflowReady, wf.State)
+	require.Equal(t, confirmChangeOwner, wf.NextAction)
+	require.Equal(t, target.String(), wf.NextSender)
+	advanceWorkflow(wf, pendingOwner, 160, now)
+	require.Equal(t, int64(150), wf.ReadyEpoch)
+
+	// the nominee approves first, the owner is the current beneficiary so the change is then applied
+	wf = newWorkflow(datastore.WorkflowChangeBeneficiary)
+	pendingBeneficiary := mi
+	pendingBeneficiary.PendingBeneficiaryTerm = &miner.PendingBeneficiaryChange{
+		NewBeneficiary: target,
+		NewQuota:       big.NewInt(100),
+		NewExpiration:  1000,
+	}
+	advanceWorkflow(wf, pendingBeneficiary, 100, now)
+	require.Equal(t, datastore.WorkflowReady, wf.State)
+	require.Equal(t, confirmChangeBeneficiary, wf.NextAction)
+	require.Equal(t, target.String(), wf.NextSender)
+
+	changed = mi
+	changed.Beneficiary = target
+	advanceWorkflow(wf, changed, 101, now)
+	require.Equal(t, datastore.WorkflowDone, wf.State)
+}
@@ -1,9 +1,11 @@
 import request from '@/utils/request'
 
-export function withdraw(minerId, amount) {
+export function withdraw(minerId, amount, sender, from) {
     const data = {
         "miner_id": minerId,
-        "amount": amount
+        "amount": amount,
+        "sender": sender,
+        "from": from
     }
 
     return request({
@@ -13,6 +15,14 @@ export function withdraw(minerId, amount) {
     })
 }
 
+export function minerInfo(minerId) {
+    return request({
+        url: '/miner/info',
+        method: 'get',
+        params: { "miner_id": minerId }
+    })
+}
+
 export function changeOwner(minerId, newOwnerAddr, fromAddr) {
     const data = {
         "miner_id": minerId,